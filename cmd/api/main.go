@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	_ "tool/database/indexes"        // 加载 MongoDB 索引，开启 AutoIndex 时创建连接后同步
 	_ "tool/server/http/routers/api" // 加载api路由
 )
 
@@ -33,6 +34,7 @@ func startServerInForeground() {
 			//middleware.SessionMiddleware(),
 			middleware.ValidateParams(),
 			middleware.Cors(),
+			middleware.DbSticky(),
 		},
 	}
 
@@ -1,11 +1,18 @@
 package main
 
 import (
-	"fmt"
-	"net"
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"tool/bootstrap"
 	"tool/global/variable"
+	"tool/pkg/event_manage"
+	"tool/pkg/udp"
+	"tool/server/job" // 加载任务
+
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -13,31 +20,35 @@ func main() {
 	// 初始化全局变量
 	bootstrap.Initialize()
 
-	port := variable.ConfigYml.GetInt("JobServer.Port")
-	ip := variable.ConfigYml.GetString("JobServer.Ip")
+	//初始化协程池
+	bootstrap.InitPool(variable.ConfigYml.GetInt("JobServer.WorkNum"))
 
-	addr := net.UDPAddr{
-		Port: port,
-		IP:   net.ParseIP(ip),
-	}
+	server := udp.NewServer(variable.Pool)
 
-	conn, err := net.ListenUDP("udp", &addr)
-	if err != nil {
-		fmt.Println("Error starting UDP server:", err)
-		os.Exit(1)
-	}
-	defer conn.Close()
+	// 定期清理过期的分片上传会话
+	job.ScheduleUploadClean()
 
-	fmt.Println("UDP server is listening on port", port)
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGTERM)
 
-	buf := make([]byte, 1024)
-	for {
-		n, addr, err := conn.ReadFromUDP(buf)
-		if err != nil {
-			fmt.Println("Error reading from UDP:", err)
-			continue
+		received := <-c
+		variable.Logs.Warn("ProcessKilled", zap.String("信号值", received.String()))
+
+		// 等待正在执行的任务完成
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Stop(ctx); err != nil {
+			variable.Logs.Error("Job server stop error", zap.Error(err))
 		}
+	}()
 
-		fmt.Printf("Received message from %v: %s\n", addr, string(buf[:n]))
+	if err := server.ListenAndServe(); err != nil {
+		variable.Logs.Fatal("Error starting job server", zap.Error(err))
 	}
+
+	variable.Pool.Release()
+
+	// 自定义的销毁逻辑
+	(event_manage.CreateEventManageFactory()).FuzzyCall(variable.EventDestroyPrefix)
 }
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"tool/bootstrap"
+	"tool/global/variable"
+	"tool/pkg/event_manage"
+	"tool/pkg/migrate"
+	"tool/pkg/mongo"
+	"tool/pkg/seed"
+
+	_ "tool/database/indexes"    // 加载 MongoDB 索引
+	_ "tool/database/migrations" // 加载 Go 迁移
+	_ "tool/database/seeds"      // 加载数据填充
+)
+
+const usage = `用法: go run cmd/migrate/main.go [参数] <命令>
+
+命令:
+  status          查看迁移状态
+  up [N]          执行未执行的迁移，N 为执行个数，默认全部
+  down [N]        回滚最近的 N 个迁移，默认 1
+  redo            回滚最近一个迁移后重新执行
+  create <name>   创建迁移文件
+  seed [name...]  执行数据填充，默认全部，依赖会自动执行
+  mongo-index [status|sync]
+                  查看或同步 MongoDB 索引和校验规则，默认 status，-drop 时重建不一致的索引并删除未声明的索引
+
+参数:
+`
+
+func main() {
+
+	conns := flag.String("conn", "Local", "mysql.yml 中的连接名称，多个用逗号分隔")
+	dir := flag.String("dir", "", "迁移文件目录，默认 database/migrations")
+	goMigration := flag.Bool("go", false, "create 时创建 Go 迁移")
+	drop := flag.Bool("drop", false, "mongo-index sync 时删除不一致和未声明的索引")
+
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// 初始化全局变量
+	bootstrap.Initialize()
+
+	if *dir == "" {
+		*dir = variable.BasePath + "/database/migrations"
+	}
+
+	code := 0
+	switch args[0] {
+	case "seed":
+		// 数据填充按各自声明的连接执行，不受 -conn 参数影响
+		done, err := seed.Run(context.Background(), args[1:]...)
+		for _, name := range done {
+			fmt.Println("填充:", name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		}
+	case "mongo-index":
+		// 按注册时声明的连接执行，不受 -conn 参数影响
+		if err := mongoIndex(args[1:], *drop); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		}
+	default:
+		for _, conn := range strings.Split(*conns, ",") {
+			if err := run(strings.TrimSpace(conn), *dir, *goMigration, args); err != nil {
+				fmt.Fprintf(os.Stderr, "[%s] %v\n", conn, err)
+				code = 1
+				break
+			}
+		}
+	}
+
+	// 自定义的销毁逻辑
+	(event_manage.CreateEventManageFactory()).FuzzyCall(variable.EventDestroyPrefix)
+
+	os.Exit(code)
+}
+
+// run 对单个连接执行命令
+func run(conn, dir string, goMigration bool, args []string) error {
+	command := args[0]
+
+	if command == "create" {
+		if len(args) < 2 {
+			return fmt.Errorf("缺少迁移名称")
+		}
+		files, err := migrate.Create(dir, conn, args[1], goMigration)
+		for _, file := range files {
+			fmt.Println("创建:", file)
+		}
+		return err
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("步数错误: %s", args[1])
+		}
+		steps = n
+	}
+
+	migrator, err := migrate.New(conn, dir)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "status":
+		list, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(conn, list)
+	case "up":
+		done, err := migrator.Up(ctx, steps)
+		printDone(conn, "执行", done)
+		return err
+	case "down":
+		done, err := migrator.Down(ctx, steps)
+		printDone(conn, "回滚", done)
+		return err
+	case "redo":
+		done, err := migrator.Redo(ctx)
+		if done != nil {
+			printDone(conn, "重新执行", []*migrate.Migration{done})
+		}
+		return err
+	default:
+		return fmt.Errorf("未知命令: %s", command)
+	}
+
+	return nil
+}
+
+// mongoIndex 查看或同步 MongoDB 索引
+func mongoIndex(args []string, drop bool) error {
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	var (
+		list []mongo.IndexStatus
+		err  error
+	)
+
+	switch command {
+	case "status":
+		list, err = mongo.Status(context.Background())
+	case "sync":
+		list, err = mongo.Sync(context.Background(), drop)
+	default:
+		return fmt.Errorf("未知命令: mongo-index %s", command)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "连接\t集合\t索引\t状态\t说明")
+	for _, s := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Conn, s.Collection, s.Name, s.State, s.Detail)
+	}
+	w.Flush()
+
+	return err
+}
+
+// printStatus 输出迁移状态
+func printStatus(conn string, list []migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "[%s]\n", conn)
+	fmt.Fprintln(w, "版本\t名称\t类型\t状态\t批次\t执行时间")
+
+	for _, s := range list {
+		state, batch, appliedAt := "未执行", "", ""
+		switch {
+		case s.Missing:
+			state = "文件缺失"
+		case s.Modified:
+			state = "已修改"
+		case s.Applied:
+			state = "已执行"
+		}
+		if s.Applied {
+			batch = strconv.Itoa(s.Batch)
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Version, s.Name, s.Kind, state, batch, appliedAt)
+	}
+
+	w.Flush()
+}
+
+// printDone 输出本次执行的迁移
+func printDone(conn, action string, done []*migrate.Migration) {
+	if len(done) == 0 {
+		fmt.Printf("[%s] 没有需要%s的迁移\n", conn, action)
+		return
+	}
+	for _, m := range done {
+		fmt.Printf("[%s] %s: %s_%s\n", conn, action, m.Version, m.Name)
+	}
+}
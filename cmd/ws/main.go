@@ -63,6 +63,9 @@ func startServerInForeground() {
 
 	go handle.HandleMsg()
 
+	// 推送 MongoDB 变更
+	handle.WatchMongo()
+
 	server.Start()
 
 }
@@ -0,0 +1,23 @@
+// Package indexes 注册 MongoDB 集合的索引和校验规则
+//
+//	go run cmd/migrate/main.go mongo-index status       # 查看差异
+//	go run cmd/migrate/main.go mongo-index sync         # 创建缺少的索引
+//	go run cmd/migrate/main.go -drop mongo-index sync   # 同时重建不一致的索引并删除未声明的索引
+//
+// mongo.yml 中开启 AutoIndex 时，创建连接后自动同步（不删除索引）
+package indexes
+
+import (
+	"tool/pkg/mongo"
+	"tool/server/http/model"
+)
+
+func init() {
+
+	// ChatGPT 对话记录
+	mongo.RegisterCollection(mongo.CollectionSpec{
+		Collection: model.ChatgptLog{}.CollectionName(),
+		Indexes:    mongo.IndexesOf[model.ChatgptLog](),
+		Schema:     mongo.JSONSchema[model.ChatgptLog](),
+	})
+}
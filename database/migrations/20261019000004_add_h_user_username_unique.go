@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"tool/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 用户名唯一，users 填充按 username 执行 ON DUPLICATE KEY UPDATE，重复执行不会插入重复用户；
+// 包含 delete_time，软删除后可以重新使用用户名
+func init() {
+	migrate.Register("Local", "20261019000004", "add_h_user_username_unique",
+		func(ctx context.Context, db *gorm.DB) error {
+			// 已有重复用户名时不自动删除，由运维确认保留哪条记录后再执行
+			var duplicates []string
+			err := db.Raw("SELECT CONCAT(`username`, ' (', COUNT(*), ')') FROM `h_user` " +
+				"GROUP BY `username`, `delete_time` HAVING COUNT(*) > 1 LIMIT 20").Scan(&duplicates).Error
+			if err != nil {
+				return err
+			}
+			if len(duplicates) > 0 {
+				return fmt.Errorf("h_user 存在重复的用户名，请处理后重新执行迁移: %s", strings.Join(duplicates, ", "))
+			}
+
+			return db.Exec("ALTER TABLE `h_user` DROP INDEX `idx_username`, ADD UNIQUE KEY `uk_username` (`username`, `delete_time`)").Error
+		},
+		func(ctx context.Context, db *gorm.DB) error {
+			return db.Exec("ALTER TABLE `h_user` DROP INDEX `uk_username`, ADD KEY `idx_username` (`username`)").Error
+		},
+	)
+}
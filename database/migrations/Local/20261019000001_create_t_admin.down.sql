@@ -0,0 +1 @@
+-- 基线迁移，表在引入迁移前已存在，up 只在新库中建表，回滚不能删除已有数据，因此不可回滚
@@ -0,0 +1,12 @@
+-- 后台管理员表，对应 model.Admin
+CREATE TABLE IF NOT EXISTS `t_admin` (
+  `id` int NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `username` varchar(20) NOT NULL COMMENT '用户名',
+  `password` varchar(40) NOT NULL COMMENT '密码',
+  `last_login_time` datetime DEFAULT NULL COMMENT '上次登录时间',
+  `login_status` tinyint NOT NULL DEFAULT 1 COMMENT '登录状态 0禁用 1启用',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  `update_time` datetime DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_username` (`username`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='后台管理员';
@@ -0,0 +1,15 @@
+-- 用户表，对应 controller.User
+CREATE TABLE IF NOT EXISTS `h_user` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `username` varchar(50) NOT NULL DEFAULT '' COMMENT '用户名',
+  `password` varchar(64) NOT NULL DEFAULT '' COMMENT '密码',
+  `avatar` varchar(255) NOT NULL DEFAULT '' COMMENT '头像',
+  `type` int NOT NULL DEFAULT 0 COMMENT '类型',
+  `last_login_time` int NOT NULL DEFAULT 0 COMMENT '上次登录时间',
+  `login_status` int NOT NULL DEFAULT 1 COMMENT '登录状态',
+  `create_time` int NOT NULL DEFAULT 0 COMMENT '创建时间',
+  `update_time` int NOT NULL DEFAULT 0 COMMENT '更新时间',
+  `delete_time` int NOT NULL DEFAULT 0 COMMENT '删除时间，0 表示未删除',
+  PRIMARY KEY (`id`),
+  KEY `idx_username` (`username`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户';
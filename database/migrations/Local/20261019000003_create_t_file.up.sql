@@ -0,0 +1,12 @@
+-- 直传文件表，对应 model.File
+CREATE TABLE IF NOT EXISTS `t_file` (
+  `id` int NOT NULL AUTO_INCREMENT COMMENT '主键',
+  `disk` varchar(50) NOT NULL COMMENT '磁盘名称',
+  `key` varchar(255) NOT NULL COMMENT '对象 key',
+  `size` bigint NOT NULL DEFAULT 0 COMMENT '字节数',
+  `content_type` varchar(100) NOT NULL DEFAULT '' COMMENT '类型',
+  `etag` varchar(100) NOT NULL DEFAULT '' COMMENT '存储返回的 ETag',
+  `create_time` datetime DEFAULT NULL COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_disk_key` (`disk`, `key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='直传文件';
@@ -0,0 +1,7 @@
+// Package migrations 存放 Go 迁移，SQL 迁移存放在以连接名称命名的子目录中
+//
+// 创建迁移：
+//
+//	go run cmd/migrate/main.go create add_admin_avatar       # SQL 迁移
+//	go run cmd/migrate/main.go -go create backfill_admin     # Go 迁移
+package migrations
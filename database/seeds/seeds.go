@@ -0,0 +1,54 @@
+// Package seeds 存放数据填充，数据文件位于 fixtures 目录
+//
+//	go run cmd/migrate/main.go seed                  # 执行全部填充
+//	go run cmd/migrate/main.go seed admin users      # 执行指定填充及其依赖
+package seeds
+
+import (
+	"context"
+	"time"
+	"tool/global/utils/common"
+	"tool/global/variable"
+	"tool/pkg/seed"
+	"tool/server/http/model"
+
+	"gorm.io/gorm"
+)
+
+// fixture 数据文件路径
+func fixture(name string) string {
+	return variable.BasePath + "/database/seeds/fixtures/" + name
+}
+
+func init() {
+
+	// 默认管理员，账号 admin 密码 123456
+	seed.Register(seed.Seeder{
+		Name: "admin",
+		Mysql: func(ctx context.Context, db *gorm.DB) error {
+			now := model.LocalTime(time.Now())
+			admins := []model.Admin{{
+				Username:    "admin",
+				Password:    common.Md5("123456"),
+				LoginStatus: 1,
+				CreateTime:  &now,
+				UpdateTime:  &now,
+			}}
+			return seed.UpsertMysql(ctx, db, admins, []string{"username"}, "login_status", "update_time")
+		},
+	})
+
+	// 示例用户，按 username 更新，依赖 h_user 的 uk_username 唯一索引，重复执行不会插入重复用户
+	seed.Register(seed.Seeder{
+		Name:    "users",
+		Depends: []string{"admin"},
+		Mysql:   seed.MysqlFixture(fixture("h_user.yml")),
+	})
+
+	// 示例 ChatGPT 日志
+	seed.Register(seed.Seeder{
+		Name:    "chatgpt_log",
+		Depends: []string{"users"},
+		Mongo:   seed.MongoFixture(fixture("t_chatgpt_log.yml")),
+	})
+}
@@ -0,0 +1,7 @@
+package enum
+
+// 事件总线主题，ws 与 api 服务通过同名主题共享事件
+const (
+	TopicWsBroadcast  = "ws:broadcast" // websocket 广播消息
+	TopicAdminChanged = "data:admin"   // 管理员数据变更，用于清除响应缓存
+)
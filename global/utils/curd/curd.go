@@ -1,192 +1,252 @@
 package curd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"tool/pkg/mysql"
+	"strings"
+	"tool/global/utils/tx"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-// 配置
-type Config[T any] struct {
-	db    *gorm.DB // 数据库连接
-	model T        // 模型
-	Conn  string   // 数据库名称
-}
-
-// 初始化
-func New[T any](model T) *Config[T] {
-
-	Config := &Config[T]{model: model, Conn: "Local"}
-
-	//链接数据库
-	db := mysql.NewClient(Config.Conn)
+var (
+	ErrEmptyWhere  = errors.New("删除或修改条件不能为空")
+	ErrSortField   = errors.New("不允许排序的字段")
+	ErrSoftDeleted = errors.New("模型不支持软删除")
+)
 
-	Config.db = db.Model(model)
+// Scope 查询范围，与 gorm.DB.Scopes 的参数一致
+type Scope func(db *gorm.DB) *gorm.DB
 
-	return Config
+// Config 泛型仓储，T 为模型结构体（非指针）
+// 所有链式方法都返回新的实例，不会修改原实例，因此可以安全地复用一个基础查询
+type Config[T any] struct {
+	Conn     string            // 数据库连接名称
+	scopes   []Scope           // 查询范围
+	hasWhere bool              // 是否设置了查询条件
+	sortable map[string]string // 允许排序的字段 => 数据库列名
+	trashed  trashedMode       // 软删除数据的查询方式
 }
 
-// 设置连接数据库
-func (b *Config[T]) SetDb(Conn string) *Config[T] {
+// New 创建仓储，默认使用 Local 连接
+func New[T any]() *Config[T] {
+	return &Config[T]{Conn: "Local"}
+}
 
-	//链接数据库
-	db := mysql.NewClient(Conn)
+// clone 复制实例
+func (b *Config[T]) clone() *Config[T] {
+	c := *b
+	c.scopes = append([]Scope(nil), b.scopes...)
+	return &c
+}
 
-	b.db = db.Model(b.model)
-	return b
+// SetDb 设置连接数据库
+func (b *Config[T]) SetDb(conn string) *Config[T] {
+	c := b.clone()
+	c.Conn = conn
+	return c
 }
 
-// 设置查询条件
+// Where 添加查询条件
 func (b *Config[T]) Where(query interface{}, args ...interface{}) *Config[T] {
-	b.db.Where(query, args...)
-	return b
+	c := b.clone()
+	c.scopes = append(c.scopes, func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	})
+	c.hasWhere = true
+	return c
 }
 
-// 重置where条件
-func (b *Config[T]) ResetWhere() *Config[T] {
-
-	b.db.Statement.Clauses = map[string]clause.Clause{}
-
-	return b
+// Scopes 添加自定义查询范围
+func (b *Config[T]) Scopes(scopes ...Scope) *Config[T] {
+	c := b.clone()
+	c.scopes = append(c.scopes, scopes...)
+	return c
 }
 
-// 查询单条数据
-func (b *Config[T]) First() (T, error) {
-
-	var data T
-	err := b.db.First(&data).Error
-	return data, err
+// Sortable 设置允许排序的字段，未设置时不允许任何排序
+// 参数为数据库列名，如 "id", "create_time"
+func (b *Config[T]) Sortable(columns ...string) *Config[T] {
+	c := b.clone()
+	c.sortable = make(map[string]string, len(columns))
+	for _, column := range columns {
+		c.sortable[column] = column
+	}
+	return c
 }
 
-// 查询多条数据
-func (b *Config[T]) Find() (T, error) {
+// Sort 按排序表达式排序，多个字段用逗号分隔，前缀 "-" 表示倒序，如 "-create_time,id"
+// 字段必须在 Sortable 白名单中
+func (b *Config[T]) Sort(expr string) (*Config[T], error) {
+	var columns []clause.OrderByColumn
 
-	var data T
-	err := b.db.Find(&data).Error
-	return data, err
-}
+	for _, field := range strings.Split(expr, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
 
-// 分页数据
-func (b *Config[T]) PageList(page int, pageSize int) ([]T, error) {
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimLeft(field, "+-")
 
-	defer b.ResetWhere()
+		column, ok := b.sortable[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSortField, field)
+		}
 
-	var list []T
-	if err := b.db.Offset((page - 1) * pageSize).Limit(pageSize).Find(&list).Error; err != nil {
-		return nil, err
+		columns = append(columns, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
 	}
 
-	return list, nil
+	if len(columns) == 0 {
+		return b, nil
+	}
 
+	return b.Scopes(func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clause.OrderBy{Columns: columns})
+	}), nil
 }
 
-// 统计
-func (b *Config[T]) Count() (int64, error) {
+// query 构建本次查询使用的 gorm.DB，每次都是全新的会话
+// ctx 处于 tx.Run 的事务中时，自动使用该事务
+func (b *Config[T]) query(ctx context.Context) *gorm.DB {
+	db := tx.DB(ctx, b.Conn).Model(new(T))
 
-	defer b.ResetWhere()
-
-	var count int64
-	db := b.db.Model(b.model)
-
-	if err := db.Count(&count).Error; err != nil {
-		return 0, err
+	if field := softDeleteField[T](db); field != nil {
+		db = b.trashed.apply(db, field)
 	}
 
-	return count, nil
+	return db.Scopes(toGormScopes(b.scopes)...)
 }
 
-// 创建
-func (b *Config[T]) Add() (int64, error) {
-
-	defer b.ResetWhere()
-
-	err := b.db.Create(b.model).Error
-
-	if err != nil {
-		return 0, err
+// First 查询单条数据，没有数据时返回 gorm.ErrRecordNotFound
+func (b *Config[T]) First(ctx context.Context) (T, error) {
+	var data T
+	result := b.query(ctx).Limit(1).Find(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
 	}
-
-	//获取主键名称
-	primaryKey := b.db.Statement.Schema.PrioritizedPrimaryField.Name
-
-	//获取主键值
-	primaryKeyValue := b.db.Statement.ReflectValue.FieldByName(primaryKey).Int()
-
-	return primaryKeyValue, nil
+	return data, result.Error
 }
 
-// 修改
-func (b *Config[T]) Update(data T) error {
-
-	defer b.ResetWhere()
-
-	return b.db.Updates(data).Error
+// Find 查询多条数据
+func (b *Config[T]) Find(ctx context.Context) ([]T, error) {
+	var list []T
+	err := b.query(ctx).Find(&list).Error
+	return list, err
 }
 
-// 新增或修改
-func (b *Config[T]) Save(data T) (int16, error) {
+// Count 统计
+func (b *Config[T]) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := b.query(ctx).Count(&count).Error
+	return count, err
+}
 
-	defer b.ResetWhere()
+// Exists 是否存在满足条件的数据
+func (b *Config[T]) Exists(ctx context.Context) (bool, error) {
+	var found int
+	result := b.query(ctx).Select("1").Limit(1).Find(&found)
+	return result.RowsAffected > 0, result.Error
+}
 
-	// //判断是否有where
-	if _, ok := b.db.Statement.Clauses["WHERE"]; ok {
+// Create 创建，主键会回填到 data
+func (b *Config[T]) Create(ctx context.Context, data *T) error {
+	return b.query(ctx).Create(data).Error
+}
 
-		//判断数据是否存在
-		_, err := b.First()
-		if err != nil {
-			return 0, err
-		}
+// Update 按条件修改，values 可以是 map 或结构体（结构体的零值字段不会更新）
+func (b *Config[T]) Update(ctx context.Context, values interface{}) (int64, error) {
+	if !b.hasWhere {
+		return 0, ErrEmptyWhere
+	}
 
-		//获取主键名称
-		primaryKey := b.db.Statement.Schema.PrioritizedPrimaryField.Name
+	result := b.query(ctx).Updates(values)
+	return result.RowsAffected, result.Error
+}
 
-		//获取当前主键值
-		primaryKeyValue := b.db.Statement.ReflectValue.FieldByName(primaryKey).Int()
+// Delete 按条件删除，模型有 delete_time 字段时为软删除
+func (b *Config[T]) Delete(ctx context.Context) (int64, error) {
+	if !b.hasWhere {
+		return 0, ErrEmptyWhere
+	}
 
-		//如果是0 则没有数据
-		if primaryKeyValue == 0 {
-			return 0, fmt.Errorf("数据不存在")
-		}
+	db := b.query(ctx)
 
-		//修改
-		err = b.Update(data)
+	if field := softDeleteField[T](db); field != nil {
+		result := db.UpdateColumn(field.DBName, field.deletedValue())
+		return result.RowsAffected, result.Error
+	}
 
-		if err != nil {
-			return 0, err
-		}
+	result := db.Delete(new(T))
+	return result.RowsAffected, result.Error
+}
 
-		return int16(primaryKeyValue), nil
+// ForceDelete 按条件物理删除，包括已软删除的数据；OnlyTrashed 时只删除已软删除的数据
+func (b *Config[T]) ForceDelete(ctx context.Context) (int64, error) {
+	if !b.hasWhere {
+		return 0, ErrEmptyWhere
+	}
 
+	c := b
+	if c.trashed == withoutTrashed {
+		c = b.WithTrashed()
 	}
 
-	//新增
-	err := b.db.Create(&data).Error
+	result := c.query(ctx).Unscoped().Delete(new(T))
+	return result.RowsAffected, result.Error
+}
 
-	if err != nil {
-		return 0, err
+// Restore 恢复软删除的数据
+func (b *Config[T]) Restore(ctx context.Context) (int64, error) {
+	if !b.hasWhere {
+		return 0, ErrEmptyWhere
 	}
 
-	// 获取主键值
-	primaryKey := b.db.Statement.Schema.PrioritizedPrimaryField.Name
+	c := b.OnlyTrashed()
+	db := c.query(ctx)
 
-	//获取主键值
-	primaryKeyValue := b.db.Statement.ReflectValue.FieldByName(primaryKey).Int()
+	field := softDeleteField[T](db)
+	if field == nil {
+		return 0, ErrSoftDeleted
+	}
 
-	return int16(primaryKeyValue), nil
+	result := db.UpdateColumn(field.DBName, field.restoredValue())
+	return result.RowsAffected, result.Error
+}
 
+// FirstOrCreate 按条件查询第一条数据，不存在时用 attrs 与条件合并后创建
+func (b *Config[T]) FirstOrCreate(ctx context.Context, attrs ...interface{}) (T, error) {
+	var data T
+	err := b.query(ctx).Attrs(attrs...).FirstOrCreate(&data).Error
+	return data, err
 }
 
-// 删除
-func (b *Config[T]) Delete() error {
+// Upsert 插入数据，唯一键冲突时更新指定字段
+// conflict: 冲突判断的列（MySQL 会忽略，按表上的唯一索引判断）
+// updates: 冲突时更新的列，为空时更新除主键外的所有列
+func (b *Config[T]) Upsert(ctx context.Context, data interface{}, conflict []string, updates ...string) error {
+	onConflict := clause.OnConflict{}
 
-	defer b.ResetWhere()
+	for _, column := range conflict {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
 
-	//判断是否有where
-	if _, ok := b.db.Statement.Clauses["WHERE"]; !ok {
-		return fmt.Errorf("删除条件不能为空")
+	if len(updates) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(updates)
+	} else {
+		onConflict.UpdateAll = true
 	}
 
-	return b.db.Delete(b.model).Error
+	return b.query(ctx).Clauses(onConflict).Create(data).Error
+}
+
+// toGormScopes 转换为 gorm 的 Scopes 参数
+func toGormScopes(scopes []Scope) []func(*gorm.DB) *gorm.DB {
+	fns := make([]func(*gorm.DB) *gorm.DB, len(scopes))
+	for i, scope := range scopes {
+		fns[i] = scope
+	}
+	return fns
 }
@@ -0,0 +1,80 @@
+package curd
+
+import (
+	"context"
+	"math"
+	"tool/global/variable"
+)
+
+const (
+	defaultPageSize = 20  // 默认每页条数
+	maxPageSize     = 500 // 未配置 Pagination.MaxSize 时的最大每页条数
+)
+
+// PageRequest 分页请求
+type PageRequest struct {
+	Page int    `form:"page" json:"page"` // 页码，从 1 开始
+	Size int    `form:"size" json:"size"` // 每页条数
+	Sort string `form:"sort" json:"sort"` // 排序表达式，如 "-create_time,id"，字段需在 Sortable 白名单中
+}
+
+// Normalize 规范化分页参数，每页条数不超过 Pagination.MaxSize
+func (r PageRequest) Normalize() PageRequest {
+	if r.Page < 1 {
+		r.Page = 1
+	}
+	if r.Size < 1 {
+		r.Size = defaultPageSize
+	}
+	if limit := MaxPageSize(); r.Size > limit {
+		r.Size = limit
+	}
+	return r
+}
+
+// MaxPageSize 配置的最大每页条数
+func MaxPageSize() int {
+	if variable.ConfigYml == nil {
+		return maxPageSize
+	}
+	if limit := variable.ConfigYml.GetConfig("Pagination.MaxSize", maxPageSize).(int); limit > 0 {
+		return limit
+	}
+	return maxPageSize
+}
+
+// PageResult 分页结果
+type PageResult[T any] struct {
+	List  []T   `json:"list"`  // 当前页数据
+	Total int64 `json:"total"` // 总条数
+	Page  int   `json:"page"`  // 当前页码
+	Size  int   `json:"size"`  // 每页条数
+	Pages int   `json:"pages"` // 总页数
+}
+
+// Page 分页查询
+func (b *Config[T]) Page(ctx context.Context, req PageRequest) (PageResult[T], error) {
+	req = req.Normalize()
+	result := PageResult[T]{Page: req.Page, Size: req.Size, List: []T{}}
+
+	sorted, err := b.Sort(req.Sort)
+	if err != nil {
+		return result, err
+	}
+
+	total, err := b.Count(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	result.Total = total
+	result.Pages = int(math.Ceil(float64(total) / float64(req.Size)))
+
+	// 超出范围的页码不再查询
+	if total == 0 || req.Page > result.Pages {
+		return result, nil
+	}
+
+	err = sorted.query(ctx).Offset((req.Page - 1) * req.Size).Limit(req.Size).Find(&result.List).Error
+	return result, err
+}
@@ -0,0 +1,117 @@
+package curd
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// softDeleteColumn 软删除字段的列名，与 controller.User 等模型的 delete_time 保持一致
+const softDeleteColumn = "delete_time"
+
+// trashedMode 软删除数据的查询方式
+type trashedMode int
+
+const (
+	withoutTrashed trashedMode = iota // 默认：排除已删除的数据
+	withTrashed                       // 包含已删除的数据
+	onlyTrashed                       // 只查询已删除的数据
+)
+
+// WithTrashed 查询时包含软删除的数据
+func (b *Config[T]) WithTrashed() *Config[T] {
+	c := b.clone()
+	c.trashed = withTrashed
+	return c
+}
+
+// OnlyTrashed 只查询软删除的数据
+func (b *Config[T]) OnlyTrashed() *Config[T] {
+	c := b.clone()
+	c.trashed = onlyTrashed
+	return c
+}
+
+// softField 软删除字段
+// 整数类型：0 表示未删除，删除时写入 Unix 时间戳；整数指针类型的 NULL 也表示未删除
+// 时间指针类型：NULL 表示未删除，删除时写入当前时间
+type softField struct {
+	DBName   string
+	unixTime bool
+	nullable bool
+}
+
+// deletedValue 删除时写入的值
+func (f *softField) deletedValue() interface{} {
+	if f.unixTime {
+		return time.Now().Unix()
+	}
+	return time.Now()
+}
+
+// restoredValue 恢复时写入的值
+func (f *softField) restoredValue() interface{} {
+	if f.unixTime {
+		return 0
+	}
+	return gorm.Expr("NULL")
+}
+
+// apply 添加软删除过滤条件
+func (m trashedMode) apply(db *gorm.DB, field *softField) *gorm.DB {
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+
+	switch m {
+	case withTrashed:
+		return db
+	case onlyTrashed:
+		if field.unixTime {
+			return db.Where(clause.Gt{Column: column, Value: 0})
+		}
+		return db.Where(clause.Neq{Column: column, Value: nil})
+	default:
+		if field.unixTime && field.nullable {
+			return db.Where(clause.Or(clause.Eq{Column: column, Value: 0}, clause.Eq{Column: column, Value: nil}))
+		}
+		if field.unixTime {
+			return db.Where(clause.Eq{Column: column, Value: 0})
+		}
+		return db.Where(clause.Eq{Column: column, Value: nil})
+	}
+}
+
+var (
+	schemaCache sync.Map // gorm schema 解析缓存
+	softFields  sync.Map // reflect.Type => *softField（nil 表示不支持软删除）
+)
+
+// softDeleteField 获取模型的软删除字段，不支持时返回 nil
+// 使用 gorm.DeletedAt 的模型由 gorm 自行处理，这里不重复处理
+func softDeleteField[T any](db *gorm.DB) *softField {
+	typ := reflect.TypeOf(new(T)).Elem()
+
+	if cached, ok := softFields.Load(typ); ok {
+		return cached.(*softField)
+	}
+
+	var field *softField
+
+	if s, err := schema.Parse(new(T), &schemaCache, db.NamingStrategy); err == nil {
+		if f := s.LookUpField(softDeleteColumn); f != nil && f.FieldType != reflect.TypeOf(gorm.DeletedAt{}) {
+			switch f.IndirectFieldType.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				field = &softField{DBName: f.DBName, unixTime: true, nullable: f.FieldType.Kind() == reflect.Ptr}
+			default:
+				field = &softField{DBName: f.DBName}
+			}
+		}
+	}
+
+	softFields.Store(typ, field)
+	return field
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
-func RedisLocal() *redis.Client {
+func RedisLocal() redis.UniversalClient {
 
 	return pkgRedis.NewClient("Local")
 }
@@ -0,0 +1,681 @@
+package oss
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"mime"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tool/global/utils/common"
+	"tool/global/variable"
+	pkgRedis "tool/pkg/redis"
+	"tool/pkg/storage"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSessionNotFound  = errors.New("Upload session not found")
+	ErrSessionBusy      = errors.New("Upload session is completing")
+	ErrChunkInvalid     = errors.New("Invalid chunk number")
+	ErrChunkSize        = errors.New("Chunk size does not match")
+	ErrChecksumMismatch = errors.New("Chunk checksum mismatch")
+	ErrChunksMissing    = errors.New("Some chunks are missing")
+	ErrTooManyChunks    = errors.New("Too many chunks, increase the chunk size")
+	ErrChecksumInvalid  = errors.New("Unsupported checksum algorithm")
+)
+
+// 校验算法
+const (
+	ChecksumMD5    = "md5"
+	ChecksumSHA256 = "sha256"
+)
+
+// Redis 中的 key：每个会话一个 hash，field "session" 保存创建时的会话，分片号保存已上传的分片，
+// 上传分片时会修改的类型和过期时间单独保存，避免并发上传时互相覆盖；
+// 另外用一个有序集合按过期时间记录所有会话，供任务服务清理
+const (
+	chunkKeyPrefix   = "upload:chunk:"
+	chunkSessionSet  = "upload:chunk:sessions"
+	sessionField     = "session"
+	contentTypeField = "content_type"
+	expiresField     = "expires_at"
+	completingField  = "completing"
+)
+
+// chunkKeepAlive 会话过期后 Redis 中的数据再保留的时间，清理任务需要读取会话来取消分片上传
+const chunkKeepAlive = 24 * time.Hour
+
+// completeTimeout 合并的最长时间，进程在合并中退出时，超过后允许重新合并
+const completeTimeout = 30 * time.Minute
+
+// acquireComplete 设置合并标记，标记不存在或已超时时返回 1
+var acquireComplete = redis.NewScript(`
+local started = tonumber(redis.call("HGET", KEYS[1], ARGV[1]) or "0")
+if started > 0 and tonumber(ARGV[2]) - started < tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// writeChunk 未在合并时删除（ARGV[4] 为空）或写入分片记录，正在合并时返回 0；
+// 与合并标记在同一个脚本里检查，避免检查后合并开始再修改分片
+var writeChunk = redis.NewScript(`
+local started = tonumber(redis.call("HGET", KEYS[1], ARGV[1]) or "0")
+if started > 0 and tonumber(ARGV[2]) - started < tonumber(ARGV[3]) then
+	return 0
+end
+if ARGV[5] == "" then
+	redis.call("HDEL", KEYS[1], ARGV[4])
+else
+	redis.call("HSET", KEYS[1], ARGV[4], ARGV[5])
+end
+return 1
+`)
+
+// ChunkConfig 分片上传配置
+type ChunkConfig struct {
+	Connection string        // redis.yml 中的连接名
+	Disk       string        // 上传使用的磁盘
+	TempDir    string        // 驱动不支持分片上传时，分片临时保存的目录
+	MaxSize    int64         // 文件的最大字节数
+	ChunkSize  int64         // 分片大小
+	Expire     time.Duration // 会话有效期，每上传一个分片重新计算
+	AllowExt   []string      // 允许的后缀，为空时不限制
+	AllowMime  []string      // 允许的类型（按第一个分片的内容识别），为空时不限制
+}
+
+// LoadChunkConfig 从 ChunkUpload 配置读取分片上传配置
+func LoadChunkConfig() ChunkConfig {
+	config := variable.ConfigYml
+
+	// 配置为空字符串时 GetConfig 不会返回默认值
+	disk := config.GetString("ChunkUpload.Disk")
+	if disk == "" {
+		disk = DefaultDisk()
+	}
+
+	return ChunkConfig{
+		Connection: config.GetConfig("ChunkUpload.Connection", "Local").(string),
+		Disk:       disk,
+		TempDir:    strings.Trim(config.GetConfig("ChunkUpload.TempDir", "chunks").(string), "/"),
+		MaxSize:    int64(config.GetConfig("ChunkUpload.MaxSize", 4096).(int)) << 20,
+		ChunkSize:  int64(config.GetConfig("ChunkUpload.ChunkSize", 8).(int)) << 20,
+		Expire:     time.Duration(config.GetConfig("ChunkUpload.Expire", 86400).(int)) * time.Second,
+		AllowExt:   splitList(config.GetString("ChunkUpload.AllowExt")),
+		AllowMime:  splitList(config.GetString("ChunkUpload.AllowMime")),
+	}
+}
+
+// ChunkSession 分片上传会话
+type ChunkSession struct {
+	ID          string `json:"id"`
+	Disk        string `json:"disk"`
+	Key         string `json:"key"`
+	FileName    string `json:"file_name"`
+	Size        int64  `json:"size"`
+	ChunkSize   int64  `json:"chunk_size"`
+	Chunks      int    `json:"chunks"`
+	Checksum    string `json:"checksum"`
+	ContentType string `json:"content_type"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+// chunkSize 第 number 个分片的大小，最后一个分片可能较小
+func (s ChunkSession) chunkSize(number int) int64 {
+	if number == s.Chunks {
+		return s.Size - int64(s.Chunks-1)*s.ChunkSize
+	}
+	return s.ChunkSize
+}
+
+// sessionData 保存到 Redis 的会话，UploadID 为驱动的分片上传 ID，不返回给客户端，
+// 为空时分片保存为临时对象；Owner 为创建会话的用户，其他用户不能访问
+type sessionData struct {
+	ChunkSession
+	UploadID string `json:"upload_id,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+
+	completing bool // 正在合并，不保存
+}
+
+// ChunkUploader 分片上传，分片状态保存在 Redis，分片按驱动的分片上传写入磁盘，
+// 驱动不支持时保存为临时对象，完成时再按顺序合并
+//
+//	uploader, _ := oss.NewChunkUploader(oss.LoadChunkConfig(), username)
+//	session, _ := uploader.Init(ctx, "video.mp4", size, oss.ChecksumSHA256)
+//	uploader.UploadChunk(ctx, session.ID, 1, body, checksum) // 每个分片，可以并发和重试
+//	result, _ := uploader.Complete(ctx, session.ID)
+type ChunkUploader struct {
+	config ChunkConfig
+	client redis.UniversalClient
+	owner  string
+}
+
+// NewChunkUploader 创建分片上传，owner 为当前用户，只能访问自己创建的会话；
+// 为空时不检查，用于任务服务清理过期会话
+func NewChunkUploader(config ChunkConfig, owner string) (*ChunkUploader, error) {
+	client, err := pkgRedis.Client(config.Connection)
+	if err != nil {
+		return nil, err
+	}
+	return &ChunkUploader{config: config, client: client, owner: owner}, nil
+}
+
+// Init 创建上传会话，checksum 为每个分片的校验算法，默认 sha256
+func (u *ChunkUploader) Init(ctx context.Context, filename string, size int64, checksum string) (ChunkSession, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))
+	if len(u.config.AllowExt) > 0 && !common.InArray(ext, u.config.AllowExt) {
+		return ChunkSession{}, ErrExtNotAllowed
+	}
+	if size <= 0 || (u.config.MaxSize > 0 && size > u.config.MaxSize) {
+		return ChunkSession{}, ErrTooLarge
+	}
+
+	switch checksum {
+	case "":
+		checksum = ChecksumSHA256
+	case ChecksumMD5, ChecksumSHA256:
+	default:
+		return ChunkSession{}, ErrChecksumInvalid
+	}
+
+	chunkSize := u.config.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = storage.DefaultPartSize
+	}
+	chunks := int((size + chunkSize - 1) / chunkSize)
+	if chunks > storage.MaxParts {
+		return ChunkSession{}, ErrTooManyChunks
+	}
+
+	disk, err := storage.Disk(u.config.Disk)
+	if err != nil {
+		return ChunkSession{}, err
+	}
+
+	id, err := randomName()
+	if err != nil {
+		return ChunkSession{}, err
+	}
+	name := id
+	if ext != "" {
+		name += "." + ext
+	}
+
+	contentType := mime.TypeByExtension("." + ext)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	session := sessionData{ChunkSession: ChunkSession{
+		ID:          id,
+		Disk:        u.config.Disk,
+		Key:         storage.Join(storage.DiskDir(u.config.Disk), time.Now().Format("2006/01/02"), name),
+		FileName:    path.Base(filename),
+		Size:        size,
+		ChunkSize:   chunkSize,
+		Chunks:      chunks,
+		Checksum:    checksum,
+		ContentType: contentType,
+	}, Owner: u.owner}
+
+	// 除最后一片外分片不小于 5MB 时才能使用驱动的分片上传
+	if multipart, ok := disk.(storage.Multipart); ok && (chunks == 1 || chunkSize >= storage.MinPartSize) {
+		session.UploadID, err = multipart.InitMultipart(ctx, session.Key, storage.PutOptions{Size: size, ContentType: contentType})
+		if err != nil {
+			return ChunkSession{}, err
+		}
+	}
+
+	if err := u.save(ctx, &session); err != nil {
+		u.cleanup(context.WithoutCancel(ctx), session)
+		return ChunkSession{}, err
+	}
+	return session.ChunkSession, nil
+}
+
+// UploadChunk 上传第 number 个分片，从 1 开始，checksum 为分片内容的十六进制摘要
+// 同一分片重复上传时覆盖；写入前先删除分片记录，校验失败或写入中断时分片视为未上传，客户端重新上传即可
+func (u *ChunkUploader) UploadChunk(ctx context.Context, id string, number int, r io.Reader, checksum string) (storage.Part, error) {
+	session, err := u.load(ctx, id)
+	if err != nil {
+		return storage.Part{}, err
+	}
+	if number < 1 || number > session.Chunks {
+		return storage.Part{}, ErrChunkInvalid
+	}
+	if session.completing {
+		return storage.Part{}, ErrSessionBusy
+	}
+
+	// 写入会覆盖已上传的内容，原来的记录不再有效；之后开始的合并会因为缺少分片而失败
+	if err := u.writeChunk(ctx, id, number, ""); err != nil {
+		return storage.Part{}, err
+	}
+
+	disk, err := storage.Disk(session.Disk)
+	if err != nil {
+		return storage.Part{}, err
+	}
+
+	// 第一个分片按内容识别类型
+	if number == 1 {
+		var contentType string
+		contentType, r, err = Sniff(r)
+		if err != nil {
+			return storage.Part{}, err
+		}
+		if len(u.config.AllowMime) > 0 && !common.InArray(contentType, u.config.AllowMime) {
+			return storage.Part{}, ErrTypeNotAllowed
+		}
+		if err := u.client.HSet(ctx, chunkKeyPrefix+id, contentTypeField, contentType).Err(); err != nil {
+			return storage.Part{}, err
+		}
+	}
+
+	size := session.chunkSize(number)
+	digest := newChecksum(session.Checksum)
+	body := &chunkReader{r: io.TeeReader(r, digest), remaining: size}
+
+	var part storage.Part
+	if session.UploadID != "" {
+		part, err = disk.(storage.Multipart).UploadPart(ctx, session.Key, session.UploadID, number, body, size)
+	} else {
+		var info storage.ObjectInfo
+		info, err = disk.Put(ctx, u.chunkKey(session, number), body, storage.PutOptions{Size: size})
+		part = storage.Part{Number: number, ETag: info.ETag, Size: info.Size}
+	}
+	if err == nil {
+		err = body.finish()
+	}
+	if err != nil {
+		return storage.Part{}, err
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(digest.Sum(nil)), checksum) {
+		return storage.Part{}, ErrChecksumMismatch
+	}
+
+	part.Size = size
+	value, _ := json.Marshal(part)
+
+	if err := u.writeChunk(ctx, id, number, string(value)); err != nil {
+		return storage.Part{}, err
+	}
+	if _, err := u.touch(ctx, id); err != nil {
+		return storage.Part{}, err
+	}
+	return part, nil
+}
+
+// writeChunk 会话未在合并时删除（value 为空）或保存分片记录，正在合并时返回 ErrSessionBusy
+func (u *ChunkUploader) writeChunk(ctx context.Context, id string, number int, value string) error {
+	ok, err := writeChunk.Run(ctx, u.client, []string{chunkKeyPrefix + id},
+		completingField, time.Now().Unix(), int64(completeTimeout/time.Second), strconv.Itoa(number), value).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionBusy
+	}
+	return nil
+}
+
+// Status 会话和已上传的分片号
+func (u *ChunkUploader) Status(ctx context.Context, id string) (ChunkSession, []int, error) {
+	session, err := u.load(ctx, id)
+	if err != nil {
+		return ChunkSession{}, nil, err
+	}
+
+	parts, err := u.parts(ctx, id)
+	if err != nil {
+		return ChunkSession{}, nil, err
+	}
+
+	uploaded := make([]int, 0, len(parts))
+	for _, part := range parts {
+		uploaded = append(uploaded, part.Number)
+	}
+	return session.ChunkSession, uploaded, nil
+}
+
+// Complete 所有分片上传后合并为文件并删除会话
+func (u *ChunkUploader) Complete(ctx context.Context, id string) (Result, error) {
+	session, err := u.load(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// 同一会话只允许一个请求合并，标记超时后允许重新合并
+	key := chunkKeyPrefix + id
+	ok, err := acquireComplete.Run(ctx, u.client, []string{key},
+		completingField, time.Now().Unix(), int64(completeTimeout/time.Second)).Bool()
+	if err != nil {
+		return Result{}, err
+	}
+	if !ok {
+		return Result{}, ErrSessionBusy
+	}
+
+	result, err := u.complete(ctx, session)
+	if err != nil {
+		u.client.HDel(context.WithoutCancel(ctx), key, completingField)
+		return Result{}, err
+	}
+
+	u.remove(context.WithoutCancel(ctx), id)
+	return result, nil
+}
+
+// complete 按驱动的分片上传合并，或者按顺序读取临时对象写入
+func (u *ChunkUploader) complete(ctx context.Context, session sessionData) (Result, error) {
+	parts, err := u.parts(ctx, session.ID)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(parts) != session.Chunks {
+		return Result{}, ErrChunksMissing
+	}
+
+	disk, err := storage.Disk(session.Disk)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if session.UploadID != "" {
+		if _, err := disk.(storage.Multipart).CompleteMultipart(ctx, session.Key, session.UploadID, parts); err != nil {
+			return Result{}, err
+		}
+	} else {
+		r := &chunksReader{ctx: ctx, disk: disk, keys: make([]string, session.Chunks)}
+		for i := range r.keys {
+			r.keys[i] = u.chunkKey(session, i+1)
+		}
+		_, err := storage.PutStream(ctx, disk, session.Key, r, storage.PutOptions{Size: session.Size, ContentType: session.ContentType}, session.ChunkSize)
+		r.Close()
+		if err != nil {
+			return Result{}, err
+		}
+		u.deleteChunks(context.WithoutCancel(ctx), disk, session)
+	}
+
+	return Result{Key: session.Key, URL: disk.URL(session.Key), Size: session.Size, ContentType: session.ContentType}, nil
+}
+
+// Abort 取消上传，删除已上传的分片和会话
+func (u *ChunkUploader) Abort(ctx context.Context, id string) error {
+	session, err := u.load(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	u.cleanup(ctx, session)
+	u.remove(ctx, id)
+	return nil
+}
+
+// Clean 清理已过期的会话，返回清理的数量，由任务服务定期调用
+func (u *ChunkUploader) Clean(ctx context.Context) (int, error) {
+	ids, err := u.client.ZRangeByScore(ctx, chunkSessionSet, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+
+		// Redis 中的数据已过期时无法再取消分片上传，只能由存储的生命周期规则清理
+		session, err := u.read(ctx, id)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return 0, err
+		}
+		if err == nil {
+			// 查询后会话可能又上传了分片
+			if session.ExpiresAt > time.Now().Unix() {
+				continue
+			}
+			u.cleanup(ctx, session)
+		}
+		u.remove(ctx, id)
+	}
+	return len(ids), nil
+}
+
+// cleanup 取消驱动的分片上传或删除临时对象
+func (u *ChunkUploader) cleanup(ctx context.Context, session sessionData) {
+	disk, err := storage.Disk(session.Disk)
+	if err != nil {
+		variable.Logs.Warn("清理分片上传失败", zap.String("id", session.ID), zap.Error(err))
+		return
+	}
+
+	if session.UploadID != "" {
+		if err := disk.(storage.Multipart).AbortMultipart(ctx, session.Key, session.UploadID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			variable.Logs.Warn("取消分片上传失败", zap.String("id", session.ID), zap.Error(err))
+		}
+		return
+	}
+	u.deleteChunks(ctx, disk, session)
+}
+
+// deleteChunks 删除临时保存的分片
+func (u *ChunkUploader) deleteChunks(ctx context.Context, disk storage.Driver, session sessionData) {
+	for number := 1; number <= session.Chunks; number++ {
+		if err := disk.Delete(ctx, u.chunkKey(session, number)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			variable.Logs.Warn("删除临时分片失败", zap.String("id", session.ID), zap.Int("number", number), zap.Error(err))
+		}
+	}
+}
+
+// chunkKey 临时分片的 key
+func (u *ChunkUploader) chunkKey(session sessionData, number int) string {
+	return storage.Join(u.config.TempDir, session.ID, strconv.Itoa(number))
+}
+
+// load 读取当前用户未过期的会话
+func (u *ChunkUploader) load(ctx context.Context, id string) (sessionData, error) {
+	session, err := u.read(ctx, id)
+	if err != nil {
+		return sessionData{}, err
+	}
+	if session.ExpiresAt <= time.Now().Unix() {
+		return sessionData{}, ErrSessionNotFound
+	}
+	if u.owner != "" && session.Owner != u.owner {
+		return sessionData{}, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// read 读取会话，不检查是否过期和所属用户
+func (u *ChunkUploader) read(ctx context.Context, id string) (sessionData, error) {
+	values, err := u.client.HMGet(ctx, chunkKeyPrefix+id, sessionField, contentTypeField, expiresField, completingField).Result()
+	if err != nil {
+		return sessionData{}, err
+	}
+
+	value, ok := values[0].(string)
+	if !ok {
+		return sessionData{}, ErrSessionNotFound
+	}
+
+	var session sessionData
+	if err := json.Unmarshal([]byte(value), &session); err != nil {
+		return sessionData{}, err
+	}
+	if contentType, ok := values[1].(string); ok {
+		session.ContentType = contentType
+	}
+	if expires, ok := values[2].(string); ok {
+		session.ExpiresAt, _ = strconv.ParseInt(expires, 10, 64)
+	}
+	if started, ok := values[3].(string); ok {
+		unix, _ := strconv.ParseInt(started, 10, 64)
+		session.completing = time.Now().Unix()-unix < int64(completeTimeout/time.Second)
+	}
+	return session, nil
+}
+
+// save 保存新建的会话
+func (u *ChunkUploader) save(ctx context.Context, session *sessionData) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := u.client.HSet(ctx, chunkKeyPrefix+session.ID, sessionField, value).Err(); err != nil {
+		return err
+	}
+
+	session.ExpiresAt, err = u.touch(ctx, session.ID)
+	return err
+}
+
+// touch 延长会话有效期，返回新的过期时间
+func (u *ChunkUploader) touch(ctx context.Context, id string) (int64, error) {
+	expiresAt := time.Now().Add(u.config.Expire).Unix()
+
+	key := chunkKeyPrefix + id
+	_, err := u.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, expiresField, expiresAt)
+		pipe.Expire(ctx, key, u.config.Expire+chunkKeepAlive)
+		pipe.ZAdd(ctx, chunkSessionSet, &redis.Z{Score: float64(expiresAt), Member: id})
+		return nil
+	})
+	return expiresAt, err
+}
+
+// remove 删除会话
+func (u *ChunkUploader) remove(ctx context.Context, id string) {
+	_, err := u.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, chunkKeyPrefix+id)
+		pipe.ZRem(ctx, chunkSessionSet, id)
+		return nil
+	})
+	if err != nil {
+		variable.Logs.Warn("删除上传会话失败", zap.String("id", id), zap.Error(err))
+	}
+}
+
+// parts 已上传的分片，按分片号排序
+func (u *ChunkUploader) parts(ctx context.Context, id string) ([]storage.Part, error) {
+	fields, err := u.client.HGetAll(ctx, chunkKeyPrefix+id).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]storage.Part, 0, len(fields))
+	for field, value := range fields {
+		// 其他 field 不是分片
+		if _, err := strconv.Atoi(field); err != nil {
+			continue
+		}
+		var part storage.Part
+		if err := json.Unmarshal([]byte(value), &part); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+// newChecksum 按算法创建摘要
+func newChecksum(algorithm string) hash.Hash {
+	if algorithm == ChecksumMD5 {
+		return md5.New()
+	}
+	return sha256.New()
+}
+
+// chunkReader 读取固定大小的分片，内容多于分片大小时返回错误
+type chunkReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if err == io.EOF && c.remaining > 0 {
+		err = ErrChunkSize
+	}
+	return n, err
+}
+
+// finish 驱动写入后检查内容是否正好为分片大小
+func (c *chunkReader) finish() error {
+	if c.remaining > 0 {
+		return ErrChunkSize
+	}
+	var one [1]byte
+	if n, _ := c.r.Read(one[:]); n > 0 {
+		return ErrChunkSize
+	}
+	return nil
+}
+
+// chunksReader 依次读取临时保存的分片
+type chunksReader struct {
+	ctx     context.Context
+	disk    storage.Driver
+	keys    []string
+	current io.ReadCloser
+}
+
+func (c *chunksReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.keys) == 0 {
+				return 0, io.EOF
+			}
+			reader, _, err := c.disk.Get(c.ctx, c.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			c.current, c.keys = reader, c.keys[1:]
+		}
+
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *chunksReader) Close() error {
+	if c.current != nil {
+		return c.current.Close()
+	}
+	return nil
+}
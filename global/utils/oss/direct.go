@@ -0,0 +1,184 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"tool/global/utils/common"
+	"tool/global/variable"
+	"tool/pkg/storage"
+
+	"go.uber.org/zap"
+)
+
+var ErrDirectNotSupported = errors.New("Disk does not support direct upload")
+
+// directDir 直传对象在磁盘上传目录下的子目录，上传通知只处理这个目录下的对象，
+// 存储桶通知也会包含普通上传和分片上传的对象，不能记录或删除
+const directDir = "direct"
+
+// DirectConfig 直传配置
+type DirectConfig struct {
+	Disk        string        // 直传使用的磁盘
+	MaxSize     int64         // 最大字节数，0 表示不限制
+	AllowExt    []string      // 允许的后缀，为空时不限制
+	AllowMime   []string      // 允许的类型，为空时不限制
+	Expire      time.Duration // 直传地址的有效期
+	CallbackURL string        // 存储服务回调的地址，{disk} 替换为磁盘名称
+}
+
+// LoadDirectConfig 从 DirectUpload 配置读取直传配置
+func LoadDirectConfig() DirectConfig {
+	config := variable.ConfigYml
+
+	// 配置为空字符串时 GetConfig 不会返回默认值
+	disk := config.GetString("DirectUpload.Disk")
+	if disk == "" {
+		disk = DefaultDisk()
+	}
+
+	return DirectConfig{
+		Disk:        disk,
+		MaxSize:     int64(config.GetConfig("DirectUpload.MaxSize", 1024).(int)) << 20,
+		AllowExt:    splitList(config.GetString("DirectUpload.AllowExt")),
+		AllowMime:   splitList(config.GetString("DirectUpload.AllowMime")),
+		Expire:      time.Duration(config.GetConfig("DirectUpload.Expire", 900).(int)) * time.Second,
+		CallbackURL: config.GetString("DirectUpload.CallbackURL"),
+	}
+}
+
+// DirectUpload 直传请求和对象 key
+type DirectUpload struct {
+	storage.PresignedUpload
+	Disk string `json:"disk"`
+	Key  string `json:"key"`
+}
+
+// PresignDirect 生成直传请求，文件不经过应用服务
+//
+// POST 表单上传时由存储服务限制大小范围和类型；PUT 时必须传入 size，按 size 签名 Content-Length。
+// 对象 key 为 "目录/direct/年/月/日/随机串.后缀"
+func PresignDirect(ctx context.Context, config DirectConfig, filename, contentType string, size int64, method string) (DirectUpload, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))
+	if len(config.AllowExt) > 0 && !common.InArray(ext, config.AllowExt) {
+		return DirectUpload{}, ErrExtNotAllowed
+	}
+	if len(config.AllowMime) > 0 && !common.InArray(contentType, config.AllowMime) {
+		return DirectUpload{}, ErrTypeNotAllowed
+	}
+	if strings.EqualFold(method, http.MethodPut) && size <= 0 {
+		return DirectUpload{}, storage.ErrSizeRequired
+	}
+	if size < 0 || (config.MaxSize > 0 && size > config.MaxSize) {
+		return DirectUpload{}, ErrTooLarge
+	}
+
+	disk, err := storage.Disk(config.Disk)
+	if err != nil {
+		return DirectUpload{}, err
+	}
+	uploader, ok := disk.(storage.DirectUploader)
+	if !ok {
+		return DirectUpload{}, ErrDirectNotSupported
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return DirectUpload{}, err
+	}
+	if ext != "" {
+		name += "." + ext
+	}
+	key := storage.Join(directPrefix(config.Disk), time.Now().Format("2006/01/02"), name)
+
+	presigned, err := uploader.PresignUpload(ctx, key, storage.UploadPolicy{
+		Method:      strings.ToUpper(method),
+		Size:        size,
+		MaxSize:     config.MaxSize,
+		ContentType: contentType,
+		Expires:     config.Expire,
+		CallbackURL: strings.ReplaceAll(config.CallbackURL, "{disk}", config.Disk),
+	})
+	if err != nil {
+		return DirectUpload{}, err
+	}
+
+	return DirectUpload{PresignedUpload: presigned, Disk: config.Disk, Key: key}, nil
+}
+
+// VerifyDirect 校验存储服务的上传通知，返回需要记录的对象
+//
+// 只处理 PresignDirect 生成的 key（上传目录下的 direct 目录），其他对象直接忽略；
+// 按存储中的实际大小和类型再检查一次限制，不满足时删除对象并跳过，其余对象照常返回；
+// 对象已不存在时同样跳过，通知重试时之前删除的对象不会让整批失败
+func VerifyDirect(ctx context.Context, config DirectConfig, diskName string, r *http.Request, body []byte) ([]storage.ObjectInfo, error) {
+	disk, err := storage.Disk(diskName)
+	if err != nil {
+		return nil, err
+	}
+	notifier, ok := disk.(storage.Notifier)
+	if !ok {
+		return nil, ErrDirectNotSupported
+	}
+
+	objects, err := notifier.VerifyNotification(ctx, r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := directPrefix(diskName) + "/"
+
+	var verified []storage.ObjectInfo
+	for _, object := range objects {
+		if !strings.HasPrefix(object.Key, prefix) {
+			continue
+		}
+
+		info, err := disk.Stat(ctx, object.Key)
+		if errors.Is(err, storage.ErrNotFound) {
+			variable.Logs.Warn("直传文件不存在，跳过", zap.String("key", object.Key))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var reject error
+		switch {
+		case config.MaxSize > 0 && info.Size > config.MaxSize:
+			reject = ErrTooLarge
+		case len(config.AllowMime) > 0 && !common.InArray(info.ContentType, config.AllowMime):
+			reject = ErrTypeNotAllowed
+		}
+		if reject != nil {
+			variable.Logs.Warn("直传文件不符合限制，跳过", zap.String("key", info.Key), zap.Error(reject))
+			if err := disk.Delete(ctx, info.Key); err != nil {
+				variable.Logs.Warn("删除不符合限制的直传文件失败", zap.String("key", info.Key), zap.Error(err))
+			}
+			continue
+		}
+
+		if info.ETag == "" {
+			info.ETag = object.ETag
+		}
+		verified = append(verified, info)
+	}
+	return verified, nil
+}
+
+// directPrefix 磁盘上直传对象的目录
+func directPrefix(diskName string) string {
+	return storage.Join(storage.DiskDir(diskName), directDir)
+}
+
+// DiskURL 磁盘上对象的公开访问地址
+func DiskURL(diskName, key string) string {
+	disk, err := storage.Disk(diskName)
+	if err != nil {
+		return ""
+	}
+	return disk.URL(key)
+}
@@ -0,0 +1,221 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"tool/global/utils/common"
+	"tool/global/variable"
+	"tool/pkg/imaging"
+	"tool/pkg/storage"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrVariantNotFound = errors.New("image variant not found")
+	ErrImageForbidden  = errors.New("image access denied")
+)
+
+// 内置的变体，可以在 Image.Variants 下按名称覆盖
+var defaultVariants = map[string]imaging.Options{
+	"thumb":  {Width: 150, Height: 150, Mode: imaging.ModeFill},
+	"medium": {Width: 800, Height: 600, Mode: imaging.ModeFit},
+	"large":  {Width: 1600, Height: 1200, Mode: imaging.ModeFit},
+}
+
+// variantGroup 同一变体并发请求时只生成一次
+var variantGroup singleflight.Group
+
+// ImageDisk 读取原图和缓存变体的磁盘，默认与上传使用的磁盘相同
+func ImageDisk() string {
+	if disk := variable.ConfigYml.GetString("Image.Disk"); disk != "" {
+		return disk
+	}
+	return DefaultDisk()
+}
+
+// Variant 按名称获取变体参数，名称需要在 Image.Presets 中
+func Variant(name string) (imaging.Options, error) {
+	presets := splitList(variable.ConfigYml.GetConfig("Image.Presets", "thumb,medium,large").(string))
+	if !common.InArray(name, presets) {
+		return imaging.Options{}, ErrVariantNotFound
+	}
+
+	prefix := "Image.Variants." + name + "."
+	opts := defaultVariants[name]
+	opts.Width = variable.ConfigYml.GetConfig(prefix+"Width", opts.Width).(int)
+	opts.Height = variable.ConfigYml.GetConfig(prefix+"Height", opts.Height).(int)
+	opts.Mode = variable.ConfigYml.GetConfig(prefix+"Mode", opts.Mode).(string)
+	opts.Quality = variable.ConfigYml.GetConfig(prefix+"Quality", variable.ConfigYml.GetInt("UploadFile.JPEGQuality")).(int)
+
+	format, err := imaging.ParseFormat(variable.ConfigYml.GetString(prefix + "Format"))
+	if err != nil {
+		return imaging.Options{}, err
+	}
+	opts.Format = format
+
+	if opts.Width <= 0 && opts.Height <= 0 {
+		return imaging.Options{}, ErrVariantNotFound
+	}
+	return opts, nil
+}
+
+// VariantURL 变体的访问地址
+func VariantURL(name, key string) string {
+	return "/img/" + name + "/" + key
+}
+
+// VariantURLs 所有变体的访问地址，按变体名称索引
+func VariantURLs(key string) map[string]string {
+	urls := make(map[string]string)
+	for _, name := range splitList(variable.ConfigYml.GetConfig("Image.Presets", "thumb,medium,large").(string)) {
+		urls[name] = VariantURL(name, key)
+	}
+	return urls
+}
+
+// ImageTransform 上传时处理图片：校正方向、去除 EXIF、按 ResizeWidth/ResizeHeight 缩小
+// 默认保持原格式，UploadFile.Format 可以统一转换为 jpeg / png / webp
+func ImageTransform() Transform {
+	return func(r io.Reader, contentType string) (io.Reader, string, string, error) {
+		if !isImage(contentType) {
+			return r, contentType, "", nil
+		}
+
+		// 读取时仍然受上传大小的限制
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		format, err := imaging.ParseFormat(variable.ConfigYml.GetString("UploadFile.Format"))
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		result, err := imaging.Process(data, imaging.Options{
+			Width:   variable.ConfigYml.GetInt("UploadFile.ResizeWidth"),
+			Height:  variable.ConfigYml.GetInt("UploadFile.ResizeHeight"),
+			Mode:    imaging.ModeFit,
+			Format:  format,
+			Quality: variable.ConfigYml.GetInt("UploadFile.JPEGQuality"),
+		})
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		return bytes.NewReader(result.Data), result.ContentType, result.Ext, nil
+	}
+}
+
+// OpenVariant 读取变体，缓存中没有时从原图生成并写入缓存目录
+// 缓存 key 为 "缓存目录/变体/原图key"，转换格式时追加新后缀
+//
+// 原图只能是磁盘上传目录（Oss.*.Dir）下的对象；本地磁盘配置了 Secret 时，
+// query 需要带上原图 SignedURL 的签名参数，与 /files 的访问限制一致
+func OpenVariant(ctx context.Context, name, key string, query url.Values) (io.ReadCloser, storage.ObjectInfo, error) {
+	opts, err := Variant(name)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, err
+	}
+
+	cacheDir := strings.Trim(variable.ConfigYml.GetConfig("Image.CacheDir", "variants").(string), "/")
+	if key == cacheDir || strings.HasPrefix(key, cacheDir+"/") {
+		return nil, storage.ObjectInfo{}, storage.ErrInvalidKey
+	}
+
+	diskName := ImageDisk()
+	if dir := storage.DiskDir(diskName); dir != "" && !strings.HasPrefix(key, dir+"/") {
+		return nil, storage.ObjectInfo{}, storage.ErrInvalidKey
+	}
+
+	disk, err := storage.Disk(diskName)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, err
+	}
+
+	if local, ok := disk.(*storage.LocalDriver); ok {
+		if err := local.Verify(http.MethodGet, key, query); err != nil && !errors.Is(err, storage.ErrNotSupported) {
+			return nil, storage.ObjectInfo{}, ErrImageForbidden
+		}
+	}
+
+	cacheKey := storage.Join(cacheDir, name, key)
+	if opts.Format != "" {
+		cacheKey += "." + imaging.Ext(opts.Format)
+	}
+
+	if reader, info, err := disk.Get(ctx, cacheKey); err == nil {
+		return reader, info, nil
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, storage.ObjectInfo{}, err
+	}
+
+	value, err, _ := variantGroup.Do(cacheKey, func() (interface{}, error) {
+		return generateVariant(ctx, disk, key, cacheKey, opts)
+	})
+	if err != nil {
+		return nil, storage.ObjectInfo{}, err
+	}
+
+	result := value.(variantResult)
+	return io.NopCloser(bytes.NewReader(result.data)), result.info, nil
+}
+
+// variantResult 生成的变体
+type variantResult struct {
+	data []byte
+	info storage.ObjectInfo
+}
+
+// generateVariant 读取原图、处理并写入缓存
+func generateVariant(ctx context.Context, disk storage.Driver, key, cacheKey string, opts imaging.Options) (variantResult, error) {
+	reader, info, err := disk.Get(ctx, key)
+	if err != nil {
+		return variantResult{}, err
+	}
+	defer reader.Close()
+
+	maxSize := int64(variable.ConfigYml.GetConfig("Image.MaxSourceSize", 20).(int)) << 20
+	if info.Size > maxSize {
+		return variantResult{}, ErrTooLarge
+	}
+
+	data, err := io.ReadAll(&limitReader{r: reader, remaining: maxSize})
+	if err != nil {
+		return variantResult{}, err
+	}
+
+	result, err := imaging.Process(data, opts)
+	if err != nil {
+		return variantResult{}, err
+	}
+
+	cached, err := disk.Put(ctx, cacheKey, bytes.NewReader(result.Data), storage.PutOptions{
+		Size:         int64(len(result.Data)),
+		ContentType:  result.ContentType,
+		CacheControl: "public, max-age=31536000",
+	})
+	if err != nil {
+		// 缓存失败时仍然返回生成的结果
+		variable.Logs.Warn("缓存图片变体失败: " + cacheKey + ", " + err.Error())
+		cached = storage.ObjectInfo{Key: cacheKey, Size: int64(len(result.Data))}
+	}
+	cached.ContentType = result.ContentType
+
+	return variantResult{data: result.Data, info: cached}, nil
+}
+
+// isImage 可以处理的图片类型
+func isImage(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package oss
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"tool/global/utils/common"
+	"tool/global/variable"
+	"tool/pkg/storage"
+)
+
+var (
+	ErrTooLarge       = errors.New("File size exceeds the limit")
+	ErrExtNotAllowed  = errors.New("File suffix is not allowed")
+	ErrTypeNotAllowed = errors.New("File type is not allowed")
+)
+
+// sniffLength 识别文件类型需要读取的字节数，与 http.DetectContentType 一致
+const sniffLength = 512
+
+// Limits 上传限制
+type Limits struct {
+	MaxSize   int64    // 最大字节数，0 表示不限制
+	AllowExt  []string // 允许的后缀，为空时不限制
+	AllowMime []string // 允许的类型（按内容识别），为空时不限制
+	PartSize  int64    // 分片上传的分片大小
+}
+
+// LoadLimits 从 UploadFile 配置读取上传限制
+func LoadLimits() Limits {
+	return Limits{
+		MaxSize:   int64(variable.ConfigYml.GetInt("UploadFile.MaxSize")) << 20,
+		AllowExt:  splitList(variable.ConfigYml.GetString("UploadFile.AllowExt")),
+		AllowMime: splitList(variable.ConfigYml.GetString("UploadFile.AllowMime")),
+		PartSize:  int64(variable.ConfigYml.GetConfig("UploadFile.PartSize", 8).(int)) << 20,
+	}
+}
+
+// Transform 写入前转换内容，如压缩图片，返回新的内容、类型和后缀，后缀为空时保留原后缀
+type Transform func(r io.Reader, contentType string) (io.Reader, string, string, error)
+
+// Result 上传结果
+type Result struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// DefaultDisk 上传使用的磁盘，UploadFile.Disk 未配置时为 Aliyun，兼容旧配置
+func DefaultDisk() string {
+	return variable.ConfigYml.GetConfig("UploadFile.Disk", "Aliyun").(string)
+}
+
+// Upload 流式上传到配置的磁盘
+//
+// 按文件名检查后缀，按内容开头的魔数识别类型，不使用客户端提交的 Content-Type；
+// 读取时检查大小，超过限制时中止写入，驱动会清理已上传的分片；
+// 对象 key 为 "目录/年/月/日/随机串.后缀"，不使用客户端提交的文件名
+func Upload(ctx context.Context, filename string, r io.Reader, limits Limits, transform Transform) (Result, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))
+	if len(limits.AllowExt) > 0 && !common.InArray(ext, limits.AllowExt) {
+		return Result{}, ErrExtNotAllowed
+	}
+
+	if limits.MaxSize > 0 {
+		r = &limitReader{r: r, remaining: limits.MaxSize}
+	}
+
+	contentType, r, err := Sniff(r)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(limits.AllowMime) > 0 && !common.InArray(contentType, limits.AllowMime) {
+		return Result{}, ErrTypeNotAllowed
+	}
+
+	if transform != nil {
+		var newExt string
+		r, contentType, newExt, err = transform(r, contentType)
+		if err != nil {
+			return Result{}, err
+		}
+		if newExt != "" {
+			ext = newExt
+		}
+
+		// 转换结果可能是管道，写入失败时关闭，避免生成方一直阻塞
+		if closer, ok := r.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+
+	diskName := DefaultDisk()
+	disk, err := storage.Disk(diskName)
+	if err != nil {
+		return Result{}, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return Result{}, err
+	}
+	if ext != "" {
+		name += "." + ext
+	}
+	key := storage.Join(storage.DiskDir(diskName), time.Now().Format("2006/01/02"), name)
+
+	info, err := storage.PutStream(ctx, disk, key, r, storage.PutOptions{Size: -1, ContentType: contentType}, limits.PartSize)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Key: info.Key, URL: disk.URL(info.Key), Size: info.Size, ContentType: contentType}, nil
+}
+
+// Sniff 按内容开头的魔数识别类型，返回的 Reader 包含已读取的内容
+func Sniff(r io.Reader) (string, io.Reader, error) {
+	buffered := bufio.NewReaderSize(r, sniffLength)
+
+	head, err := buffered.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", nil, err
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return contentType, buffered, nil
+}
+
+// limitReader 超过大小限制时返回 ErrTooLarge，而不是像 io.LimitReader 一样截断
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// 已达到限制，再读一个字节判断是否还有内容
+		var one [1]byte
+		n, err := l.r.Read(one[:])
+		if n > 0 {
+			return 0, ErrTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// randomName 随机文件名
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// splitList 逗号分隔的配置，去除空白和空项
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
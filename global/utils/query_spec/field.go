@@ -0,0 +1,114 @@
+package query_spec
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// 支持的过滤操作符
+const (
+	OpEq      = "eq"      // 等于
+	OpNe      = "ne"      // 不等于
+	OpGt      = "gt"      // 大于
+	OpGte     = "gte"     // 大于等于
+	OpLt      = "lt"      // 小于
+	OpLte     = "lte"     // 小于等于
+	OpLike    = "like"    // 模糊匹配
+	OpIn      = "in"      // 在列表中，逗号分隔
+	OpBetween = "between" // 区间，逗号分隔的两个值
+	OpNull    = "null"    // 是否为空，值为 true / false
+)
+
+var operators = map[string]bool{
+	OpEq: true, OpNe: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true,
+	OpLike: true, OpIn: true, OpBetween: true, OpNull: true,
+}
+
+// field 模型上声明的可查询字段
+//
+// 通过 query 标签声明，多个选项用分号分隔：
+//
+//	Username string `json:"username" query:"filter:eq,like;sort;search"`
+//
+// filter: 允许的过滤操作符；sort: 允许排序；search: 参与关键字搜索
+// 查询参数中的字段名使用 json 标签名，数据库列名取自 gorm
+type field struct {
+	Name      string          // 查询参数中的字段名
+	Column    string          // 数据库列名
+	Operators map[string]bool // 允许的操作符
+	Sortable  bool            // 是否允许排序
+	Search    bool            // 是否参与关键字搜索
+}
+
+// fields 模型的可查询字段
+type fields struct {
+	byName        map[string]*field
+	searchColumns []string
+	sortColumns   []string
+}
+
+var (
+	fieldsCache sync.Map // reflect.Type => *fields
+	schemaCache sync.Map
+)
+
+// parseFields 解析模型的 query 标签
+func parseFields[T any]() (*fields, error) {
+	typ := reflect.TypeOf(new(T)).Elem()
+
+	if cached, ok := fieldsCache.Load(typ); ok {
+		return cached.(*fields), nil
+	}
+
+	s, err := schema.Parse(new(T), &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &fields{byName: make(map[string]*field)}
+
+	for _, sf := range s.Fields {
+		tag, ok := sf.Tag.Lookup("query")
+		if !ok || sf.DBName == "" {
+			continue
+		}
+
+		f := &field{Name: jsonName(sf), Column: sf.DBName, Operators: make(map[string]bool)}
+
+		for _, option := range strings.Split(tag, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(option), ":")
+
+			switch key {
+			case "filter":
+				for _, op := range strings.Split(value, ",") {
+					if op = strings.TrimSpace(op); operators[op] {
+						f.Operators[op] = true
+					}
+				}
+			case "sort":
+				f.Sortable = true
+				result.sortColumns = append(result.sortColumns, f.Column)
+			case "search":
+				f.Search = true
+				result.searchColumns = append(result.searchColumns, f.Column)
+			}
+		}
+
+		result.byName[f.Name] = f
+	}
+
+	fieldsCache.Store(typ, result)
+	return result, nil
+}
+
+// jsonName 取 json 标签名，没有时使用数据库列名
+func jsonName(sf *schema.Field) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.DBName
+	}
+	return name
+}
@@ -0,0 +1,264 @@
+package query_spec
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"tool/global/utils/curd"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnknownField = errors.New("不支持查询的字段")
+	ErrOperator     = errors.New("不支持的查询操作符")
+	ErrValue        = errors.New("查询参数值错误")
+)
+
+// maxInValues in 查询最多允许的值个数
+const maxInValues = 100
+
+// filterKey 匹配 filter[field] 或 filter[field][op]
+var filterKey = regexp.MustCompile(`^filter\[([A-Za-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// Condition 单个过滤条件
+type Condition struct {
+	Field  string   // 查询参数中的字段名
+	Column string   // 数据库列名
+	Op     string   // 操作符
+	Values []string // 参数值
+}
+
+// Spec 列表查询规格
+//
+//	?filter[username][like]=a&filter[id][in]=1,2&q=keyword&sort=-create_time&page=2&size=20
+type Spec struct {
+	Conditions    []Condition
+	Search        string   // 关键字，对 search 字段做模糊匹配
+	Sort          []string // 排序，数据库列名，前缀 "-" 表示倒序
+	Page          int
+	Size          int
+	searchColumns []string
+	sortColumns   []string
+}
+
+// Bind 从请求的查询参数解析
+func Bind[T any](c *gin.Context) (Spec, error) {
+	return Parse[T](c.Request.URL.Query())
+}
+
+// Parse 解析查询参数，字段和操作符必须在模型 T 的 query 标签中声明
+func Parse[T any](values url.Values) (Spec, error) {
+	var spec Spec
+
+	fields, err := parseFields[T]()
+	if err != nil {
+		return spec, err
+	}
+
+	spec.searchColumns = fields.searchColumns
+	spec.sortColumns = fields.sortColumns
+
+	// 按参数名排序，保证生成的 SQL 稳定
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		vals := values[key]
+
+		match := filterKey.FindStringSubmatch(key)
+		if match == nil {
+			return spec, fmt.Errorf("%w: %s", ErrUnknownField, key)
+		}
+
+		f, ok := fields.byName[match[1]]
+		if !ok {
+			return spec, fmt.Errorf("%w: %s", ErrUnknownField, match[1])
+		}
+
+		op := match[2]
+		if op == "" {
+			op = OpEq
+		}
+
+		if !f.Operators[op] {
+			return spec, fmt.Errorf("%w: %s[%s]", ErrOperator, f.Name, op)
+		}
+
+		condition, err := newCondition(f, op, vals[len(vals)-1])
+		if err != nil {
+			return spec, err
+		}
+
+		spec.Conditions = append(spec.Conditions, condition)
+	}
+
+	if search := strings.TrimSpace(values.Get("q")); search != "" && len(fields.searchColumns) > 0 {
+		spec.Search = search
+	}
+
+	for _, item := range strings.Split(values.Get("sort"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(item, "-")
+		name := strings.TrimLeft(item, "+-")
+
+		f, ok := fields.byName[name]
+		if !ok || !f.Sortable {
+			return spec, fmt.Errorf("%w: sort %s", ErrUnknownField, name)
+		}
+
+		if desc {
+			spec.Sort = append(spec.Sort, "-"+f.Column)
+		} else {
+			spec.Sort = append(spec.Sort, f.Column)
+		}
+	}
+
+	spec.Page, _ = strconv.Atoi(values.Get("page"))
+	spec.Size, _ = strconv.Atoi(values.Get("size"))
+
+	return spec, nil
+}
+
+// newCondition 校验参数值并创建条件
+func newCondition(f *field, op, raw string) (Condition, error) {
+	condition := Condition{Field: f.Name, Column: f.Column, Op: op, Values: []string{raw}}
+
+	switch op {
+	case OpIn:
+		condition.Values = strings.Split(raw, ",")
+		if len(condition.Values) > maxInValues {
+			return condition, fmt.Errorf("%w: %s 最多 %d 个值", ErrValue, f.Name, maxInValues)
+		}
+	case OpBetween:
+		condition.Values = strings.Split(raw, ",")
+		if len(condition.Values) != 2 {
+			return condition, fmt.Errorf("%w: %s 需要两个值", ErrValue, f.Name)
+		}
+	case OpNull:
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return condition, fmt.Errorf("%w: %s", ErrValue, f.Name)
+		}
+	}
+
+	return condition, nil
+}
+
+// Where 返回过滤和搜索条件的 gorm 查询范围
+func (s Spec) Where() curd.Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, condition := range s.Conditions {
+			db = db.Where(condition.expression())
+		}
+
+		if s.Search != "" {
+			var or []clause.Expression
+			for _, column := range s.searchColumns {
+				or = append(or, clause.Like{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: likeValue(s.Search)})
+			}
+			db = db.Where(clause.Or(or...))
+		}
+
+		return db
+	}
+}
+
+// Scope 返回过滤、搜索和排序的 gorm 查询范围，用于直接查询 gorm
+//
+//	db.Scopes(spec.Scope(), spec.Paginate()).Find(&list)
+func (s Spec) Scope() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = s.Where()(db)
+
+		if len(s.Sort) > 0 {
+			var columns []clause.OrderByColumn
+			for _, item := range s.Sort {
+				columns = append(columns, clause.OrderByColumn{
+					Column: clause.Column{Table: clause.CurrentTable, Name: strings.TrimPrefix(item, "-")},
+					Desc:   strings.HasPrefix(item, "-"),
+				})
+			}
+			db = db.Clauses(clause.OrderBy{Columns: columns})
+		}
+
+		return db
+	}
+}
+
+// Paginate 返回分页的 gorm 查询范围，每页条数按 curd 的规则限制
+func (s Spec) Paginate() func(db *gorm.DB) *gorm.DB {
+	req := s.PageRequest().Normalize()
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset((req.Page - 1) * req.Size).Limit(req.Size)
+	}
+}
+
+// PageRequest 转换为 curd 的分页请求
+func (s Spec) PageRequest() curd.PageRequest {
+	return curd.PageRequest{Page: s.Page, Size: s.Size, Sort: strings.Join(s.Sort, ",")}
+}
+
+// Apply 将查询规格应用到仓储，之后可直接调用 Page(ctx, spec.PageRequest())
+func Apply[T any](repo *curd.Config[T], spec Spec) *curd.Config[T] {
+	return repo.Scopes(spec.Where()).Sortable(spec.sortColumns...)
+}
+
+// expression 转换为 gorm 条件表达式，列名与参数均不会拼接进 SQL
+func (c Condition) expression() clause.Expression {
+	column := clause.Column{Table: clause.CurrentTable, Name: c.Column}
+	value := c.Values[0]
+
+	switch c.Op {
+	case OpNe:
+		return clause.Neq{Column: column, Value: value}
+	case OpGt:
+		return clause.Gt{Column: column, Value: value}
+	case OpGte:
+		return clause.Gte{Column: column, Value: value}
+	case OpLt:
+		return clause.Lt{Column: column, Value: value}
+	case OpLte:
+		return clause.Lte{Column: column, Value: value}
+	case OpLike:
+		return clause.Like{Column: column, Value: likeValue(value)}
+	case OpIn:
+		values := make([]interface{}, len(c.Values))
+		for i, v := range c.Values {
+			values[i] = v
+		}
+		return clause.IN{Column: column, Values: values}
+	case OpBetween:
+		return clause.And(
+			clause.Gte{Column: column, Value: c.Values[0]},
+			clause.Lte{Column: column, Value: c.Values[1]},
+		)
+	case OpNull:
+		if isNull, _ := strconv.ParseBool(value); isNull {
+			return clause.Eq{Column: column, Value: nil}
+		}
+		return clause.Neq{Column: column, Value: nil}
+	default:
+		return clause.Eq{Column: column, Value: value}
+	}
+}
+
+// likeValue 转义通配符后两侧加 %
+func likeValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(value) + "%"
+}
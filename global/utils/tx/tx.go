@@ -0,0 +1,166 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/mysql"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 默认使用的数据库连接名称，与 db_client.MysqlLocal() 一致
+const defaultConn = "Local"
+
+// MySQL 死锁错误码
+const errDeadlock = 1213
+
+// ctxKey 上下文中保存事务的键，按连接名称区分，不同连接的事务互不影响
+type ctxKey string
+
+// state 当前层级的事务状态
+type state struct {
+	db    *gorm.DB
+	hooks []func(ctx context.Context)
+}
+
+// options 事务参数
+type options struct {
+	conn    string
+	retries int
+	backoff time.Duration
+}
+
+// Option 事务参数设置
+type Option func(*options)
+
+// WithConn 指定数据库连接名称，默认 Local
+func WithConn(conn string) Option {
+	return func(o *options) {
+		o.conn = conn
+	}
+}
+
+// WithRetries 死锁时的最大重试次数，默认 3 次，0 表示不重试
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries = retries
+		o.backoff = backoff
+	}
+}
+
+// Run 在事务中执行 fn，fn 中应使用传入的 ctx 调用仓储方法
+//
+//	err := tx.Run(ctx, func(ctx context.Context) error {
+//		if err := curd.New[model.Order]().Create(ctx, &order); err != nil {
+//			return err
+//		}
+//		tx.AfterCommit(ctx, func(ctx context.Context) { ... })
+//		return nil
+//	})
+//
+// fn 返回错误或 panic 时回滚；ctx 中已有同一连接的事务时使用保存点嵌套，
+// 嵌套事务失败只回滚到保存点；最外层事务遇到死锁时会整体重试
+func Run(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	o := &options{conn: defaultConn, retries: 3, backoff: 50 * time.Millisecond}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	key := ctxKey(o.conn)
+
+	// 嵌套事务：使用保存点，成功后将提交回调交给上层事务
+	if parent, ok := ctx.Value(key).(*state); ok {
+		current := &state{}
+		err := parent.db.Transaction(func(db *gorm.DB) error {
+			current.db = db
+			return fn(context.WithValue(ctx, key, current))
+		})
+		if err == nil {
+			parent.hooks = append(parent.hooks, current.hooks...)
+		}
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		current := &state{}
+		err := mysql.NewClient(o.conn).WithContext(ctx).Transaction(func(db *gorm.DB) error {
+			current.db = db
+			return fn(context.WithValue(ctx, key, current))
+		})
+
+		if err == nil {
+			runHooks(ctx, current.hooks)
+			return nil
+		}
+
+		if !IsDeadlock(err) || attempt >= o.retries {
+			return err
+		}
+
+		variable.Logs.Warn("事务死锁，准备重试", zap.String("conn", o.conn), zap.Int("attempt", attempt+1), zap.Error(err))
+
+		// 退避加随机抖动，避免冲突的事务同时重试
+		wait := o.backoff*time.Duration(attempt+1) + time.Duration(rand.Int63n(int64(o.backoff)+1))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// DB 获取当前上下文使用的数据库连接：在事务中时返回事务，否则返回普通连接
+func DB(ctx context.Context, conn string) *gorm.DB {
+	if current, ok := ctx.Value(ctxKey(conn)).(*state); ok {
+		return current.db.WithContext(ctx)
+	}
+	return mysql.NewClient(conn).WithContext(ctx)
+}
+
+// InTx 当前上下文是否处于指定连接的事务中
+func InTx(ctx context.Context, conn string) bool {
+	_, ok := ctx.Value(ctxKey(conn)).(*state)
+	return ok
+}
+
+// AfterCommit 注册最外层事务提交后执行的回调，事务回滚时不会执行
+// 不在事务中时立即执行；conn 为空时使用默认连接
+func AfterCommit(ctx context.Context, fn func(ctx context.Context), conn ...string) {
+	name := defaultConn
+	if len(conn) > 0 && conn[0] != "" {
+		name = conn[0]
+	}
+
+	current, ok := ctx.Value(ctxKey(name)).(*state)
+	if !ok {
+		runHooks(ctx, []func(ctx context.Context){fn})
+		return
+	}
+
+	current.hooks = append(current.hooks, fn)
+}
+
+// IsDeadlock 是否为 MySQL 死锁错误
+func IsDeadlock(err error) bool {
+	var mysqlErr *mysqlDriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDeadlock
+}
+
+// runHooks 依次执行提交回调，单个回调 panic 不影响其他回调
+func runHooks(ctx context.Context, hooks []func(ctx context.Context)) {
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					variable.Logs.Error("事务提交回调执行失败", zap.Any("panic", r))
+				}
+			}()
+			hook(ctx)
+		}()
+	}
+}
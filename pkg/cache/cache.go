@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound 缓存不存在或已过期
+var ErrNotFound = errors.New("缓存不存在")
+
+// Cache 缓存后端，只处理字节数据，序列化由 Store 负责
+type Cache interface {
+	// Get 获取缓存，不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set 设置缓存，ttl 为 0 时不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete 删除缓存，key 不存在时不返回错误
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"sync"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/memcached"
+	"tool/pkg/redis"
+
+	"go.uber.org/zap"
+)
+
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	Driver     string        // 驱动 redis / memcached / memory
+	Connection string        // redis.yml / memcached.yml 中的连接名
+	Serializer string        // 序列化方式 json / msgpack
+	Prefix     string        // key 前缀
+	Jitter     float64       // 过期时间随机浮动比例
+	Local      bool          // 是否开启本地二级缓存（memory 驱动时无效）
+	LocalSize  int           // 本地缓存最多保存的条数
+	LocalTTL   time.Duration // 本地缓存的最长保存时间
+}
+
+// 加载配置
+func loadConfig() CacheConfig {
+
+	config := CacheConfig{
+		Driver:     variable.ConfigYml.GetString("Cache.Driver"),
+		Connection: variable.ConfigYml.GetString("Cache.Connection"),
+		Serializer: variable.ConfigYml.GetString("Cache.Serializer"),
+		Prefix:     variable.ConfigYml.GetString("Cache.Prefix"),
+		Jitter:     variable.ConfigYml.GetConfig("Cache.Jitter", 0.1).(float64),
+		Local:      variable.ConfigYml.GetBool("Cache.Local"),
+		LocalSize:  variable.ConfigYml.GetInt("Cache.LocalSize"),
+		LocalTTL:   variable.ConfigYml.GetDuration("Cache.LocalTTL") * time.Second,
+	}
+
+	if config.Driver == "" {
+		config.Driver = "memory"
+	}
+
+	if config.Connection == "" {
+		config.Connection = "Local"
+	}
+
+	return config
+}
+
+var (
+	defaultStore *Store
+	defaultOnce  sync.Once
+)
+
+// Default 获取按配置创建的全局缓存
+//
+// Cache:
+//
+//	Driver: "redis"        # redis / memcached / memory
+//	Connection: "Local"    # redis.yml / memcached.yml 中的连接名
+//	Serializer: "json"     # json / msgpack
+//	Prefix: "cache:"       # key 前缀
+//	Jitter: 0.1            # 过期时间随机浮动比例
+//	Local: true            # 开启本地二级缓存
+//	LocalSize: 10000       # 本地缓存最多保存的条数
+//	LocalTTL: 10           # 本地缓存的最长保存时间(秒)
+func Default() *Store {
+	defaultOnce.Do(func() {
+		config := loadConfig()
+
+		var backend Cache
+		switch config.Driver {
+		case "redis":
+			backend = NewRedis(redis.NewClient(config.Connection))
+		case "memcached":
+			backend = NewMemcached(memcached.NewClient(config.Connection))
+		default:
+			backend = NewMemory(config.LocalSize)
+		}
+
+		if config.Local && config.Driver != "memory" {
+			backend = NewTiered(NewMemory(config.LocalSize), backend, config.LocalTTL)
+		}
+
+		defaultStore = New(backend,
+			WithSerializer(serializerByName(config.Serializer)),
+			WithPrefix(config.Prefix),
+			WithJitter(config.Jitter),
+		)
+
+		variable.Logs.Info("Cache initialized", zap.String("driver", config.Driver), zap.Bool("local", config.Local))
+	})
+
+	return defaultStore
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// memcached 相对过期时间的上限，超过时需要使用 Unix 时间戳
+const memcachedMaxRelative = 30 * 24 * time.Hour
+
+// MemcachedCache Memcached 缓存后端
+type MemcachedCache struct {
+	client *memcache.Client
+}
+
+// NewMemcached 创建 Memcached 缓存后端
+func NewMemcached(client *memcache.Client) *MemcachedCache {
+	return &MemcachedCache{client: client}
+}
+
+func (c *MemcachedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	item, err := c.client.Get(memcachedKey(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.Value, nil
+}
+
+func (c *MemcachedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(&memcache.Item{Key: memcachedKey(key), Value: value, Expiration: memcachedExpiration(ttl)})
+}
+
+func (c *MemcachedCache) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := c.client.Delete(memcachedKey(key)); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+			return err
+		}
+	}
+	return nil
+}
+
+// memcachedExpiration 转换过期时间，超过 30 天时使用 Unix 时间戳
+func memcachedExpiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > memcachedMaxRelative {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	if ttl < time.Second {
+		return 1
+	}
+	return int32(ttl / time.Second)
+}
+
+// memcachedKey memcached 的 key 最长 250 字节且不能包含空白和控制字符，不合法时使用 md5
+func memcachedKey(key string) string {
+	if len(key) <= 250 {
+		valid := true
+		for i := 0; i < len(key); i++ {
+			if key[i] <= ' ' || key[i] == 0x7f {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			return key
+		}
+	}
+
+	sum := md5.Sum([]byte(key))
+	return "md5:" + hex.EncodeToString(sum[:])
+}
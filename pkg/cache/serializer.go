@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"encoding/json"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Serializer 序列化方式
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSON 使用 encoding/json 序列化
+var JSON Serializer = jsonSerializer{}
+
+// Msgpack 使用 msgpack 序列化，体积更小，结构体字段按 json 标签命名
+var Msgpack Serializer = newMsgpack()
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonSerializer) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackSerializer struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpack() Serializer {
+	handle := &codec.MsgpackHandle{}
+	handle.WriteExt = true
+	handle.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	return msgpackSerializer{handle: handle}
+}
+
+func (s msgpackSerializer) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, s.handle).Encode(v)
+	return data, err
+}
+
+func (s msgpackSerializer) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, s.handle).Decode(v)
+}
+
+// serializerByName 按配置名称获取序列化方式
+func serializerByName(name string) Serializer {
+	if name == "msgpack" {
+		return Msgpack
+	}
+	return JSON
+}
@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// 标签版本号的 key 前缀
+const tagPrefix = "tag:"
+
+// Store 带序列化、标签和防击穿的缓存
+//
+// 标签通过版本号实现：写入时记录每个标签的当前版本，读取时版本不一致视为未命中，
+// 因此 InvalidateTags 只需要更新版本号，不需要遍历数据，所有后端都适用
+type Store struct {
+	backend    Cache
+	tags       Cache // 标签版本号存储，两级缓存时只使用远程缓存，避免读到本地旧版本
+	serializer Serializer
+	prefix     string
+	jitter     float64
+	group      singleflight.Group
+}
+
+// Option Store 参数设置
+type Option func(*Store)
+
+// WithSerializer 设置序列化方式，默认 JSON
+func WithSerializer(serializer Serializer) Option {
+	return func(s *Store) {
+		s.serializer = serializer
+	}
+}
+
+// WithPrefix 设置 key 前缀
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithJitter 设置过期时间的随机浮动比例，避免大量缓存同时过期，默认 0.1
+func WithJitter(jitter float64) Option {
+	return func(s *Store) {
+		s.jitter = jitter
+	}
+}
+
+// New 创建缓存
+func New(backend Cache, opts ...Option) *Store {
+	s := &Store{backend: backend, tags: backend, serializer: JSON, jitter: 0.1}
+
+	if tiered, ok := backend.(*TieredCache); ok {
+		s.tags = tiered.Remote()
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// envelope 缓存数据的存储格式
+type envelope struct {
+	Value []byte            `json:"v" codec:"v"`
+	Tags  map[string]string `json:"t,omitempty" codec:"t,omitempty"`
+}
+
+// Get 获取缓存并反序列化到 out，不存在或标签已失效时返回 ErrNotFound
+func (s *Store) Get(ctx context.Context, key string, out interface{}) error {
+	data, err := s.backend.Get(ctx, s.prefix+key)
+	if err != nil {
+		return err
+	}
+
+	// 数据格式不一致（如切换了序列化方式）时视为未命中，由下次写入覆盖
+	var env envelope
+	if err := s.serializer.Unmarshal(data, &env); err != nil {
+		return ErrNotFound
+	}
+
+	if len(env.Tags) > 0 {
+		names := make([]string, 0, len(env.Tags))
+		for name := range env.Tags {
+			names = append(names, name)
+		}
+
+		versions, err := s.tagVersions(ctx, names, false)
+		if err != nil {
+			return err
+		}
+
+		for name, version := range env.Tags {
+			if versions[name] != version {
+				return ErrNotFound
+			}
+		}
+	}
+
+	return s.serializer.Unmarshal(env.Value, out)
+}
+
+// Set 序列化后写入缓存，ttl 会增加随机浮动
+func (s *Store) Set(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	versions, err := s.tagVersions(ctx, tags, true)
+	if err != nil {
+		return err
+	}
+	return s.set(ctx, key, value, ttl, versions)
+}
+
+// set 按指定的标签版本号写入缓存
+func (s *Store) set(ctx context.Context, key string, value interface{}, ttl time.Duration, versions map[string]string) error {
+	data, err := s.serializer.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	env := envelope{Value: data}
+	if len(versions) > 0 {
+		env.Tags = versions
+	}
+
+	if data, err = s.serializer.Marshal(env); err != nil {
+		return err
+	}
+
+	return s.backend.Set(ctx, s.prefix+key, data, s.withJitter(ttl))
+}
+
+// Delete 删除缓存
+func (s *Store) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.prefix + key
+	}
+	return s.backend.Delete(ctx, prefixed...)
+}
+
+// InvalidateTags 使带有这些标签的缓存全部失效
+func (s *Store) InvalidateTags(ctx context.Context, tags ...string) error {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	for _, tag := range tags {
+		if err := s.tags.Set(ctx, s.prefix+tagPrefix+tag, []byte(version), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagVersions 获取标签当前版本号，create 为 true 时为不存在的标签创建版本号
+func (s *Store) tagVersions(ctx context.Context, tags []string, create bool) (map[string]string, error) {
+	versions := make(map[string]string, len(tags))
+
+	for _, tag := range tags {
+		key := s.prefix + tagPrefix + tag
+
+		value, err := s.tags.Get(ctx, key)
+		switch {
+		case err == nil:
+			versions[tag] = string(value)
+		case errors.Is(err, ErrNotFound) && create:
+			version := strconv.FormatInt(time.Now().UnixNano(), 36)
+			if err := s.tags.Set(ctx, key, []byte(version), 0); err != nil {
+				return nil, err
+			}
+			versions[tag] = version
+		case errors.Is(err, ErrNotFound):
+			versions[tag] = ""
+		default:
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// withJitter 在 ttl 基础上增加 0 ~ jitter 比例的随机时间
+func (s *Store) withJitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || s.jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(float64(ttl)*s.jitter)+1))
+}
+
+// RememberWith 从指定缓存读取，未命中时调用 loader 加载并写入
+// 同一个 key 的并发加载只会执行一次 loader
+func RememberWith[T any](ctx context.Context, s *Store, key string, ttl time.Duration, loader func(ctx context.Context) (T, error), tags ...string) (T, error) {
+	var value T
+
+	if err := s.Get(ctx, key, &value); err == nil {
+		return value, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		// 缓存不可用时直接加载，不影响业务
+		return loader(ctx)
+	}
+
+	result, err, _ := s.group.Do(key, func() (interface{}, error) {
+		// 先取标签版本号再加载，加载期间标签失效时写入的缓存会直接过期
+		versions, versionErr := s.tagVersions(ctx, tags, true)
+
+		loaded, err := loader(ctx)
+		if err != nil {
+			return loaded, err
+		}
+
+		if versionErr == nil {
+			_ = s.set(ctx, key, loaded, ttl, versions)
+		}
+		return loaded, nil
+	})
+
+	if err != nil {
+		return value, err
+	}
+
+	return result.(T), nil
+}
+
+// Remember 使用默认缓存，见 RememberWith
+//
+//	admin, err := cache.Remember(ctx, "admin:1", time.Minute, func(ctx context.Context) (model.Admin, error) {
+//		return curd.New[model.Admin]().Where("id = ?", 1).First(ctx)
+//	}, "admin")
+func Remember[T any](ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error), tags ...string) (T, error) {
+	return RememberWith(ctx, Default(), key, ttl, loader, tags...)
+}
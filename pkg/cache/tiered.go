@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TieredCache 两级缓存：进程内缓存 + 远程缓存
+//
+// 读取时先查本地，未命中再查远程并回填本地；写入和删除同时作用于两级。
+// 其他进程的本地缓存不会收到删除通知，因此本地过期时间应设置得较短
+type TieredCache struct {
+	local    Cache
+	remote   Cache
+	localTTL time.Duration
+}
+
+// NewTiered 创建两级缓存，localTTL 为本地缓存的最长保存时间
+func NewTiered(local, remote Cache, localTTL time.Duration) *TieredCache {
+	if localTTL <= 0 {
+		localTTL = 10 * time.Second
+	}
+	return &TieredCache{local: local, remote: remote, localTTL: localTTL}
+}
+
+// Remote 远程缓存
+func (c *TieredCache) Remote() Cache {
+	return c.remote
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := c.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = c.local.Set(ctx, key, value, c.localTTL)
+	return value, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	localTTL := c.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	return c.local.Set(ctx, key, value, localTTL)
+}
+
+func (c *TieredCache) Delete(ctx context.Context, keys ...string) error {
+	return errors.Join(c.local.Delete(ctx, keys...), c.remote.Delete(ctx, keys...))
+}
@@ -0,0 +1,35 @@
+package event_bus
+
+import "tool/global/variable"
+
+// BusConfig 事件总线配置
+type BusConfig struct {
+	Driver     string // 驱动 redis（默认）/ memory
+	Connection string // redis 连接名
+	MaxLen     int64  // 每个主题保留的历史事件条数
+}
+
+// 加载配置
+func loadConfig() BusConfig {
+
+	config := BusConfig{
+		Driver:     variable.ConfigYml.GetString("EventBus.Driver"),
+		Connection: variable.ConfigYml.GetString("EventBus.Connection"),
+		MaxLen:     int64(variable.ConfigYml.GetInt("EventBus.MaxLen")),
+	}
+
+	// api 和 ws 是不同的进程，默认使用 redis 才能共享事件
+	if config.Driver == "" {
+		config.Driver = "redis"
+	}
+
+	if config.Connection == "" {
+		config.Connection = "Local"
+	}
+
+	if config.MaxLen <= 0 {
+		config.MaxLen = 1000
+	}
+
+	return config
+}
@@ -0,0 +1,65 @@
+package event_bus
+
+import (
+	"context"
+	"sync"
+	"tool/global/variable"
+	"tool/pkg/redis"
+
+	"go.uber.org/zap"
+)
+
+// Event 总线上的单条事件
+type Event struct {
+	ID    string // 事件ID，在同一主题内单调递增，可用于断点续传
+	Topic string // 主题
+	Data  []byte // 事件内容
+}
+
+// Bus 事件总线接口
+type Bus interface {
+	// Publish 发布事件到指定主题，返回事件ID
+	Publish(ctx context.Context, topic string, data []byte) (string, error)
+
+	// Subscribe 订阅多个主题
+	// offsets 的键为主题、值为最后收到的事件ID，为空字符串时只接收订阅之后的新事件
+	// ctx 取消后返回的通道会被关闭
+	Subscribe(ctx context.Context, offsets map[string]string) (<-chan Event, error)
+}
+
+var (
+	defaultBus  Bus
+	defaultOnce sync.Once
+)
+
+// Default 获取按配置创建的全局事件总线
+//
+// EventBus:
+//
+//	Driver: "redis"      # redis（默认）: 基于 Redis Stream，可跨进程（api / ws）共享，memory: 仅进程内
+//	Connection: "Local"  # redis.yml 中的连接名
+//	MaxLen: 1000         # 每个主题保留的历史事件条数，用于断点续传
+func Default() Bus {
+	defaultOnce.Do(func() {
+		config := loadConfig()
+
+		switch config.Driver {
+		case "redis":
+			defaultBus = NewRedisBus(redis.NewClient(config.Connection), config.MaxLen)
+		default:
+			// ws 发布的事件不会到达 api 的 SSE，只适合单进程或测试
+			variable.Logs.Warn("EventBus uses the memory driver, events are not shared between processes (api / ws)",
+				zap.String("driver", config.Driver))
+			defaultBus = NewMemoryBus(int(config.MaxLen))
+		}
+
+		variable.Logs.Info("EventBus initialized", zap.String("driver", config.Driver))
+	})
+
+	return defaultBus
+}
+
+// Publish 使用全局事件总线发布事件
+func Publish(ctx context.Context, topic string, data []byte) (string, error) {
+	return Default().Publish(ctx, topic, data)
+}
@@ -0,0 +1,134 @@
+package event_bus
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// subscriberBuffer 订阅者通道缓冲大小，消费过慢导致缓冲写满时订阅会被关闭，客户端可通过事件ID续传
+const subscriberBuffer = 64
+
+// memoryBus 进程内事件总线
+type memoryBus struct {
+	mu      sync.Mutex
+	topics  map[string]*memoryTopic
+	history int
+}
+
+// memoryTopic 单个主题的状态
+type memoryTopic struct {
+	seq         uint64
+	events      []Event
+	subscribers map[*memorySubscriber]struct{}
+}
+
+// memorySubscriber 订阅者
+type memorySubscriber struct {
+	ch     chan Event
+	topics []string
+	once   sync.Once
+}
+
+// NewMemoryBus 创建进程内事件总线
+// history: 每个主题保留的历史事件条数
+func NewMemoryBus(history int) Bus {
+	return &memoryBus{
+		topics:  make(map[string]*memoryTopic),
+		history: history,
+	}
+}
+
+// topic 获取主题，不存在时创建，调用方需持有锁
+func (b *memoryBus) topic(name string) *memoryTopic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &memoryTopic{subscribers: make(map[*memorySubscriber]struct{})}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// Publish 发布事件
+func (b *memoryBus) Publish(_ context.Context, topic string, data []byte) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(topic)
+	t.seq++
+
+	evt := Event{ID: strconv.FormatUint(t.seq, 10), Topic: topic, Data: data}
+
+	t.events = append(t.events, evt)
+	if len(t.events) > b.history {
+		t.events = t.events[len(t.events)-b.history:]
+	}
+
+	for sub := range t.subscribers {
+		select {
+		case sub.ch <- evt:
+		default:
+			// 消费过慢，关闭订阅
+			b.remove(sub)
+		}
+	}
+
+	return evt.ID, nil
+}
+
+// Subscribe 订阅主题
+func (b *memoryBus) Subscribe(ctx context.Context, offsets map[string]string) (<-chan Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 收集需要补发的历史事件
+	var backlog []Event
+	for name, offset := range offsets {
+		if offset == "" {
+			continue
+		}
+
+		last, err := strconv.ParseUint(offset, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		for _, evt := range b.topic(name).events {
+			if seq, _ := strconv.ParseUint(evt.ID, 10, 64); seq > last {
+				backlog = append(backlog, evt)
+			}
+		}
+	}
+
+	sub := &memorySubscriber{ch: make(chan Event, len(backlog)+subscriberBuffer)}
+	for _, evt := range backlog {
+		sub.ch <- evt
+	}
+
+	for name := range offsets {
+		b.topic(name).subscribers[sub] = struct{}{}
+		sub.topics = append(sub.topics, name)
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(sub)
+	}()
+
+	return sub.ch, nil
+}
+
+// remove 移除订阅者并关闭通道，调用方需持有锁
+func (b *memoryBus) remove(sub *memorySubscriber) {
+	sub.once.Do(func() {
+		for _, name := range sub.topics {
+			if t, ok := b.topics[name]; ok {
+				delete(t.subscribers, sub)
+			}
+		}
+		close(sub.ch)
+	})
+}
@@ -0,0 +1,151 @@
+package event_bus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"tool/global/variable"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Redis Stream 键前缀
+// 集群模式下 XREAD 的多个 Stream 必须在同一个槽，使用 hash tag 把全部主题放到同一个槽
+const (
+	streamPrefix        = "event_bus:"
+	clusterStreamPrefix = "{event_bus}:"
+)
+
+// redisBus 基于 Redis Stream 的事件总线，多个进程共享同一份事件流
+type redisBus struct {
+	client redis.UniversalClient
+	prefix string
+	maxLen int64
+	block  time.Duration
+}
+
+// NewRedisBus 创建 Redis 事件总线，支持单节点、哨兵和集群客户端
+// maxLen: 每个主题（Stream）保留的近似最大长度
+func NewRedisBus(client redis.UniversalClient, maxLen int64) Bus {
+	prefix := streamPrefix
+	if _, ok := client.(*redis.ClusterClient); ok {
+		prefix = clusterStreamPrefix
+	}
+
+	return &redisBus{
+		client: client,
+		prefix: prefix,
+		maxLen: maxLen,
+		block:  5 * time.Second,
+	}
+}
+
+// Publish 发布事件
+func (b *redisBus) Publish(ctx context.Context, topic string, data []byte) (string, error) {
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.prefix + topic,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": data},
+	}).Result()
+}
+
+// Subscribe 订阅主题
+func (b *redisBus) Subscribe(ctx context.Context, offsets map[string]string) (<-chan Event, error) {
+	topics := make([]string, 0, len(offsets))
+	cursor := make(map[string]string, len(offsets))
+
+	for topic, offset := range offsets {
+		// 未指定位置时从当前最新事件之后开始，避免使用 "$" 在两次读取之间丢失事件
+		if offset == "" {
+			latest, err := b.latestID(ctx, topic)
+			if err != nil {
+				return nil, err
+			}
+			offset = latest
+		}
+
+		topics = append(topics, topic)
+		cursor[topic] = offset
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+
+	go func() {
+		defer close(ch)
+
+		for ctx.Err() == nil {
+			streams := make([]string, 0, len(topics)*2)
+			for _, topic := range topics {
+				streams = append(streams, b.prefix+topic)
+			}
+			for _, topic := range topics {
+				streams = append(streams, cursor[topic])
+			}
+
+			result, err := b.client.XRead(ctx, &redis.XReadArgs{
+				Streams: streams,
+				Count:   100,
+				Block:   b.block,
+			}).Result()
+
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				variable.Logs.Error("EventBus read error", zap.Strings("topics", topics), zap.Error(err))
+				time.Sleep(time.Second)
+				continue
+			}
+
+			for _, stream := range result {
+				topic := strings.TrimPrefix(stream.Stream, b.prefix)
+
+				for _, message := range stream.Messages {
+					cursor[topic] = message.ID
+
+					select {
+					case ch <- Event{ID: message.ID, Topic: topic, Data: messageData(message)}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// latestID 获取主题最后一条事件的ID，主题为空时返回 "0-0"
+func (b *redisBus) latestID(ctx context.Context, topic string) (string, error) {
+	messages, err := b.client.XRevRangeN(ctx, b.prefix+topic, "+", "-", 1).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+
+	if len(messages) == 0 {
+		return "0-0", nil
+	}
+
+	return messages[0].ID, nil
+}
+
+// messageData 取出消息内容
+func messageData(message redis.XMessage) []byte {
+	switch data := message.Values["data"].(type) {
+	case string:
+		return []byte(data)
+	case []byte:
+		return data
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,345 @@
+// Package imaging 图片处理：按 EXIF 方向校正、缩放裁剪、格式转换，重新编码时去除 EXIF 等元数据
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/nfnt/resize"
+	_ "golang.org/x/image/webp" // 支持读取 WebP
+)
+
+// 缩放模式
+const (
+	ModeFit  = "fit"  // 等比缩小到框内，不放大
+	ModeFill = "fill" // 等比缩放到覆盖整个框，再居中裁剪为框的大小
+	ModeCrop = "crop" // 不缩放，居中裁剪为框的大小
+)
+
+// 输出格式，为空时保持原格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// 默认值
+const (
+	DefaultQuality = 85
+	MaxPixels      = 50_000_000 // 解码前检查，避免超大尺寸的图片占满内存；GIF 按帧数累计
+)
+
+var (
+	ErrTooManyPixels = errors.New("imaging: image too large")
+	ErrDecode        = errors.New("imaging: decode failed")
+)
+
+// Options 处理参数
+type Options struct {
+	Width   int    // 框的宽度，0 表示不限制
+	Height  int    // 框的高度，0 表示不限制
+	Mode    string // fit（默认）/ fill / crop
+	Format  string // jpeg / png / gif / webp，为空时保持原格式
+	Quality int    // JPEG 质量，1-100，默认 85；WebP 为无损编码，不使用
+}
+
+// Result 处理结果
+type Result struct {
+	Data        []byte
+	Format      string
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// Process 处理图片：校正方向、缩放裁剪、按目标格式编码
+// GIF 输出为 GIF 时逐帧处理，保留动画
+func Process(data []byte, opts Options) (Result, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return Result{}, ErrTooManyPixels
+	}
+
+	target := opts.Format
+	if target == "" {
+		target = format
+	}
+
+	var buf bytes.Buffer
+	var bounds image.Rectangle
+
+	if format == FormatGIF && target == FormatGIF {
+		// DecodeAll 会解码全部帧，按帧数累计像素
+		frames, err := gifFrames(data)
+		if err != nil {
+			return Result{}, fmt.Errorf("%w: %v", ErrDecode, err)
+		}
+		if int64(config.Width)*int64(config.Height)*int64(frames) > MaxPixels {
+			return Result{}, ErrTooManyPixels
+		}
+
+		bounds, err = processGIF(&buf, data, opts)
+		if err != nil {
+			return Result{}, err
+		}
+	} else {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Result{}, fmt.Errorf("%w: %v", ErrDecode, err)
+		}
+		if format == FormatJPEG {
+			img = Orient(img, Orientation(data))
+		}
+
+		img = Transform(img, opts)
+		bounds = img.Bounds()
+
+		if err := Encode(&buf, img, target, opts.Quality); err != nil {
+			return Result{}, err
+		}
+	}
+
+	return Result{
+		Data:        buf.Bytes(),
+		Format:      target,
+		ContentType: "image/" + target,
+		Ext:         Ext(target),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}, nil
+}
+
+// Transform 按模式缩放和裁剪
+func Transform(img image.Image, opts Options) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 || (opts.Width <= 0 && opts.Height <= 0) {
+		return img
+	}
+
+	boxW, boxH := opts.Width, opts.Height
+
+	switch opts.Mode {
+	case ModeFill:
+		// 只给出一边时按 fit 处理
+		if boxW <= 0 || boxH <= 0 {
+			break
+		}
+		scale := max(float64(boxW)/float64(w), float64(boxH)/float64(h))
+		scaledW, scaledH := max(int(float64(w)*scale+0.5), boxW), max(int(float64(h)*scale+0.5), boxH)
+		return cropCenter(resize.Resize(uint(scaledW), uint(scaledH), img, resize.Lanczos3), boxW, boxH)
+	case ModeCrop:
+		if boxW <= 0 {
+			boxW = w
+		}
+		if boxH <= 0 {
+			boxH = h
+		}
+		return cropCenter(img, min(boxW, w), min(boxH, h))
+	}
+
+	// fit：只缩小不放大
+	scale := 1.0
+	if boxW > 0 {
+		scale = min(scale, float64(boxW)/float64(w))
+	}
+	if boxH > 0 {
+		scale = min(scale, float64(boxH)/float64(h))
+	}
+	if scale >= 1 {
+		return img
+	}
+	return resize.Resize(uint(max(int(float64(w)*scale+0.5), 1)), uint(max(int(float64(h)*scale+0.5), 1)), img, resize.Lanczos3)
+}
+
+// cropCenter 居中裁剪
+func cropCenter(img image.Image, w, h int) image.Image {
+	bounds := img.Bounds()
+	x0 := bounds.Min.X + (bounds.Dx()-w)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-h)/2
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// Encode 按格式编码，JPEG 不支持透明，透明部分填充为白色
+func Encode(buf *bytes.Buffer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG:
+		if quality <= 0 || quality > 100 {
+			quality = DefaultQuality
+		}
+		return jpeg.Encode(buf, flatten(img), &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(buf, img)
+	case FormatGIF:
+		return gif.Encode(buf, img, nil)
+	case FormatWebP:
+		return EncodeWebP(buf, img)
+	}
+	return fmt.Errorf("imaging: unsupported format %q", format)
+}
+
+// flatten 把透明部分合成到白色背景上
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
+
+// processGIF 逐帧合成到画布后缩放，保留动画、帧间隔和循环次数
+func processGIF(buf *bytes.Buffer, data []byte, opts Options) (image.Rectangle, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	out := &gif.GIF{LoopCount: g.LoopCount}
+
+	var bounds image.Rectangle
+	for i, frame := range g.Image {
+		var previous *image.NRGBA
+		if g.Disposal[i] == gif.DisposalPrevious {
+			previous = toNRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		resized := Transform(canvas, opts)
+		if resized == image.Image(canvas) {
+			resized = toNRGBA(canvas)
+		}
+		bounds = resized.Bounds()
+
+		// 使用原帧的调色板，保持颜色一致
+		paletted := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), frame.Palette)
+		draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), resized, bounds.Min)
+
+		out.Image = append(out.Image, paletted)
+		out.Delay = append(out.Delay, g.Delay[i])
+		out.Disposal = append(out.Disposal, gif.DisposalNone)
+
+		switch g.Disposal[i] {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	out.Config = image.Config{Width: bounds.Dx(), Height: bounds.Dy()}
+	return bounds, gif.EncodeAll(buf, out)
+}
+
+// toNRGBA 转换为 NRGBA，左上角移动到原点
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// Ext 格式对应的后缀
+func Ext(format string) string {
+	if format == FormatJPEG {
+		return "jpg"
+	}
+	return format
+}
+
+// ParseFormat 规范化格式名称，不支持时返回错误
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", "original":
+		return "", nil
+	case "jpg", FormatJPEG:
+		return FormatJPEG, nil
+	case FormatPNG, FormatGIF, FormatWebP:
+		return format, nil
+	}
+	return "", fmt.Errorf("imaging: unsupported format %q", format)
+}
+
+// gifFrames 只解析块结构统计 GIF 的帧数，不解码图像数据
+// https://www.w3.org/Graphics/GIF/spec-gif89a.txt
+func gifFrames(data []byte) (int, error) {
+	errFormat := errors.New("gif: invalid format")
+
+	// 文件头 6 字节，逻辑屏幕描述符 7 字节
+	if len(data) < 13 {
+		return 0, errFormat
+	}
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1)
+	}
+
+	// skipSubBlocks 跳过以长度 0 结尾的数据子块
+	skipSubBlocks := func() error {
+		for {
+			if pos >= len(data) {
+				return errFormat
+			}
+			size := int(data[pos])
+			pos++
+			if size == 0 {
+				return nil
+			}
+			pos += size
+		}
+	}
+
+	frames := 0
+	for {
+		if pos >= len(data) {
+			// 缺少结束符时按已读取的帧数计算，与解码器一致
+			return frames, nil
+		}
+
+		switch data[pos] {
+		case 0x21: // 扩展块：标签 + 数据子块
+			pos += 2
+			if err := skipSubBlocks(); err != nil {
+				return 0, err
+			}
+		case 0x2C: // 图像描述符 10 字节，可选局部颜色表，LZW 最小码长 1 字节，数据子块
+			if pos+10 > len(data) {
+				return 0, errFormat
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			pos++
+			if err := skipSubBlocks(); err != nil {
+				return 0, err
+			}
+			frames++
+		case 0x3B: // 结束符
+			return frames, nil
+		default:
+			return 0, errFormat
+		}
+	}
+}
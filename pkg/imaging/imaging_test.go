@@ -0,0 +1,121 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+)
+
+// encodeGIF 生成指定帧数的 GIF，奇数帧使用局部颜色表
+func encodeGIF(t *testing.T, frames int) []byte {
+	t.Helper()
+
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette.Plan9)
+		frame.SetColorIndex(i%4, i%4, uint8(i))
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	if frames > 1 {
+		g.Config = image.Config{ColorModel: color.Palette(palette.Plan9[:16]), Width: 4, Height: 4}
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGifFrames(t *testing.T) {
+	for _, frames := range []int{1, 2, 7} {
+		data := encodeGIF(t, frames)
+
+		got, err := gifFrames(data)
+		if err != nil {
+			t.Fatalf("%d frames: %v", frames, err)
+		}
+		if got != frames {
+			t.Fatalf("gifFrames = %d, want %d", got, frames)
+		}
+
+		// 与标准库解码的帧数一致
+		decoded, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(decoded.Image) != got {
+			t.Fatalf("DecodeAll = %d frames, %v", len(decoded.Image), err)
+		}
+	}
+}
+
+func TestGifFramesInvalid(t *testing.T) {
+	data := encodeGIF(t, 3)
+
+	tests := map[string][]byte{
+		"empty":     nil,
+		"header":    data[:10],
+		"truncated": data[:len(data)/2],
+		"garbage":   []byte("GIF89a\x04\x00\x04\x00\x00\x00\x00\x99"),
+	}
+	for name, input := range tests {
+		if _, err := gifFrames(input); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// 缺少结束符时按已读取的帧数计算
+	if got, err := gifFrames(data[:len(data)-1]); err != nil || got != 3 {
+		t.Fatalf("without trailer = %d, %v", got, err)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name   string
+		w, h   int
+		opts   Options
+		bounds image.Point
+	}{
+		{"no box", 400, 200, Options{}, image.Pt(400, 200)},
+		{"fit width", 400, 200, Options{Width: 100}, image.Pt(100, 50)},
+		{"fit height", 400, 200, Options{Height: 100}, image.Pt(200, 100)},
+		{"fit box", 400, 200, Options{Width: 100, Height: 100}, image.Pt(100, 50)},
+		{"fit no upscale", 400, 200, Options{Width: 800}, image.Pt(400, 200)},
+		{"fill", 400, 200, Options{Width: 100, Height: 100, Mode: ModeFill}, image.Pt(100, 100)},
+		{"fill upscale", 40, 20, Options{Width: 100, Height: 100, Mode: ModeFill}, image.Pt(100, 100)},
+		{"fill one side", 400, 200, Options{Width: 100, Mode: ModeFill}, image.Pt(100, 50)},
+		{"crop", 400, 200, Options{Width: 100, Height: 100, Mode: ModeCrop}, image.Pt(100, 100)},
+		{"crop larger", 400, 200, Options{Width: 500, Height: 100, Mode: ModeCrop}, image.Pt(400, 100)},
+		{"crop one side", 400, 200, Options{Height: 50, Mode: ModeCrop}, image.Pt(400, 50)},
+		{"tiny", 1000, 1, Options{Width: 10}, image.Pt(10, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := Transform(image.NewNRGBA(image.Rect(0, 0, tt.w, tt.h)), tt.opts)
+			if got := img.Bounds().Size(); got != tt.bounds {
+				t.Fatalf("size = %v, want %v", got, tt.bounds)
+			}
+		})
+	}
+}
+
+func TestCropCenter(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	src.SetNRGBA(1, 1, color.NRGBA{0xff, 0, 0, 0xff})
+
+	dst := Transform(src, Options{Width: 1, Height: 1, Mode: ModeCrop}).(*image.NRGBA)
+	if got := dst.NRGBAAt(0, 0); got != (color.NRGBA{0xff, 0, 0, 0xff}) {
+		t.Fatalf("center pixel = %v", got)
+	}
+}
+
+func TestProcessErrors(t *testing.T) {
+	if _, err := Process([]byte("not an image"), Options{}); !errors.Is(err, ErrDecode) {
+		t.Fatalf("Process = %v, want ErrDecode", err)
+	}
+}
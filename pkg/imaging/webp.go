@@ -0,0 +1,452 @@
+package imaging
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+)
+
+// WebP 无损（VP8L）编码，只使用减绿和预测变换，不做反向引用
+// 编码比 libwebp 慢且体积更大，但不依赖 cgo，解码器全部兼容
+// https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+
+const (
+	webpMaxSize       = 1 << 14
+	predictorBits     = 4 // 预测模式分块为 16x16
+	greenAlphabetSize = 256 + 24
+	distAlphabetSize  = 40
+	maxCodeLength     = 15
+	maxCodeLengthCode = 7
+)
+
+// codeLengthOrder 码长编码的码长写入顺序
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP 以无损 WebP 格式编码
+func EncodeWebP(w io.Writer, img image.Image) error {
+	src := toNRGBA(img)
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if width < 1 || height < 1 || width > webpMaxSize || height > webpMaxSize {
+		return errors.New("imaging: webp image size out of range")
+	}
+
+	// 转换为 ARGB，同时判断是否有透明像素
+	argb := make([]uint32, width*height)
+	alpha := false
+	for y := 0; y < height; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x := 0; x < width; x++ {
+			p := row[x*4 : x*4+4]
+			if p[3] != 0xff {
+				alpha = true
+			}
+			argb[y*width+x] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if alpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3)
+
+	// 减绿变换：红、蓝减去绿
+	subtractGreen(argb)
+	bw.write(1, 1)
+	bw.write(2, 2)
+
+	// 预测变换：每个分块选择残差最小的模式
+	modes, residuals := predict(argb, width, height)
+	bw.write(1, 1)
+	bw.write(0, 2)
+	bw.write(predictorBits-2, 3)
+	writeImage(bw, modes, false)
+
+	bw.write(0, 1)
+	writeImage(bw, residuals, true)
+
+	data := bw.bytes()
+	padding := len(data) & 1
+
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(12+len(data)+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(data)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// subtractGreen 红、蓝通道减去绿通道
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		g := (p >> 8) & 0xff
+		r := ((p >> 16) - g) & 0xff
+		b := (p - g) & 0xff
+		argb[i] = p&0xff00ff00 | r<<16 | b
+	}
+}
+
+// predict 计算预测残差，返回每个分块的模式（绿通道）和残差
+// 第一个像素预测为不透明黑色，第一行使用左侧像素，第一列使用上方像素
+func predict(argb []uint32, width, height int) ([]uint32, []uint32) {
+	blockSize := 1 << predictorBits
+	blocksX := (width + blockSize - 1) / blockSize
+	blocksY := (height + blockSize - 1) / blockSize
+
+	// 候选模式：1 左，2 上，7 左上平均
+	candidates := []int{1, 2, 7}
+
+	modes := make([]uint32, blocksX*blocksY)
+	residuals := make([]uint32, len(argb))
+
+	for by := 0; by < blocksY; by++ {
+		for bx := 0; bx < blocksX; bx++ {
+			x0, y0 := bx*blockSize, by*blockSize
+			x1, y1 := min(x0+blockSize, width), min(y0+blockSize, height)
+
+			best, bestCost := candidates[0], -1
+			for _, mode := range candidates {
+				cost := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						cost += residualCost(sub(argb[y*width+x], predictor(argb, width, x, y, mode)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+
+			modes[by*blocksX+bx] = uint32(best) << 8
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					residuals[y*width+x] = sub(argb[y*width+x], predictor(argb, width, x, y, best))
+				}
+			}
+		}
+	}
+	return modes, residuals
+}
+
+// predictor 像素的预测值
+func predictor(argb []uint32, width, x, y, mode int) uint32 {
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[x-1]
+	case x == 0:
+		return argb[(y-1)*width]
+	}
+
+	left, top := argb[y*width+x-1], argb[(y-1)*width+x]
+	switch mode {
+	case 1:
+		return left
+	case 2:
+		return top
+	default:
+		return average2(left, top)
+	}
+}
+
+// average2 按通道取平均
+func average2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+// sub 按通道相减
+func sub(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return (alphaGreen & 0xff00ff00) | (redBlue & 0x00ff00ff)
+}
+
+// residualCost 残差的绝对值之和，用于选择预测模式
+func residualCost(p uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(int8(p >> shift))
+		if v < 0 {
+			v = -v
+		}
+		cost += v
+	}
+	return cost
+}
+
+// writeImage 写入熵编码的图像，只使用一组前缀码，level0 为主图像
+func writeImage(bw *bitWriter, argb []uint32, level0 bool) {
+	// 不使用颜色缓存
+	bw.write(0, 1)
+	if level0 {
+		// 不使用元前缀码
+		bw.write(0, 1)
+	}
+
+	green := make([]int, greenAlphabetSize)
+	red := make([]int, 256)
+	blue := make([]int, 256)
+	alpha := make([]int, 256)
+	for _, p := range argb {
+		green[(p>>8)&0xff]++
+		red[(p>>16)&0xff]++
+		blue[p&0xff]++
+		alpha[p>>24]++
+	}
+
+	greenCode := writeCode(bw, green)
+	redCode := writeCode(bw, red)
+	blueCode := writeCode(bw, blue)
+	alphaCode := writeCode(bw, alpha)
+	writeCode(bw, make([]int, distAlphabetSize))
+
+	for _, p := range argb {
+		greenCode.write(bw, int((p>>8)&0xff))
+		redCode.write(bw, int((p>>16)&0xff))
+		blueCode.write(bw, int(p&0xff))
+		alphaCode.write(bw, int(p>>24))
+	}
+}
+
+// prefixCode 前缀码，codes 为按写入顺序反转后的编码
+type prefixCode struct {
+	lengths []int
+	codes   []uint32
+}
+
+// write 写入符号
+func (c prefixCode) write(bw *bitWriter, symbol int) {
+	if c.lengths[symbol] > 0 {
+		bw.write(c.codes[symbol], uint(c.lengths[symbol]))
+	}
+}
+
+// writeCode 按频率生成并写入前缀码，不超过两个符号且都小于 256 时使用简单码
+func writeCode(bw *bitWriter, freq []int) prefixCode {
+	var symbols []int
+	for symbol, n := range freq {
+		if n > 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	lengths := make([]int, len(freq))
+
+	if len(symbols) <= 2 && (len(symbols) == 0 || symbols[len(symbols)-1] < 256) {
+		if len(symbols) == 0 {
+			symbols = []int{0}
+		}
+
+		bw.write(1, 1)
+		bw.write(uint32(len(symbols)-1), 1)
+		if symbols[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(symbols[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(symbols[0]), 8)
+		}
+		if len(symbols) == 2 {
+			bw.write(uint32(symbols[1]), 8)
+			lengths[symbols[0]], lengths[symbols[1]] = 1, 1
+		}
+		return prefixCode{lengths: lengths, codes: canonicalCodes(lengths)}
+	}
+
+	lengths = huffmanLengths(freq, maxCodeLength)
+
+	// 码长本身再用前缀码编码，码长码至少需要两个符号
+	lengthFreq := make([]int, 19)
+	for _, length := range lengths {
+		lengthFreq[length]++
+	}
+	used := 0
+	for _, n := range lengthFreq {
+		if n > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		if lengthFreq[0] == 0 {
+			lengthFreq[0] = 1
+		} else {
+			lengthFreq[1] = 1
+		}
+	}
+	lengthCode := prefixCode{lengths: huffmanLengths(lengthFreq, maxCodeLengthCode)}
+	lengthCode.codes = canonicalCodes(lengthCode.lengths)
+
+	bw.write(0, 1)
+	bw.write(uint32(len(codeLengthOrder)-4), 4)
+	for _, symbol := range codeLengthOrder {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+
+	// 写入全部符号的码长，不使用重复码
+	bw.write(0, 1)
+	for _, length := range lengths {
+		lengthCode.write(bw, length)
+	}
+
+	return prefixCode{lengths: lengths, codes: canonicalCodes(lengths)}
+}
+
+// canonicalCodes 按码长生成规范哈夫曼编码，并反转为低位先写的顺序
+func canonicalCodes(lengths []int) []uint32 {
+	var count [maxCodeLength + 1]int
+	for _, length := range lengths {
+		if length > 0 {
+			count[length]++
+		}
+	}
+
+	var next [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for bits := 1; bits <= maxCodeLength; bits++ {
+		code = (code + uint32(count[bits-1])) << 1
+		next[bits] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		code := next[length]
+		next[length]++
+
+		var reversed uint32
+		for i := 0; i < length; i++ {
+			reversed = reversed<<1 | (code>>i)&1
+		}
+		codes[symbol] = reversed
+	}
+	return codes
+}
+
+// huffmanLengths 按频率计算哈夫曼码长，超过 maxLength 时减小频率差距后重新计算
+func huffmanLengths(freq []int, maxLength int) []int {
+	weights := append([]int(nil), freq...)
+
+	for {
+		lengths := buildLengths(weights)
+
+		longest := 0
+		for _, length := range lengths {
+			longest = max(longest, length)
+		}
+		if longest <= maxLength {
+			return lengths
+		}
+
+		for i, w := range weights {
+			if w > 0 {
+				weights[i] = (w + 1) / 2
+			}
+		}
+	}
+}
+
+// huffmanNode 哈夫曼树节点
+type huffmanNode struct {
+	weight int
+	symbol int // 叶子节点的符号，内部节点为 -1
+	left   *huffmanNode
+	right  *huffmanNode
+}
+
+// nodeHeap 按权重排序的最小堆
+type nodeHeap []*huffmanNode
+
+func (h nodeHeap) Len() int { return len(h) }
+func (h nodeHeap) Less(i, j int) bool {
+	if h[i].weight != h[j].weight {
+		return h[i].weight < h[j].weight
+	}
+	return h[i].symbol > h[j].symbol
+}
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	node := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return node
+}
+
+// buildLengths 构建哈夫曼树并返回各符号的深度，至少需要两个非零频率
+func buildLengths(freq []int) []int {
+	h := &nodeHeap{}
+	for symbol, w := range freq {
+		if w > 0 {
+			*h = append(*h, &huffmanNode{weight: w, symbol: symbol})
+		}
+	}
+	heap.Init(h)
+
+	for h.Len() > 1 {
+		a := heap.Pop(h).(*huffmanNode)
+		b := heap.Pop(h).(*huffmanNode)
+		heap.Push(h, &huffmanNode{weight: a.weight + b.weight, symbol: -1, left: a, right: b})
+	}
+
+	lengths := make([]int, len(freq))
+	var walk func(node *huffmanNode, depth int)
+	walk = func(node *huffmanNode, depth int) {
+		if node.symbol >= 0 {
+			lengths[node.symbol] = depth
+			return
+		}
+		walk(node.left, depth+1)
+		walk(node.right, depth+1)
+	}
+	if h.Len() == 1 {
+		walk((*h)[0], 0)
+	}
+	return lengths
+}
+
+// bitWriter 低位优先的位写入
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) write(value uint32, bits uint) {
+	w.acc |= uint64(value) << w.nbits
+	w.nbits += bits
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
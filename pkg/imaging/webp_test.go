@@ -0,0 +1,74 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	fills := map[string]func(x, y int) color.NRGBA{
+		"noise": func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), 0xff}
+		},
+		"gradient": func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x), uint8(y), uint8(x + y), 0xff}
+		},
+		"alpha": func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 3), uint8(y * 5), uint8(rnd.Intn(256)), uint8(rnd.Intn(256))}
+		},
+		"solid": func(x, y int) color.NRGBA {
+			return color.NRGBA{0x12, 0x34, 0x56, 0xff}
+		},
+	}
+	sizes := []image.Point{{1, 1}, {2, 3}, {16, 16}, {17, 1}, {1, 33}, {257, 129}}
+
+	for name, fill := range fills {
+		for _, size := range sizes {
+			src := image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					src.SetNRGBA(x, y, fill(x, y))
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, src); err != nil {
+				t.Fatalf("%s %v: encode: %v", name, size, err)
+			}
+
+			decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("%s %v: decode: %v", name, size, err)
+			}
+			if decoded.Bounds() != src.Bounds() {
+				t.Fatalf("%s %v: bounds = %v", name, size, decoded.Bounds())
+			}
+
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					want := src.NRGBAAt(x, y)
+					if got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA); got != want {
+						t.Fatalf("%s %v: pixel (%d, %d) = %v, want %v", name, size, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeWebPSize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, image.NewNRGBA(image.Rect(0, 0, 0, 0))); err == nil {
+		t.Fatal("empty image should fail")
+	}
+	if err := EncodeWebP(&buf, image.NewNRGBA(image.Rect(0, 0, webpMaxSize+1, 1))); err == nil {
+		t.Fatal("oversize image should fail")
+	}
+}
@@ -0,0 +1,45 @@
+package lock
+
+import (
+	"strings"
+	"time"
+	"tool/global/variable"
+)
+
+// LockConfig 分布式锁配置
+type LockConfig struct {
+	Connections []string      // redis.yml 中的连接名，多个相互独立的节点时按 Redlock 算法过半数加锁
+	Prefix      string        // key 前缀
+	TTL         time.Duration // 锁的租期
+}
+
+// 加载配置
+//
+// Lock:
+//
+//	Connections: "Local"   # redis.yml 中的连接名，多个用逗号分隔
+//	Prefix: "lock:"        # key 前缀
+//	TTL: 30                # 锁的租期(秒)，持有期间由看门狗自动续期
+func loadConfig() LockConfig {
+
+	config := LockConfig{
+		Prefix: variable.ConfigYml.GetString("Lock.Prefix"),
+		TTL:    variable.ConfigYml.GetDuration("Lock.TTL") * time.Second,
+	}
+
+	for _, conn := range strings.Split(variable.ConfigYml.GetString("Lock.Connections"), ",") {
+		if conn = strings.TrimSpace(conn); conn != "" {
+			config.Connections = append(config.Connections, conn)
+		}
+	}
+
+	if len(config.Connections) == 0 {
+		config.Connections = []string{"Local"}
+	}
+
+	if config.TTL <= 0 {
+		config.TTL = 30 * time.Second
+	}
+
+	return config
+}
@@ -0,0 +1,95 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+	"tool/global/variable"
+
+	"go.uber.org/zap"
+)
+
+// LeaderCallbacks 领导权变化的回调
+type LeaderCallbacks struct {
+	OnStartedLeading func(ctx context.Context) // 成为领导者，ctx 在失去领导权时取消，回调应在 ctx 取消后尽快返回
+	OnStoppedLeading func()                    // 失去领导权，OnStartedLeading 返回后调用
+}
+
+// LeaderElector 基于分布式锁的领导者选举，同一个 key 同时只有一个进程成为领导者
+//
+//	elector := lock.NewLeaderElector("job:cleanup", lock.LeaderCallbacks{
+//		OnStartedLeading: func(ctx context.Context) {
+//			runCleanup(ctx)
+//		},
+//	})
+//	go elector.Run(ctx)
+type LeaderElector struct {
+	mutex     *Mutex
+	callbacks LeaderCallbacks
+	interval  time.Duration
+	leader    atomic.Bool
+}
+
+// NewLeaderElector 创建领导者选举，未成为领导者时每隔 retryDelay（默认 5 秒）尝试一次
+func NewLeaderElector(key string, callbacks LeaderCallbacks, opts ...Option) *LeaderElector {
+	opts = append([]Option{WithRetryDelay(5 * time.Second)}, opts...)
+	opts = append(opts, WithWatchdog(true))
+
+	mutex := New(key, opts...)
+
+	return &LeaderElector{
+		mutex:     mutex,
+		callbacks: callbacks,
+		interval:  mutex.opts.retryDelay,
+	}
+}
+
+// IsLeader 当前进程是否是领导者
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run 参与选举直到 ctx 取消，取消时如果是领导者会主动释放
+func (e *LeaderElector) Run(ctx context.Context) {
+	for {
+		lease, err := e.mutex.TryLock(ctx)
+		if err == nil {
+			e.lead(lease)
+		} else if !errors.Is(err, ErrNotObtained) && ctx.Err() == nil {
+			variable.Logs.Warn("领导者选举失败", zap.String("key", e.mutex.Key()), zap.Error(err))
+		}
+
+		timer := time.NewTimer(e.interval + jitter(e.interval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// lead 持有领导权直到租约结束
+func (e *LeaderElector) lead(lease *Lease) {
+	e.leader.Store(true)
+	variable.Logs.Info("成为领导者", zap.String("key", e.mutex.Key()))
+
+	if e.callbacks.OnStartedLeading != nil {
+		e.callbacks.OnStartedLeading(lease.Context())
+	}
+
+	// 回调提前返回时继续持有领导权，直到租约结束
+	<-lease.Context().Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_ = lease.Release(ctx)
+	cancel()
+
+	e.leader.Store(false)
+	variable.Logs.Info("失去领导权", zap.String("key", e.mutex.Key()))
+
+	if e.callbacks.OnStoppedLeading != nil {
+		e.callbacks.OnStoppedLeading()
+	}
+}
@@ -0,0 +1,130 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// Lease 一次加锁成功后持有的租约
+type Lease struct {
+	mutex   *Mutex
+	clients []goredis.Cmdable
+	token   string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	until time.Time
+
+	once     sync.Once
+	done     chan struct{} // 看门狗已退出
+	released int           // 看门狗退出时释放成功的节点数
+}
+
+// newLease 创建租约，开启看门狗时在后台自动续期
+func newLease(parent context.Context, m *Mutex, clients []goredis.Cmdable, token string, until time.Time) *Lease {
+	l := &Lease{
+		mutex:   m,
+		clients: clients,
+		token:   token,
+		until:   until,
+		done:    make(chan struct{}),
+	}
+
+	if m.opts.watchdog {
+		// 看门狗负责延长有效期，不使用固定的截止时间
+		l.ctx, l.cancel = context.WithCancel(parent)
+		go l.watchdog()
+	} else {
+		l.ctx, l.cancel = context.WithDeadline(parent, until)
+		close(l.done)
+	}
+
+	return l
+}
+
+// Context 返回随租约结束而取消的 context
+// 加锁时传入的 ctx 取消、续期失败（锁已丢失）或 Release 后都会取消
+func (l *Lease) Context() context.Context {
+	return l.ctx
+}
+
+// Token 返回本次加锁的 token
+func (l *Lease) Token() string {
+	return l.token
+}
+
+// Valid 判断租约是否仍在有效期内
+func (l *Lease) Valid() bool {
+	if l.ctx.Err() != nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().Before(l.until)
+}
+
+// Extend 手动续期一个租期
+func (l *Lease) Extend(ctx context.Context) error {
+	until, err := l.mutex.extend(ctx, l.clients, l.token)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.until = until
+	l.mu.Unlock()
+	return nil
+}
+
+// Release 释放锁，锁已过期或被其他进程持有时返回 ErrNotHeld
+func (l *Lease) Release(ctx context.Context) error {
+	var err error
+
+	l.once.Do(func() {
+		l.cancel()
+		<-l.done
+
+		released := l.released
+		if !l.mutex.opts.watchdog {
+			released = l.mutex.release(ctx, l.clients, l.token)
+		}
+
+		if released < len(l.clients)/2+1 {
+			err = ErrNotHeld
+		}
+	})
+
+	return err
+}
+
+// watchdog 每隔三分之一租期续期一次
+// 续期失败且超过有效期时视为锁已丢失，取消 Context；ctx 取消后释放锁
+func (l *Lease) watchdog() {
+	defer close(l.done)
+
+	interval := l.mutex.opts.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			// 加锁时的 ctx 取消或调用 Release 后释放锁
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			l.released = l.mutex.release(ctx, l.clients, l.token)
+			cancel()
+			return
+		case <-ticker.C:
+		}
+
+		if err := l.Extend(l.ctx); err != nil && !l.Valid() {
+			l.cancel()
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"time"
+	"tool/pkg/redis"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+var (
+	// ErrNotObtained 锁被其他进程持有或未能在过半数节点上加锁
+	ErrNotObtained = errors.New("lock: not obtained")
+	// ErrNotHeld 锁已过期或已被其他进程持有
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+// 仅在 value 与 token 一致时删除，避免释放其他进程的锁
+var releaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// 仅在 value 与 token 一致时续期
+var extendScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// 时钟漂移系数，见 Redlock 算法
+const driftFactor = 0.01
+
+// options 锁参数
+type options struct {
+	conns      []string
+	prefix     string
+	ttl        time.Duration
+	retryDelay time.Duration
+	watchdog   bool
+	clients    []goredis.Cmdable
+}
+
+// Option 锁参数设置
+type Option func(*options)
+
+// WithConns 设置 redis.yml 中的连接名，默认使用 Lock.Connections 配置
+func WithConns(conns ...string) Option {
+	return func(o *options) {
+		o.conns = conns
+	}
+}
+
+// WithClients 直接指定 Redis 客户端，优先于 WithConns
+func WithClients(clients ...goredis.Cmdable) Option {
+	return func(o *options) {
+		o.clients = clients
+	}
+}
+
+// WithTTL 设置锁的租期，默认使用 Lock.TTL 配置（30 秒）
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithRetryDelay 设置 Lock 重试的间隔，默认 100 毫秒，实际间隔会增加随机浮动
+func WithRetryDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.retryDelay = delay
+	}
+}
+
+// WithWatchdog 设置是否自动续期，默认开启
+func WithWatchdog(enabled bool) Option {
+	return func(o *options) {
+		o.watchdog = enabled
+	}
+}
+
+// Mutex 基于 Redis 的分布式锁
+//
+// 配置多个相互独立的 Redis 节点时按 Redlock 算法在过半数节点上加锁；
+// 每次加锁生成随机 token，释放和续期时比较 token，只会操作自己持有的锁
+type Mutex struct {
+	key  string
+	opts options
+}
+
+// New 创建分布式锁，key 会加上 Lock.Prefix 前缀
+//
+//	lease, err := lock.New("wechat:access_token").Lock(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer lease.Release(context.Background())
+//
+//	// 耗时操作使用 lease.Context()，锁丢失时会被取消
+//	refresh(lease.Context())
+func New(key string, opts ...Option) *Mutex {
+	config := loadConfig()
+
+	o := options{
+		conns:      config.Connections,
+		prefix:     config.Prefix,
+		ttl:        config.TTL,
+		retryDelay: 100 * time.Millisecond,
+		watchdog:   true,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Mutex{key: o.prefix + key, opts: o}
+}
+
+// Key 返回带前缀的 key
+func (m *Mutex) Key() string {
+	return m.key
+}
+
+// TryLock 尝试加锁一次，锁被占用时返回 ErrNotObtained
+// 开启看门狗时，ctx 取消或调用 Release 后停止续期
+func (m *Mutex) TryLock(ctx context.Context) (*Lease, error) {
+	clients, err := m.getClients()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	until, err := m.acquire(ctx, clients, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLease(ctx, m, clients, token, until), nil
+}
+
+// Lock 加锁，锁被占用时按间隔重试，直到成功或 ctx 取消
+func (m *Mutex) Lock(ctx context.Context) (*Lease, error) {
+	for {
+		lease, err := m.TryLock(ctx)
+		if !errors.Is(err, ErrNotObtained) {
+			return lease, err
+		}
+
+		timer := time.NewTimer(m.opts.retryDelay + jitter(m.opts.retryDelay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// getClients 获取各节点的客户端，每次从注册表获取，连接失败时下次调用重新创建
+func (m *Mutex) getClients() ([]goredis.Cmdable, error) {
+	if len(m.opts.clients) > 0 {
+		return m.opts.clients, nil
+	}
+
+	clients := make([]goredis.Cmdable, 0, len(m.opts.conns))
+	for _, conn := range m.opts.conns {
+		client, err := redis.Client(conn)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// acquire 在全部节点上加锁，过半数成功且剩余有效期大于 0 时返回有效期截止时间
+func (m *Mutex) acquire(ctx context.Context, clients []goredis.Cmdable, token string) (time.Time, error) {
+	start := time.Now()
+
+	n := m.each(ctx, clients, func(ctx context.Context, client goredis.Cmdable) (bool, error) {
+		return client.SetNX(ctx, m.key, token, m.opts.ttl).Result()
+	})
+
+	if until, ok := m.validUntil(start, n, len(clients)); ok {
+		return until, nil
+	}
+
+	// 未获得锁时释放已加锁的节点
+	m.release(context.Background(), clients, token)
+
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, ErrNotObtained
+}
+
+// extend 在全部节点上续期
+func (m *Mutex) extend(ctx context.Context, clients []goredis.Cmdable, token string) (time.Time, error) {
+	start := time.Now()
+
+	n := m.each(ctx, clients, func(ctx context.Context, client goredis.Cmdable) (bool, error) {
+		result, err := extendScript.Run(ctx, client, []string{m.key}, token, m.opts.ttl.Milliseconds()).Int64()
+		return result == 1, err
+	})
+
+	if until, ok := m.validUntil(start, n, len(clients)); ok {
+		return until, nil
+	}
+	return time.Time{}, ErrNotHeld
+}
+
+// release 在全部节点上释放锁，返回释放成功的节点数
+func (m *Mutex) release(ctx context.Context, clients []goredis.Cmdable, token string) int {
+	return m.each(ctx, clients, func(ctx context.Context, client goredis.Cmdable) (bool, error) {
+		result, err := releaseScript.Run(ctx, client, []string{m.key}, token).Int64()
+		return result == 1, err
+	})
+}
+
+// each 并发在全部节点上执行，返回成功的节点数
+// 单个节点的超时不超过租期的一半，避免一个节点故障拖住整个加锁过程
+func (m *Mutex) each(ctx context.Context, clients []goredis.Cmdable, fn func(ctx context.Context, client goredis.Cmdable) (bool, error)) int {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.ttl/2)
+	defer cancel()
+
+	results := make(chan bool, len(clients))
+	for _, client := range clients {
+		go func(client goredis.Cmdable) {
+			ok, err := fn(ctx, client)
+			results <- ok && err == nil
+		}(client)
+	}
+
+	n := 0
+	for range clients {
+		if <-results {
+			n++
+		}
+	}
+	return n
+}
+
+// validUntil 按 Redlock 算法计算有效期，扣除加锁耗时和时钟漂移
+func (m *Mutex) validUntil(start time.Time, n, total int) (time.Time, bool) {
+	if n < total/2+1 {
+		return time.Time{}, false
+	}
+
+	drift := time.Duration(float64(m.opts.ttl)*driftFactor) + 2*time.Millisecond
+	validity := m.opts.ttl - time.Since(start) - drift
+	if validity <= 0 {
+		return time.Time{}, false
+	}
+
+	return start.Add(m.opts.ttl - drift), true
+}
+
+// randomToken 生成随机 token
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// jitter 返回 0 ~ d/2 的随机时间，避免多个进程同时重试
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return 0
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(d/2)+1))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n.Int64())
+}
@@ -1,101 +1,106 @@
 package memcached
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"sync"
 	"time"
 	"tool/pkg/event_manage"
+	"tool/pkg/registry"
 
 	"github.com/bradfitz/gomemcache/memcache"
 )
 
-var (
-	clients sync.Map // 用于存储多个 Memcached 客户端实例
-)
+// clients 按连接名称管理的 Memcached 客户端
+var clients *registry.Registry[*memcache.Client]
+
+func init() {
+	clients = registry.New(registry.Options[*memcache.Client]{
+		Kind:   "Memcached",
+		Create: createClient,
+		Close: func(name string, client *memcache.Client) error {
+			return client.Close()
+		},
+		Ping: func(ctx context.Context, client *memcache.Client) error {
+			return Ping(client)
+		},
+	})
+}
 
-// NewClient 创建一个新的 Memcached 客户端
+// NewClient 获取 Memcached 客户端，创建失败时 panic
+// 需要处理错误时使用 Client
 func NewClient(name string) *memcache.Client {
-	client, loaded := clients.LoadOrStore(name, createClient(name))
-
-	if loaded {
-		// 检查连接是否有效
-		if !isValidConnection(client.(*memcache.Client)) {
-			log.Printf("Memcached 连接丢失，正在重新连接: %s", name)
-			client = createClient(name)
-			clients.Store(name, client)
-		}
+	client, err := clients.Get(name)
+	if err != nil {
+		panic(err)
 	}
+	return client
+}
 
-	return client.(*memcache.Client)
+// Client 获取 Memcached 客户端，首次调用时创建连接
+func Client(name string) (*memcache.Client, error) {
+	return clients.Get(name)
 }
 
 // createClient 创建一个新的 Memcached 客户端
-func createClient(name string) *memcache.Client {
+func createClient(name string) (*memcache.Client, error) {
 	// 加载配置
-	config := loadConfig(name)
-
-	// 判断是否为空
-	if config.Host == "" {
-		panic(fmt.Sprintf("Failed to get Memcached config: %s", name))
+	config, err := loadConfig(name)
+	if err != nil {
+		return nil, err
 	}
 
 	maxRetries := config.ConnFailRetryTimes                                    // 最大重试次数
 	retryInterval := time.Duration(config.ConnFailRetryInterval) * time.Second // 重试间隔
 
+	// 至少尝试一次
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+
+	// 节点地址在创建时解析
+	selector, err := newRingSelector(config.Servers)
+	if err != nil {
+		return nil, err
+	}
+
 	var client *memcache.Client
-	var err error
 
 	for i := 0; i < maxRetries; i++ {
-		client = memcache.New(config.Host)
+		client = memcache.NewFromSelector(selector)
+		client.Timeout = config.Timeout
+		client.MaxIdleConns = config.MaxIdleConns
+
 		err = Ping(client)
 		if err == nil {
 			break // 连接成功，跳出循环
 		}
-		log.Printf("Failed to connect to Memcached, retrying... (%d/%d)", i+1, maxRetries)
-		time.Sleep(retryInterval)
+		_ = client.Close()
+		if i+1 < maxRetries {
+			log.Printf("Failed to connect to Memcached, retrying... (%d/%d)", i+1, maxRetries)
+			time.Sleep(retryInterval)
+		}
 	}
 
 	if err != nil {
-		panic(fmt.Sprintf("Failed to connect to Memcached after %d attempts: %v", maxRetries, err))
+		return nil, fmt.Errorf("连接 Memcached 失败，已重试 %d 次: %w", maxRetries, err)
 	}
 
-	// 注册销毁事件
+	// 注册销毁事件，重连后仍然关闭当前的连接
 	eventManageFactory := event_manage.CreateEventManageFactory()
 	if _, exists := eventManageFactory.Get(config.EventDestroyPrefix); !exists {
 		eventManageFactory.Set(config.EventDestroyPrefix, func(args ...interface{}) {
 			log.Printf("Destroying Memcached connection for %s", name)
-			client = nil
+			_ = clients.Close(name)
 		})
 	}
 
-	return client
+	return client, nil
 }
 
-// Ping 检测 Memcached 连接是否连通
+// Ping 检测全部节点是否连通，使用 version 命令，不会写入数据
 func Ping(client *memcache.Client) error {
-	key := "ping_key"
-	value := []byte("ping_value")
-
-	// 尝试设置一个键值对
-	err := client.Set(&memcache.Item{Key: key, Value: value, Expiration: 10})
-	if err != nil {
-		return err
-	}
-
-	// 尝试获取刚刚设置的键值对
-	_, err = client.Get(key)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// isValidConnection 检查 Memcached 连接是否有效
-func isValidConnection(client *memcache.Client) bool {
-	err := Ping(client)
-	return err == nil
+	return client.Ping()
 }
 
 // Set 设置一个键值对
@@ -143,12 +148,5 @@ func Delete(clientName, key string) error {
 
 // getClient 获取指定名称的 Memcached 客户端
 func getClient(name string) (*memcache.Client, error) {
-	client, ok := clients.Load(name)
-	if !ok {
-		return nil, ErrClientNotInitialized
-	}
-	return client.(*memcache.Client), nil
+	return clients.Get(name)
 }
-
-// ErrClientNotInitialized is returned when the Memcached client is not initialized
-var ErrClientNotInitialized = fmt.Errorf("Memcached client not initialized")
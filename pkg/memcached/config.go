@@ -2,39 +2,78 @@ package memcached
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 	"tool/global/variable"
 	"tool/pkg/yml_config"
 )
 
 type MemcachedConfig struct {
-	Host                  string // Memcached 服务器地址，格式为 "host:port"。
-	ConnFailRetryTimes    int    // 连接失败重试次数
-	ConnFailRetryInterval int    // 连接失败重试间隔秒数
-	EventDestroyPrefix    string // 事件销毁前缀
+	Servers               []ServerConfig // 节点列表，按一致性哈希分布 key
+	Timeout               time.Duration  // 读写超时，默认 500 毫秒
+	MaxIdleConns          int            // 每个节点的最大空闲连接数，默认 2
+	ConnFailRetryTimes    int            // 连接失败重试次数
+	ConnFailRetryInterval int            // 连接失败重试间隔秒数
+	EventDestroyPrefix    string         // 事件销毁前缀
+}
+
+// ServerConfig 单个节点配置
+type ServerConfig struct {
+	Host   string // Memcached 服务器地址，格式为 "host:port"。
+	Weight int    // 权重，默认 1，权重越大分到的 key 越多
 }
 
 // 加载配置文件
-func loadConfig(conn string) MemcachedConfig {
+func loadConfig(conn string) (MemcachedConfig, error) {
 
 	// 加载配置文件
 	memcachedConfig := yml_config.LoadConfig("memcached")
 
-	// 查找配置文件中的 Memcached 配置
+	// 查找配置文件中的 Memcached 配置，单节点时可以只配置 Host
 	// Local:
 	// 	Host: "127.0.0.1:11213"
+	// 	Servers:
+	// 	  - Host: "10.0.0.1:11211"
+	// 	    Weight: 2
+	// 	  - Host: "10.0.0.2:11211"
+	// 	Timeout: 500             #读写超时毫秒数
+	// 	MaxIdleConns: 10         #每个节点的最大空闲连接数
 	// 	ConnFailRetryTimes: 1    #连接失败重试次数
 	// 	ConnFailRetryInterval: 2 #连接失败重试间隔秒数
 
-	if memcachedConfig.GetString(conn+".Host") == "" {
-		panic(fmt.Sprintf("Failed to get Memcached config: %s", conn))
-	}
-
 	config := MemcachedConfig{
-		Host:                  memcachedConfig.GetString(conn + ".Host"),
+		Timeout:               time.Duration(memcachedConfig.GetInt(conn+".Timeout")) * time.Millisecond,
+		MaxIdleConns:          memcachedConfig.GetInt(conn + ".MaxIdleConns"),
 		ConnFailRetryTimes:    memcachedConfig.GetInt(conn + ".ConnFailRetryTimes"),
 		ConnFailRetryInterval: memcachedConfig.GetInt(conn + ".ConnFailRetryInterval"),
 		EventDestroyPrefix:    variable.EventDestroyPrefix + "Memcached_" + conn,
 	}
 
-	return config
+	for i := 0; ; i++ {
+		prefix := conn + ".Servers." + strconv.Itoa(i)
+
+		host := memcachedConfig.GetString(prefix + ".Host")
+		if host == "" {
+			break
+		}
+
+		weight := memcachedConfig.GetInt(prefix + ".Weight")
+		if weight < 1 {
+			weight = 1
+		}
+
+		config.Servers = append(config.Servers, ServerConfig{Host: host, Weight: weight})
+	}
+
+	if len(config.Servers) == 0 {
+		if host := memcachedConfig.GetString(conn + ".Host"); host != "" {
+			config.Servers = []ServerConfig{{Host: host, Weight: 1}}
+		}
+	}
+
+	if len(config.Servers) == 0 {
+		return MemcachedConfig{}, fmt.Errorf("获取 Memcached 配置失败: %s", conn)
+	}
+
+	return config, nil
 }
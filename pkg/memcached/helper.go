@@ -0,0 +1,105 @@
+package memcached
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// Update 冲突时的最大重试次数
+const maxCASRetries = 10
+
+// GetJSON 获取并按 JSON 解析，不存在时返回 memcache.ErrCacheMiss
+func GetJSON[T any](clientName, key string) (T, error) {
+	var value T
+
+	data, err := Get(clientName, key)
+	if err != nil {
+		return value, err
+	}
+
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// SetJSON 按 JSON 序列化后写入
+func SetJSON(clientName, key string, value interface{}, expiration int32) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return Set(clientName, key, data, expiration)
+}
+
+// GetMulti 批量获取，不存在的 key 不会出现在结果中
+// 多个节点时按节点分组并发请求
+func GetMulti(clientName string, keys ...string) (map[string][]byte, error) {
+	client, err := getClient(clientName)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := client.GetMulti(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]byte, len(items))
+	for key, item := range items {
+		values[key] = item.Value
+	}
+	return values, nil
+}
+
+// Update 使用 CAS 原子更新，fn 收到当前值（不存在时为 nil）并返回新值
+// 期间被其他进程修改时重新读取并再次调用 fn，fn 可能执行多次，不应有副作用
+//
+//	err := memcached.Update("Local", "counter", 3600, func(old []byte) ([]byte, error) {
+//		n, _ := strconv.Atoi(string(old))
+//		return []byte(strconv.Itoa(n + 1)), nil
+//	})
+func Update(clientName, key string, expiration int32, fn func(value []byte) ([]byte, error)) error {
+	client, err := getClient(clientName)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < maxCASRetries; i++ {
+		item, err := client.Get(key)
+
+		switch {
+		case errors.Is(err, memcache.ErrCacheMiss):
+			value, err := fn(nil)
+			if err != nil {
+				return err
+			}
+
+			// 不存在时使用 Add，期间被其他进程写入会返回 ErrNotStored
+			err = client.Add(&memcache.Item{Key: key, Value: value, Expiration: expiration})
+			if errors.Is(err, memcache.ErrNotStored) {
+				continue
+			}
+			return err
+		case err != nil:
+			return err
+		}
+
+		value, err := fn(item.Value)
+		if err != nil {
+			return err
+		}
+
+		item.Value = value
+		item.Expiration = expiration
+
+		// 期间被修改返回 ErrCASConflict，被删除返回 ErrCacheMiss，都重新读取
+		err = client.CompareAndSwap(item)
+		if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrCacheMiss) {
+			continue
+		}
+		return err
+	}
+
+	return memcache.ErrCASConflict
+}
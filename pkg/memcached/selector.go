@@ -0,0 +1,105 @@
+package memcached
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// 每个权重对应的虚拟节点数，与 ketama 一致
+const pointsPerWeight = 160
+
+// ringPoint 哈希环上的虚拟节点
+type ringPoint struct {
+	hash uint32
+	addr net.Addr
+}
+
+// ringSelector 按一致性哈希选择节点，增减节点时只有少量 key 需要迁移
+// 实现 memcache.ServerSelector，创建后只读，可并发使用
+type ringSelector struct {
+	points []ringPoint
+	addrs  []net.Addr
+}
+
+// newRingSelector 按节点和权重创建哈希环
+func newRingSelector(servers []ServerConfig) (*ringSelector, error) {
+	s := &ringSelector{}
+
+	for _, server := range servers {
+		addr, err := resolveAddr(server.Host)
+		if err != nil {
+			return nil, err
+		}
+		s.addrs = append(s.addrs, addr)
+
+		// 每次 md5 得到 4 个虚拟节点
+		for i := 0; i < pointsPerWeight*server.Weight/4; i++ {
+			sum := md5.Sum([]byte(server.Host + "-" + strconv.Itoa(i)))
+			for j := 0; j < 4; j++ {
+				s.points = append(s.points, ringPoint{
+					hash: binary.LittleEndian.Uint32(sum[j*4:]),
+					addr: addr,
+				})
+			}
+		}
+	}
+
+	if len(s.points) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+
+	sort.Slice(s.points, func(i, j int) bool {
+		return s.points[i].hash < s.points[j].hash
+	})
+
+	return s, nil
+}
+
+// PickServer 返回 key 所在的节点
+func (s *ringSelector) PickServer(key string) (net.Addr, error) {
+	sum := md5.Sum([]byte(key))
+	hash := binary.LittleEndian.Uint32(sum[:4])
+
+	i := sort.Search(len(s.points), func(i int) bool {
+		return s.points[i].hash >= hash
+	})
+	if i == len(s.points) {
+		i = 0
+	}
+
+	return s.points[i].addr, nil
+}
+
+// Each 遍历全部节点
+func (s *ringSelector) Each(fn func(net.Addr) error) error {
+	for _, addr := range s.addrs {
+		if err := fn(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveAddr 解析节点地址，包含 "/" 时视为 unix socket
+func resolveAddr(host string) (net.Addr, error) {
+	if strings.Contains(host, "/") {
+		addr, err := net.ResolveUnixAddr("unix", host)
+		if err != nil {
+			return nil, fmt.Errorf("解析 Memcached 地址 %s 失败: %w", host, err)
+		}
+		return addr, nil
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("解析 Memcached 地址 %s 失败: %w", host, err)
+	}
+	return addr, nil
+}
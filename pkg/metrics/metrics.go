@@ -0,0 +1,160 @@
+// Package metrics 进程内的耗时统计，通过 expvar 导出，可在 /debug/vars 或后台 /admin/metrics 查看
+package metrics
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 默认的耗时分桶上限
+var DefaultBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+}
+
+// Timer 按标签聚合的耗时统计，如按 "命令/集合" 统计 MongoDB 命令
+type Timer struct {
+	buckets []time.Duration
+	mu      sync.Mutex
+	series  map[string]*series
+}
+
+// series 单个标签的统计
+type series struct {
+	count  int64
+	errors int64
+	total  time.Duration
+	max    time.Duration
+	counts []int64 // 各分桶的次数，最后一个为超出全部分桶的次数
+}
+
+// Stat 单个标签的统计结果，耗时单位为毫秒
+type Stat struct {
+	Label   string  `json:"label"`
+	Count   int64   `json:"count"`
+	Errors  int64   `json:"errors"`
+	TotalMs float64 `json:"total_ms"`
+	AvgMs   float64 `json:"avg_ms"`
+	MaxMs   float64 `json:"max_ms"`
+	P50Ms   float64 `json:"p50_ms"`
+	P95Ms   float64 `json:"p95_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+}
+
+var (
+	timersMu sync.Mutex
+	timers   = make(map[string]*Timer)
+)
+
+// NewTimer 获取名称对应的统计，不存在时创建并通过 expvar 导出，buckets 为空时使用 DefaultBuckets
+// 同名的统计只创建一次，可以在多处调用
+func NewTimer(name string, buckets ...time.Duration) *Timer {
+	timersMu.Lock()
+	defer timersMu.Unlock()
+
+	if t, ok := timers[name]; ok {
+		return t
+	}
+
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	t := &Timer{buckets: buckets, series: make(map[string]*series)}
+	timers[name] = t
+
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return t.Stats()
+	}))
+
+	return t
+}
+
+// Observe 记录一次耗时
+func (t *Timer) Observe(label string, elapsed time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.series[label]
+	if !ok {
+		s = &series{counts: make([]int64, len(t.buckets)+1)}
+		t.series[label] = s
+	}
+
+	s.count++
+	s.total += elapsed
+	if elapsed > s.max {
+		s.max = elapsed
+	}
+	if failed {
+		s.errors++
+	}
+
+	i := sort.Search(len(t.buckets), func(i int) bool { return elapsed <= t.buckets[i] })
+	s.counts[i]++
+}
+
+// Stats 全部标签的统计结果，按总耗时倒序
+func (t *Timer) Stats() []Stat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make([]Stat, 0, len(t.series))
+
+	for label, s := range t.series {
+		stats = append(stats, Stat{
+			Label:   label,
+			Count:   s.count,
+			Errors:  s.errors,
+			TotalMs: ms(s.total),
+			AvgMs:   ms(s.total / time.Duration(s.count)),
+			MaxMs:   ms(s.max),
+			P50Ms:   ms(t.quantile(s, 0.50)),
+			P95Ms:   ms(t.quantile(s, 0.95)),
+			P99Ms:   ms(t.quantile(s, 0.99)),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].TotalMs > stats[j].TotalMs
+	})
+	return stats
+}
+
+// Reset 清空统计
+func (t *Timer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.series = make(map[string]*series)
+}
+
+// quantile 按分桶估算分位数，返回所在分桶的上限，超出全部分桶时返回最大值
+func (t *Timer) quantile(s *series, q float64) time.Duration {
+	rank := int64(q*float64(s.count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for i, n := range s.counts {
+		seen += n
+		if seen < rank {
+			continue
+		}
+		if i < len(t.buckets) && t.buckets[i] < s.max {
+			return t.buckets[i]
+		}
+		return s.max
+	}
+	return s.max
+}
+
+// ms 转换为毫秒
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,93 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// nameInvalid 迁移名称中不允许的字符
+var nameInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create 创建迁移文件，返回创建的文件路径
+// SQL 迁移创建在 <dir>/<conn>/ 下；Go 迁移创建在 <dir>/ 下，属于 migrations 包
+func Create(dir, conn, name string, goMigration bool) ([]string, error) {
+	name = strings.Trim(nameInvalid.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("迁移名称不能为空")
+	}
+
+	version := time.Now().Format("20060102150405")
+	base := version + "_" + name
+
+	if goMigration {
+		file := filepath.Join(dir, base+".go")
+		content := fmt.Sprintf(goTemplate, conn, version, name)
+		return []string{file}, writeNew(file, content)
+	}
+
+	files := []string{
+		filepath.Join(dir, conn, base+".up.sql"),
+		filepath.Join(dir, conn, base+".down.sql"),
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, conn), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	for i, file := range files {
+		direction := "up"
+		if i == 1 {
+			direction = "down"
+		}
+		if err := writeNew(file, fmt.Sprintf("-- %s %s\n\n", base, direction)); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// writeNew 写入新文件，文件已存在时返回错误
+func writeNew(file, content string) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+	return err
+}
+
+// sortStatus 按版本号升序排序
+func sortStatus(list []Status) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+}
+
+const goTemplate = `package migrations
+
+import (
+	"context"
+	"tool/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrate.Register(%[1]q, %[2]q, %[3]q,
+		func(ctx context.Context, db *gorm.DB) error {
+			return nil
+		},
+		func(ctx context.Context, db *gorm.DB) error {
+			return nil
+		},
+	)
+}
+`
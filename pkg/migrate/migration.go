@@ -0,0 +1,140 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Func Go 迁移函数，db 已绑定上下文
+type Func func(ctx context.Context, db *gorm.DB) error
+
+// Migration 单个迁移
+// SQL 迁移来自 <dir>/<conn>/<version>_<name>.up.sql 和 .down.sql；Go 迁移通过 Register 注册
+type Migration struct {
+	Version string // 版本号，时间戳格式 20060102150405
+	Name    string // 名称
+	UpSQL   string // SQL 迁移的 up 语句
+	DownSQL string // SQL 迁移的 down 语句
+	Up      Func   // Go 迁移的 up 函数
+	Down    Func   // Go 迁移的 down 函数
+}
+
+// Checksum 校验和，SQL 迁移按文件内容计算，Go 迁移按版本号和名称计算
+func (m *Migration) Checksum() string {
+	var content string
+	if m.Up != nil {
+		content = "go:" + m.Version + "_" + m.Name
+	} else {
+		content = m.UpSQL + "\n-- down --\n" + m.DownSQL
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Reversible 是否可以回滚，Go 迁移没有 down 函数、SQL 迁移的 down 文件中没有语句时不可回滚
+func (m *Migration) Reversible() bool {
+	if m.Up != nil {
+		return m.Down != nil
+	}
+	return len(splitStatements(m.DownSQL)) > 0
+}
+
+// Kind 迁移类型
+func (m *Migration) Kind() string {
+	if m.Up != nil {
+		return "go"
+	}
+	return "sql"
+}
+
+var (
+	mu       sync.Mutex
+	registry = make(map[string][]*Migration) // 连接名称 => Go 迁移
+)
+
+// Register 注册 Go 迁移，通常在 database/migrations 包的 init 中调用
+//
+//	func init() {
+//		migrate.Register("Local", "20241019120000", "backfill_admin", up, down)
+//	}
+func Register(conn, version, name string, up, down Func) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registry[conn] = append(registry[conn], &Migration{Version: version, Name: name, Up: up, Down: down})
+}
+
+// sqlFile 匹配 SQL 迁移文件名
+var sqlFile = regexp.MustCompile(`^(\d{14})_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// load 加载连接的全部迁移，按版本号升序
+func load(dir, conn string) ([]*Migration, error) {
+	byVersion := make(map[string]*Migration)
+
+	entries, err := os.ReadDir(filepath.Join(dir, conn))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		match := sqlFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, conn, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[match[1]]
+		if !ok {
+			m = &Migration{Version: match[1], Name: match[2]}
+			byVersion[match[1]] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("迁移版本号重复: %s", match[1])
+		}
+
+		if match[3] == "up" {
+			m.UpSQL = string(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	mu.Lock()
+	goMigrations := registry[conn]
+	mu.Unlock()
+
+	for _, m := range goMigrations {
+		if _, ok := byVersion[m.Version]; ok {
+			return nil, fmt.Errorf("迁移版本号重复: %s", m.Version)
+		}
+		byVersion[m.Version] = m
+	}
+
+	list := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil && strings.TrimSpace(m.UpSQL) == "" {
+			return nil, fmt.Errorf("迁移缺少 up 文件: %s_%s", m.Version, m.Name)
+		}
+		list = append(list, m)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list, nil
+}
@@ -0,0 +1,340 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"tool/pkg/mysql"
+
+	"gorm.io/gorm"
+)
+
+// 迁移记录表
+const table = "schema_migrations"
+
+// 获取迁移锁的超时时间(秒)
+const lockTimeout = 30
+
+var (
+	ErrLocked       = errors.New("其他进程正在执行迁移")
+	ErrChecksum     = errors.New("已执行的迁移文件被修改")
+	ErrMissing      = errors.New("已执行的迁移不存在")
+	ErrIrreversible = errors.New("迁移不可回滚")
+)
+
+// Record 迁移记录
+type Record struct {
+	Version   string
+	Name      string
+	Checksum  string
+	Batch     int
+	AppliedAt time.Time
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   string
+	Name      string
+	Kind      string     // sql / go，迁移不存在时为空
+	Applied   bool       // 是否已执行
+	Batch     int        // 执行批次
+	AppliedAt *time.Time // 执行时间
+	Modified  bool       // 执行后文件被修改
+	Missing   bool       // 已执行但迁移不存在
+}
+
+// Migrator 迁移执行器，每个实例对应一个数据库连接
+type Migrator struct {
+	conn       string
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+// New 创建迁移执行器
+// conn: mysql.yml 中的连接名称；dir: SQL 迁移根目录，文件位于 <dir>/<conn>/
+func New(conn, dir string) (*Migrator, error) {
+	migrations, err := load(dir, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{conn: conn, db: mysql.NewClient(conn), migrations: migrations}, nil
+}
+
+// Status 查询全部迁移的状态，按版本号升序
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	ctx = mysql.UsePrimary(ctx)
+
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	records, err := m.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, Kind: migration.Kind()}
+		if record, ok := records[migration.Version]; ok {
+			status.Applied = true
+			status.Batch = record.Batch
+			status.AppliedAt = &record.AppliedAt
+			status.Modified = record.Checksum != migration.Checksum()
+			delete(records, migration.Version)
+		}
+		list = append(list, status)
+	}
+
+	// 已执行但迁移文件已被删除
+	for _, record := range records {
+		appliedAt := record.AppliedAt
+		list = append(list, Status{
+			Version: record.Version, Name: record.Name, Applied: true,
+			Batch: record.Batch, AppliedAt: &appliedAt, Missing: true,
+		})
+	}
+
+	sortStatus(list)
+	return list, nil
+}
+
+// Up 执行未执行的迁移，steps 为 0 时全部执行，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		var err error
+		done, err = m.up(ctx, steps)
+		return err
+	})
+	return done, err
+}
+
+// Down 回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		var err error
+		done, err = m.down(ctx, steps)
+		return err
+	})
+	return done, err
+}
+
+// Redo 回滚最近一个迁移后重新执行
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var done *Migration
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		reverted, err := m.down(ctx, 1)
+		if err != nil || len(reverted) == 0 {
+			return err
+		}
+
+		// 重新执行刚回滚的版本，不能用 up(ctx, 1)，否则会执行最早一个未执行的迁移
+		records, err := m.records(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.apply(ctx, reverted[0], nextBatch(records)); err != nil {
+			return err
+		}
+		done = reverted[0]
+		return nil
+	})
+	return done, err
+}
+
+// up 执行迁移，调用方需持有锁
+func (m *Migrator) up(ctx context.Context, steps int) ([]*Migration, error) {
+	records, err := m.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已执行的迁移被修改时拒绝继续，避免数据库结构与迁移文件不一致
+	var pending []*Migration
+	for _, migration := range m.migrations {
+		record, ok := records[migration.Version]
+		if !ok {
+			pending = append(pending, migration)
+			continue
+		}
+		if record.Checksum != migration.Checksum() {
+			return nil, fmt.Errorf("%w: %s_%s", ErrChecksum, migration.Version, migration.Name)
+		}
+	}
+
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+
+	batch := nextBatch(records)
+
+	var done []*Migration
+	for _, migration := range pending {
+		if err := m.apply(ctx, migration, batch); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// apply 执行单个迁移并写入记录，调用方需持有锁
+func (m *Migrator) apply(ctx context.Context, migration *Migration, batch int) error {
+	if err := m.run(ctx, migration.Up, migration.UpSQL); err != nil {
+		return fmt.Errorf("执行迁移 %s_%s 失败: %w", migration.Version, migration.Name, err)
+	}
+
+	record := Record{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		Checksum:  migration.Checksum(),
+		Batch:     batch,
+		AppliedAt: time.Now(),
+	}
+	return m.db.WithContext(ctx).Table(table).Create(&record).Error
+}
+
+// nextBatch 下一次执行的批次号
+func nextBatch(records map[string]Record) int {
+	batch := 1
+	for _, record := range records {
+		if record.Batch >= batch {
+			batch = record.Batch + 1
+		}
+	}
+	return batch
+}
+
+// down 回滚迁移，调用方需持有锁
+func (m *Migrator) down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps < 1 {
+		steps = 1
+	}
+
+	var records []Record
+	err := m.db.WithContext(ctx).Table(table).Order("version DESC").Limit(steps).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[string]*Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	// 先检查全部要回滚的迁移，避免回滚到一半才发现不可回滚
+	migrations := make([]*Migration, 0, len(records))
+	for _, record := range records {
+		migration, ok := byVersion[record.Version]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s_%s", ErrMissing, record.Version, record.Name)
+		}
+		if !migration.Reversible() {
+			return nil, fmt.Errorf("%w: %s_%s", ErrIrreversible, record.Version, record.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	var done []*Migration
+	for _, migration := range migrations {
+		if err := m.run(ctx, migration.Down, migration.DownSQL); err != nil {
+			return done, fmt.Errorf("回滚迁移 %s_%s 失败: %w", migration.Version, migration.Name, err)
+		}
+
+		if err := m.db.WithContext(ctx).Table(table).Where("version = ?", migration.Version).Delete(&Record{}).Error; err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// run 执行 Go 迁移函数或 SQL 语句
+// MySQL 的 DDL 会隐式提交，因此不包裹事务，迁移中的 DML 如需原子性请在 Go 迁移中自行开启事务
+func (m *Migrator) run(ctx context.Context, fn Func, content string) error {
+	if fn != nil {
+		return fn(ctx, m.db.WithContext(ctx))
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+
+	// 直接使用 database/sql 执行，避免 DDL 走预处理语句
+	for _, statement := range splitStatements(content) {
+		if _, err := sqlDB.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w\n%s", err, statement)
+		}
+	}
+
+	return nil
+}
+
+// records 查询已执行的迁移
+func (m *Migrator) records(ctx context.Context) (map[string]Record, error) {
+	var list []Record
+	if err := m.db.WithContext(ctx).Table(table).Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]Record, len(list))
+	for _, record := range list {
+		records[record.Version] = record
+	}
+	return records, nil
+}
+
+// ensureTable 创建迁移记录表
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec("CREATE TABLE IF NOT EXISTS `" + table + "` (" +
+		"`version` varchar(32) NOT NULL," +
+		"`name` varchar(255) NOT NULL," +
+		"`checksum` char(64) NOT NULL," +
+		"`batch` int NOT NULL," +
+		"`applied_at` datetime NOT NULL," +
+		"PRIMARY KEY (`version`)" +
+		") ENGINE=InnoDB").Error
+}
+
+// withLock 持有数据库级别的咨询锁执行 fn，避免多个部署同时执行迁移
+// GET_LOCK 与会话绑定，因此加锁和解锁需要在同一个连接上执行
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx = mysql.UsePrimary(ctx)
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var acquired sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(CONCAT(?, DATABASE()), ?)", table+":", lockTimeout).Scan(&acquired)
+	if err != nil {
+		return err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return ErrLocked
+	}
+
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(CONCAT(?, DATABASE()))", table+":")
+
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	return fn(ctx)
+}
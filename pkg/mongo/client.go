@@ -7,55 +7,61 @@ import (
 	"sync"
 	"time"
 	"tool/pkg/event_manage"
+	"tool/pkg/registry"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-// 全局 sync.Map 变量
-var (
-	dbs sync.Map
-)
-
-// NewClient 初始化 MongoDB 客户端，并支持多个数据库连接
-func NewClient(configName string) *mongo.Database {
-	// 使用 LoadOrStore 确保在并发环境中只初始化一次数据库连接
-	db, loaded := dbs.LoadOrStore(configName, func() interface{} {
-		return createMongoClient(configName)
+// dbs 按连接名称管理的 MongoDB 数据库
+var dbs *registry.Registry[*mongo.Database]
+
+// timeouts 按连接名称保存的单次操作超时时间，创建连接时从配置读取
+var timeouts sync.Map
+
+func init() {
+	dbs = registry.New(registry.Options[*mongo.Database]{
+		Kind:   "MongoDB",
+		Create: createMongoClient,
+		Close: func(name string, database *mongo.Database) error {
+			CloseMongo(database.Client(), name)
+			return nil
+		},
+		Ping: func(ctx context.Context, database *mongo.Database) error {
+			return database.Client().Ping(ctx, readpref.Primary())
+		},
 	})
+}
 
-	database := db.(*mongo.Database)
-
-	if loaded {
-		// 检查连接是否有效
-		client := database.Client()
-		if !isValidConnection(client) {
-			log.Printf("MongoDB 连接丢失，正在重新连接: %s", configName)
-			database = createMongoClient(configName)
-			dbs.Store(configName, database)
-		}
+// NewClient 获取 MongoDB 数据库，并支持多个数据库连接，创建失败时 panic
+// 需要处理错误时使用 Client
+func NewClient(configName string) *mongo.Database {
+	database, err := dbs.Get(configName)
+	if err != nil {
+		panic(err)
 	}
 	return database
 }
 
-// isValidConnection 检查 MongoDB 连接是否有效
-func isValidConnection(client *mongo.Client) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	err := client.Ping(ctx, readpref.Primary())
-	return err == nil
+// Client 获取 MongoDB 数据库，首次调用时创建连接
+func Client(configName string) (*mongo.Database, error) {
+	return dbs.Get(configName)
 }
 
 // createMongoClient 创建新的 MongoDB 客户端
-func createMongoClient(configName string) *mongo.Database {
+func createMongoClient(configName string) (*mongo.Database, error) {
 
 	// 加载配置
-	dbConfig := loadConfig(configName)
+	dbConfig, err := loadConfig(configName)
+	if err != nil {
+		return nil, err
+	}
 
 	// 判断是否为空
 	if dbConfig.URI == "" {
-		panic(fmt.Sprintf("Failed to get MongoDB config: %s", configName))
+		return nil, fmt.Errorf("获取 MongoDB 配置失败: %s", configName)
 	}
 
 	// 设置客户端连接选项
@@ -63,53 +69,80 @@ func createMongoClient(configName string) *mongo.Database {
 		ApplyURI(dbConfig.URI + dbConfig.Database).
 		SetMaxPoolSize(dbConfig.MaxPoolSize).
 		SetMinPoolSize(dbConfig.MinPoolSize).
-		SetMonitor(NewMonitor())
+		SetMonitor(NewMonitor(configName, dbConfig.Monitor))
 
 	// 连接到 MongoDB
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to connect to MongoDB: %v", err))
+		return nil, fmt.Errorf("连接 MongoDB 失败: %w", err)
 	}
 
 	// 检查连接
 	err = client.Ping(ctx, readpref.Primary())
 	if err != nil {
-		panic(fmt.Sprintf("Failed to ping MongoDB: %v", err))
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("MongoDB 连接检查失败: %w", err)
 	}
 
 	log.Printf("Connected to MongoDB successfully, database: %s", dbConfig.Database)
 
-	// 注册销毁事件
+	timeouts.Store(configName, dbConfig.Timeout)
+
+	database := client.Database(dbConfig.Database)
+
+	// 同步注册的索引和校验规则
+	if dbConfig.AutoIndex {
+		go autoSync(configName, database)
+	}
+
+	// 注册销毁事件，重连后仍然关闭当前的连接
 	eventManageFactory := event_manage.CreateEventManageFactory()
 	eventName := dbConfig.EventDestroyPrefix
 	if _, exists := eventManageFactory.Get(eventName); !exists {
 		eventManageFactory.Set(eventName, func(args ...interface{}) {
-			CloseMongo(client, dbConfig.Database)
+			_ = dbs.Close(configName)
 			log.Printf("Destroying MongoDB connection for %s", dbConfig.Database)
 		})
 	}
 
-	return client.Database(dbConfig.Database)
+	return database, nil
 }
 
 // GetCollection 获取指定数据库的集合
 func GetCollection(dbName string, collection string) *mongo.Collection {
 	db, exists := dbs.Load(dbName)
 	if exists {
-		return db.(*mongo.Database).Collection(collection)
+		return db.Collection(collection)
 	}
 	return nil
 }
 
-// FindOne 查找单个文档
+// FindOne 查找单个文档，filter 为 nil 时匹配全部文档
+// 新代码使用 Repo，可以传入请求的 ctx
 func FindOne(dbName string, collection string, filter interface{}) *mongo.SingleResult {
 	col := GetCollection(dbName, collection)
 	if col == nil {
 		return nil
 	}
-	return col.FindOne(context.TODO(), filter)
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	ctx, cancel := withTimeout(context.Background(), dbName)
+	defer cancel()
+	return col.FindOne(ctx, filter)
+}
+
+// withTimeout 按连接配置的超时时间设置 ctx 截止时间，ctx 已有更早的截止时间时保留
+func withTimeout(ctx context.Context, conn string) (context.Context, context.CancelFunc) {
+	timeout := 10 * time.Second
+	if value, ok := timeouts.Load(conn); ok {
+		timeout = value.(time.Duration)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // CloseMongo 关闭 MongoDB 客户端
@@ -2,21 +2,45 @@ package mongo
 
 import (
 	"fmt"
+	"strings"
+	"time"
 	"tool/global/variable"
 	"tool/pkg/yml_config"
+	"tool/pkg/yml_config/ymlconfig_interf"
 )
 
 // DatabaseConfig 定义数据库配置结构体
 type DatabaseConfig struct {
-	URI                string // 数据库连接 URI 字符串 (e.g. "mongodb://localhost:27017/")
-	Database           string // 数据库名称
-	MaxPoolSize        uint64 // 连接池中的最大连接数
-	MinPoolSize        uint64 // 连接池中的最小连接数
-	EventDestroyPrefix string // 事件销毁前缀
+	URI                string        // 数据库连接 URI 字符串 (e.g. "mongodb://localhost:27017/")
+	Database           string        // 数据库名称
+	MaxPoolSize        uint64        // 连接池中的最大连接数
+	MinPoolSize        uint64        // 连接池中的最小连接数
+	Timeout            time.Duration // 单次操作的超时时间，默认 10 秒
+	AutoIndex          bool          // 创建连接时同步注册的索引和校验规则
+	Monitor            MonitorConfig // 命令监控
+	EventDestroyPrefix string        // 事件销毁前缀
 }
 
+// MonitorConfig 命令监控配置
+type MonitorConfig struct {
+	Log            bool          // 是否记录命令日志
+	SlowThreshold  time.Duration // 慢命令阈值，超过时以 Warn 记录，0 表示不记录慢命令
+	SampleRate     float64       // 其他命令以 Info 记录的采样比例，0 - 1
+	Commands       []string      // 只监控这些命令，为空时监控全部
+	IgnoreCommands []string      // 不监控的命令
+	Redact         []string      // 隐藏值的字段名，不区分大小写，"*" 隐藏全部字段值
+	MaxLength      int           // 日志中命令内容的最大长度
+	Metrics        bool          // 是否按命令和集合统计耗时
+}
+
+// 监控的默认值，未配置 Monitor 时使用
+const (
+	defaultIgnoreCommands = "hello,isMaster,ismaster,ping,buildInfo,saslStart,saslContinue,endSessions,killCursors"
+	defaultRedact         = "password,pwd,token,secret"
+)
+
 // 加载配置文件
-func loadConfig(conn string) DatabaseConfig {
+func loadConfig(conn string) (DatabaseConfig, error) {
 
 	mongoConfig := yml_config.LoadConfig("mongo")
 
@@ -27,9 +51,20 @@ func loadConfig(conn string) DatabaseConfig {
 	// 	Database: "hospital"
 	// 	MaxPoolSize: 10  # 最大连接数
 	// 	MinPoolSize: 1   # 最小空闲连接数
+	// 	Timeout: 10      # 单次操作超时秒数
+	// 	AutoIndex: false # 创建连接时同步索引
+	// 	Monitor:
+	// 	  Log: true
+	// 	  SlowThreshold: "100ms"       # 慢命令阈值，支持 100ms、1s 等格式，纯数字按秒处理，0 不记录
+	// 	  SampleRate: 0                # 普通命令的日志采样比例
+	// 	  Commands: ""                 # 只监控的命令，多个用逗号分隔
+	// 	  IgnoreCommands: "hello,isMaster,ping,saslStart,saslContinue,endSessions"
+	// 	  Redact: "password,token,secret"
+	// 	  MaxLength: 2000
+	// 	  Metrics: true
 
 	if !mongoConfig.GetBool(conn + ".Open") {
-		panic(fmt.Sprintf("Failed to get MongoDB config: %s", conn))
+		return DatabaseConfig{}, fmt.Errorf("获取 MongoDB 配置失败: %s", conn)
 	}
 
 	config := DatabaseConfig{
@@ -37,8 +72,40 @@ func loadConfig(conn string) DatabaseConfig {
 		Database:           mongoConfig.GetString(conn + ".Database"),
 		MaxPoolSize:        uint64(mongoConfig.GetInt(conn + ".MaxPoolSize")),
 		MinPoolSize:        uint64(mongoConfig.GetInt(conn + ".MinPoolSize")),
+		Timeout:            time.Duration(mongoConfig.GetInt(conn+".Timeout")) * time.Second,
+		AutoIndex:          mongoConfig.GetBool(conn + ".AutoIndex"),
+		Monitor:            loadMonitorConfig(mongoConfig, conn),
 		EventDestroyPrefix: variable.EventDestroyPrefix + "Mongo_" + conn,
 	}
 
-	return config
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return config, nil
+}
+
+// loadMonitorConfig 加载命令监控配置，未配置时使用默认值
+func loadMonitorConfig(mongoConfig ymlconfig_interf.YmlConfigInterf, conn string) MonitorConfig {
+	return MonitorConfig{
+		Log:            mongoConfig.GetConfig(conn+".Monitor.Log", true).(bool),
+		SlowThreshold:  yml_config.ParseDuration(conn+".Monitor.SlowThreshold", mongoConfig.GetConfig(conn+".Monitor.SlowThreshold", "100ms").(string)),
+		SampleRate:     mongoConfig.GetFloat64(conn + ".Monitor.SampleRate"),
+		Commands:       splitList(mongoConfig.GetString(conn + ".Monitor.Commands")),
+		IgnoreCommands: splitList(mongoConfig.GetConfig(conn+".Monitor.IgnoreCommands", defaultIgnoreCommands).(string)),
+		Redact:         splitList(mongoConfig.GetConfig(conn+".Monitor.Redact", defaultRedact).(string)),
+		MaxLength:      mongoConfig.GetConfig(conn+".Monitor.MaxLength", 2000).(int),
+		Metrics:        mongoConfig.GetConfig(conn+".Monitor.Metrics", true).(bool),
+	}
+}
+
+// splitList 拆分逗号分隔的配置
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
@@ -0,0 +1,531 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 全文索引字段的前缀
+const textPrefix = "$text:"
+
+// Index 索引定义
+type Index struct {
+	Name   string        // 索引名称，为空时按字段生成，如 username_1_create_time_-1
+	Keys   []string      // 字段，前缀 "-" 表示倒序，"$text:" 表示全文索引，如 {"username", "-create_time"}
+	Unique bool          // 唯一索引
+	Sparse bool          // 稀疏索引，不包含没有该字段的文档
+	TTL    time.Duration // 大于 0 时为 TTL 索引，文档在字段时间之后的 TTL 过期，只能用于单个日期字段
+}
+
+// CollectionSpec 集合的索引和校验规则
+type CollectionSpec struct {
+	Conn             string  // mongo.yml 中的连接名，默认 Local
+	Collection       string  // 集合名称
+	Indexes          []Index // 索引
+	Schema           bson.D  // $jsonSchema 校验规则，为空时不设置，可以使用 JSONSchema 从结构体生成
+	ValidationLevel  string  // strict: 校验全部写入，moderate: 不校验已存在的不合规文档，默认 moderate
+	ValidationAction string  // error: 拒绝写入，warn: 只记录日志，默认 error
+}
+
+// IndexStatus 索引或校验规则的状态
+type IndexStatus struct {
+	Conn       string
+	Collection string
+	Name       string // 索引名称，校验规则为 $jsonSchema
+	State      string // 见 Index* 常量
+	Detail     string
+}
+
+// 索引状态
+const (
+	IndexOK         = "ok"         // 与声明一致
+	IndexMissing    = "missing"    // 已声明但不存在
+	IndexChanged    = "changed"    // 已存在但字段或选项与声明不一致
+	IndexUndeclared = "undeclared" // 存在但没有声明
+	IndexCreated    = "created"    // 本次创建
+	IndexRebuilt    = "rebuilt"    // 本次删除后重新创建
+	IndexDropped    = "dropped"    // 本次删除
+)
+
+var (
+	specsMu sync.Mutex
+	specs   []CollectionSpec
+)
+
+// RegisterCollection 注册集合的索引和校验规则，通常在 init 中调用
+//
+//	mongo.RegisterCollection(mongo.CollectionSpec{
+//		Collection: "t_chatgpt_log",
+//		Indexes:    mongo.IndexesOf[model.ChatgptLog](),
+//		Schema:     mongo.JSONSchema[model.ChatgptLog](),
+//	})
+func RegisterCollection(spec CollectionSpec) {
+	if spec.Conn == "" {
+		spec.Conn = "Local"
+	}
+	if spec.ValidationLevel == "" {
+		spec.ValidationLevel = "moderate"
+	}
+	if spec.ValidationAction == "" {
+		spec.ValidationAction = "error"
+	}
+
+	for i := range spec.Indexes {
+		if spec.Indexes[i].Name == "" {
+			spec.Indexes[i].Name = indexName(spec.Indexes[i].Keys)
+		}
+	}
+
+	specsMu.Lock()
+	defer specsMu.Unlock()
+	specs = append(specs, spec)
+}
+
+// registered 获取连接的注册信息，conns 为空时返回全部
+func registered(conns ...string) []CollectionSpec {
+	specsMu.Lock()
+	defer specsMu.Unlock()
+
+	var list []CollectionSpec
+	for _, spec := range specs {
+		if len(conns) == 0 || contains(conns, spec.Conn) {
+			list = append(list, spec)
+		}
+	}
+	return list
+}
+
+// Status 对比已注册的索引和校验规则与数据库中的差异，不做修改
+func Status(ctx context.Context, conns ...string) ([]IndexStatus, error) {
+	return syncSpecs(ctx, registered(conns...), false, false)
+}
+
+// Sync 创建缺少的索引并设置校验规则
+// drop 为 true 时删除并重建不一致的索引，同时删除未声明的索引；否则只报告差异
+func Sync(ctx context.Context, drop bool, conns ...string) ([]IndexStatus, error) {
+	return syncSpecs(ctx, registered(conns...), true, drop)
+}
+
+// syncSpecs 按集合依次处理
+func syncSpecs(ctx context.Context, list []CollectionSpec, apply, drop bool) ([]IndexStatus, error) {
+	var result []IndexStatus
+
+	for _, spec := range list {
+		db, err := Client(spec.Conn)
+		if err != nil {
+			return result, err
+		}
+
+		statuses, err := syncCollection(ctx, db, spec, apply, drop)
+		result = append(result, statuses...)
+		if err != nil {
+			return result, fmt.Errorf("同步集合 %s.%s 失败: %w", spec.Conn, spec.Collection, err)
+		}
+	}
+
+	return result, nil
+}
+
+// syncCollection 处理单个集合
+func syncCollection(ctx context.Context, db *mongo.Database, spec CollectionSpec, apply, drop bool) ([]IndexStatus, error) {
+	var result []IndexStatus
+	status := func(name, state, detail string) {
+		result = append(result, IndexStatus{Conn: spec.Conn, Collection: spec.Collection, Name: name, State: state, Detail: detail})
+	}
+
+	// 校验规则需要在创建集合时设置，先于索引处理
+	if len(spec.Schema) > 0 {
+		state, err := syncValidator(ctx, db, spec, apply)
+		if err != nil {
+			return result, err
+		}
+		status("$jsonSchema", state, "")
+	}
+
+	coll := db.Collection(spec.Collection)
+
+	existing, err := listIndexes(ctx, coll)
+	if err != nil {
+		return result, err
+	}
+
+	declared := make(map[string]bool, len(spec.Indexes))
+	for _, index := range spec.Indexes {
+		declared[index.Name] = true
+
+		current, ok := existing[index.Name]
+		switch {
+		case ok && current.equal(index):
+			status(index.Name, IndexOK, "")
+		case ok && !(apply && drop):
+			status(index.Name, IndexChanged, fmt.Sprintf("数据库中为 %s，声明为 %s", current.describe(), describeIndex(index)))
+		case !ok && !apply:
+			status(index.Name, IndexMissing, describeIndex(index))
+		default:
+			state := IndexCreated
+			if ok {
+				if _, err := coll.Indexes().DropOne(ctx, index.Name); err != nil {
+					return result, err
+				}
+				state = IndexRebuilt
+			}
+
+			if _, err := coll.Indexes().CreateOne(ctx, indexModel(index)); err != nil {
+				return result, fmt.Errorf("创建索引 %s 失败: %w", index.Name, err)
+			}
+			status(index.Name, state, describeIndex(index))
+		}
+	}
+
+	names := make([]string, 0, len(existing))
+	for name := range existing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "_id_" || declared[name] {
+			continue
+		}
+
+		if apply && drop {
+			if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+				return result, err
+			}
+			status(name, IndexDropped, existing[name].describe())
+			continue
+		}
+		status(name, IndexUndeclared, existing[name].describe())
+	}
+
+	return result, nil
+}
+
+// existingIndex 数据库中的索引
+type existingIndex struct {
+	Name    string `bson:"name"`
+	Key     bson.D `bson:"key"`
+	Unique  bool   `bson:"unique"`
+	Sparse  bool   `bson:"sparse"`
+	TTL     *int64 `bson:"expireAfterSeconds"`
+	Weights bson.D `bson:"weights"`
+}
+
+// listIndexes 获取集合的全部索引，集合不存在时返回空
+func listIndexes(ctx context.Context, coll *mongo.Collection) (map[string]existingIndex, error) {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		// 集合不存在
+		if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == 26 {
+			return map[string]existingIndex{}, nil
+		}
+		return nil, err
+	}
+
+	var list []existingIndex
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]existingIndex, len(list))
+	for _, index := range list {
+		indexes[index.Name] = index
+	}
+	return indexes, nil
+}
+
+// keys 转换为与声明相同的字段格式
+// 全文索引的 key 为 _fts / _ftsx，字段保存在 weights 中
+func (e existingIndex) keys() []string {
+	var keys []string
+	for _, elem := range e.Key {
+		switch elem.Key {
+		case "_fts":
+			for _, weight := range e.Weights {
+				keys = append(keys, textPrefix+weight.Key)
+			}
+		case "_ftsx":
+		default:
+			if toInt(elem.Value) < 0 {
+				keys = append(keys, "-"+elem.Key)
+			} else {
+				keys = append(keys, elem.Key)
+			}
+		}
+	}
+	return keys
+}
+
+// equal 是否与声明一致，全文索引的字段顺序不影响
+func (e existingIndex) equal(index Index) bool {
+	var ttl time.Duration
+	if e.TTL != nil {
+		ttl = time.Duration(*e.TTL) * time.Second
+	}
+
+	return e.Unique == index.Unique &&
+		e.Sparse == index.Sparse &&
+		ttl == index.TTL &&
+		sameKeys(e.keys(), index.Keys)
+}
+
+// describe 索引的描述
+func (e existingIndex) describe() string {
+	index := Index{Keys: e.keys(), Unique: e.Unique, Sparse: e.Sparse}
+	if e.TTL != nil {
+		index.TTL = time.Duration(*e.TTL) * time.Second
+	}
+	return describeIndex(index)
+}
+
+// describeIndex 索引的描述，如 (username, -create_time) unique
+func describeIndex(index Index) string {
+	desc := "(" + strings.Join(index.Keys, ", ") + ")"
+	if index.Unique {
+		desc += " unique"
+	}
+	if index.Sparse {
+		desc += " sparse"
+	}
+	if index.TTL > 0 {
+		desc += " ttl=" + index.TTL.String()
+	}
+	return desc
+}
+
+// indexModel 转换为驱动的索引定义
+func indexModel(index Index) mongo.IndexModel {
+	keys := bson.D{}
+	for _, key := range index.Keys {
+		switch {
+		case strings.HasPrefix(key, textPrefix):
+			keys = append(keys, bson.E{Key: strings.TrimPrefix(key, textPrefix), Value: "text"})
+		case strings.HasPrefix(key, "-"):
+			keys = append(keys, bson.E{Key: key[1:], Value: -1})
+		default:
+			keys = append(keys, bson.E{Key: key, Value: 1})
+		}
+	}
+
+	opts := options.Index().SetName(index.Name)
+	if index.Unique {
+		opts.SetUnique(true)
+	}
+	if index.Sparse {
+		opts.SetSparse(true)
+	}
+	if index.TTL > 0 {
+		opts.SetExpireAfterSeconds(int32(index.TTL / time.Second))
+	}
+
+	return mongo.IndexModel{Keys: keys, Options: opts}
+}
+
+// indexName 按字段生成索引名称，与数据库默认的命名方式一致
+func indexName(keys []string) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		switch {
+		case strings.HasPrefix(key, textPrefix):
+			parts = append(parts, strings.TrimPrefix(key, textPrefix)+"_text")
+		case strings.HasPrefix(key, "-"):
+			parts = append(parts, key[1:]+"_-1")
+		default:
+			parts = append(parts, key+"_1")
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// IndexesOf 按结构体的 index 标签生成索引
+//
+//	index:"<名称>[,unique][,desc][,text][,sparse][,ttl=<时长>]"
+//
+// ttl 按 time.ParseDuration 解析，精确到秒，格式错误时记录日志并忽略
+//
+// 名称相同的字段按字段顺序组成复合索引，一个字段属于多个索引时用 ";" 分隔；
+// 名称为空时为单字段索引，如：
+//
+//	Username   string `bson:"username" index:"user_time;,unique"`
+//	CreateTime int64  `bson:"create_time" index:"user_time,desc"`
+//	CreatedAt  time.Time `bson:"created_at" index:",ttl=720h"`
+func IndexesOf[T any]() []Index {
+	var (
+		indexes []Index
+		named   = make(map[string]int) // 名称 => indexes 下标
+	)
+
+	eachField(reflect.TypeOf((*T)(nil)).Elem(), "", func(name string, field reflect.StructField) {
+		tag, ok := field.Tag.Lookup("index")
+		if !ok {
+			return
+		}
+
+		for _, def := range strings.Split(tag, ";") {
+			parts := strings.Split(def, ",")
+			indexName := strings.TrimSpace(parts[0])
+
+			key := name
+			var unique, sparse bool
+			var ttl time.Duration
+
+			for _, opt := range parts[1:] {
+				opt = strings.TrimSpace(opt)
+				switch {
+				case opt == "unique":
+					unique = true
+				case opt == "sparse":
+					sparse = true
+				case opt == "desc":
+					key = "-" + name
+				case opt == "text":
+					key = textPrefix + name
+				case strings.HasPrefix(opt, "ttl="):
+					var err error
+					if ttl, err = time.ParseDuration(strings.TrimPrefix(opt, "ttl=")); err != nil {
+						log.Printf("Invalid MongoDB index ttl on %s.%s, ttl ignored: %v", reflect.TypeOf((*T)(nil)).Elem(), field.Name, err)
+					}
+				}
+			}
+
+			if i, ok := named[indexName]; ok && indexName != "" {
+				indexes[i].Keys = append(indexes[i].Keys, key)
+				indexes[i].Unique = indexes[i].Unique || unique
+				indexes[i].Sparse = indexes[i].Sparse || sparse
+				continue
+			}
+
+			indexes = append(indexes, Index{Name: indexName, Keys: []string{key}, Unique: unique, Sparse: sparse, TTL: ttl})
+			if indexName != "" {
+				named[indexName] = len(indexes) - 1
+			}
+		}
+	})
+
+	return indexes
+}
+
+// eachField 遍历结构体字段，name 为 bson 字段路径，内嵌（inline）结构体的字段视为本层字段
+func eachField(t reflect.Type, prefix string, fn func(name string, field reflect.StructField)) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts := bsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && indirect(field.Type).Kind() == reflect.Struct && (name == "" || strings.Contains(opts, "inline")) {
+			eachField(field.Type, prefix, fn)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fn(prefix+name, field)
+	}
+}
+
+// bsonName 解析 bson 标签的名称和选项
+func bsonName(field reflect.StructField) (string, string) {
+	name, opts, _ := strings.Cut(field.Tag.Get("bson"), ",")
+	return name, opts
+}
+
+// indirect 去掉指针
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// sameKeys 比较字段，全文索引的字段不区分顺序
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	normalize := func(keys []string) string {
+		var text []string
+		var plain []string
+		for _, key := range keys {
+			if strings.HasPrefix(key, textPrefix) {
+				text = append(text, key)
+			} else {
+				plain = append(plain, key)
+			}
+		}
+		sort.Strings(text)
+		return strings.Join(plain, ",") + "|" + strings.Join(text, ",")
+	}
+
+	return normalize(a) == normalize(b)
+}
+
+// toInt 把索引方向转换为整数，数据库中可能是 int32 / int64 / float64
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	default:
+		return 0
+	}
+}
+
+// contains 切片中是否包含
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// autoSync 创建连接后在后台同步该连接注册的索引和校验规则，不删除索引，只记录差异
+func autoSync(conn string, db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, spec := range registered(conn) {
+		statuses, err := syncCollection(ctx, db, spec, true, false)
+		for _, s := range statuses {
+			if s.State != IndexOK {
+				log.Printf("MongoDB index %s.%s %s: %s %s", s.Conn, s.Collection, s.Name, s.State, s.Detail)
+			}
+		}
+		if err != nil {
+			log.Printf("Failed to sync MongoDB indexes for %s.%s: %v", conn, spec.Collection, err)
+		}
+	}
+}
@@ -3,12 +3,24 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
 	"tool/global/variable"
+	"tool/pkg/metrics"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/event"
 	"go.uber.org/zap"
 )
 
+// 隐藏后的字段值
+const redacted = "***"
+
+// commandTimer 按 "连接/命令/集合" 统计的命令耗时，通过 expvar 导出为 mongo_commands
+var commandTimer = metrics.NewTimer("mongo_commands")
+
 // CustomLogger implements mongo.Logger interface for custom logging.
 type CustomLogger struct{}
 
@@ -16,33 +28,191 @@ func (cl CustomLogger) Log(ctx context.Context, msg string, args ...interface{})
 	variable.Logs.Info(fmt.Sprintf(msg, args...))
 }
 
-// NewMonitor returns a CommandMonitor for logging MongoDB commands.
-func NewMonitor() *event.CommandMonitor {
+// startedCommand 命令开始时记录的信息，结束事件中没有命令内容
+type startedCommand struct {
+	collection string
+	command    string // 隐藏敏感字段后的命令，不需要记录日志时为空
+	sampled    bool
+}
+
+// monitor 命令监控
+type monitor struct {
+	conn    string
+	config  MonitorConfig
+	started sync.Map // 请求ID => *startedCommand
+}
+
+// NewMonitor 按配置创建命令监控
+// 慢命令以 Warn 记录，失败的命令以 Error 记录，其他命令按采样率以 Info 记录，命令内容会隐藏配置的敏感字段
+func NewMonitor(conn string, config MonitorConfig) *event.CommandMonitor {
+	m := &monitor{conn: conn, config: config}
+
 	return &event.CommandMonitor{
-		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
-			variable.Logs.Info("MongoDB Command Started",
-				zap.String("Database", evt.DatabaseName),
-				zap.String("Command", evt.CommandName),
-				zap.String("CommandDetails", evt.Command.String()),
-				zap.Int64("RequestID", evt.RequestID),
-				zap.String("ConnectionID", evt.ConnectionID),
-			)
-		},
-		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
-			variable.Logs.Info("MongoDB Command Succeeded",
-				zap.String("Command", evt.CommandName),
-				zap.Int64("RequestID", evt.RequestID),
-				zap.Duration("Duration", evt.Duration),
-				//zap.String("Reply", evt.Reply.String()),
-			)
-		},
-		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
-			variable.Logs.Error("MongoDB Command Failed",
-				zap.String("Command", evt.CommandName),
-				zap.Int64("RequestID", evt.RequestID),
-				zap.Duration("Duration", evt.Duration),
-				zap.String("Failure", evt.Failure),
-			)
-		},
+		Started:   m.onStarted,
+		Succeeded: m.onSucceeded,
+		Failed:    m.onFailed,
+	}
+}
+
+// key 请求ID在连接内唯一
+func (m *monitor) key(connectionID string, requestID int64) string {
+	return connectionID + "/" + strconv.FormatInt(requestID, 10)
+}
+
+// onStarted 命令开始
+func (m *monitor) onStarted(ctx context.Context, evt *event.CommandStartedEvent) {
+	if !m.config.allowed(evt.CommandName) {
+		return
+	}
+
+	cmd := &startedCommand{
+		collection: collectionOf(evt.Command, evt.CommandName),
+		sampled:    m.config.SampleRate > 0 && rand.Float64() < m.config.SampleRate,
+	}
+
+	// 命令内容在事件结束后不再有效，需要记录日志时先转换为字符串
+	if m.config.Log && (m.config.SlowThreshold > 0 || cmd.sampled) {
+		cmd.command = m.config.redact(evt.Command)
+	}
+
+	m.started.Store(m.key(evt.ConnectionID, evt.RequestID), cmd)
+}
+
+// onSucceeded 命令成功
+func (m *monitor) onSucceeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	cmd, ok := m.finish(evt.CommandFinishedEvent, false)
+	if !ok || !m.config.Log {
+		return
+	}
+
+	switch {
+	case m.config.SlowThreshold > 0 && evt.Duration >= m.config.SlowThreshold:
+		variable.Logs.Warn("MongoDB 慢命令", m.fields(evt.CommandFinishedEvent, cmd)...)
+	case cmd.sampled:
+		variable.Logs.Info("MongoDB 命令", m.fields(evt.CommandFinishedEvent, cmd)...)
+	}
+}
+
+// onFailed 命令失败
+func (m *monitor) onFailed(ctx context.Context, evt *event.CommandFailedEvent) {
+	cmd, ok := m.finish(evt.CommandFinishedEvent, true)
+	if !ok || !m.config.Log {
+		return
+	}
+
+	variable.Logs.Error("MongoDB 命令失败", append(m.fields(evt.CommandFinishedEvent, cmd), zap.String("failure", evt.Failure))...)
+}
+
+// finish 取出开始时的信息并记录耗时
+func (m *monitor) finish(evt event.CommandFinishedEvent, failed bool) (*startedCommand, bool) {
+	value, ok := m.started.LoadAndDelete(m.key(evt.ConnectionID, evt.RequestID))
+	if !ok {
+		return nil, false
+	}
+
+	cmd := value.(*startedCommand)
+	if m.config.Metrics {
+		commandTimer.Observe(m.conn+"/"+evt.CommandName+"/"+cmd.collection, evt.Duration, failed)
+	}
+	return cmd, true
+}
+
+// fields 日志字段
+func (m *monitor) fields(evt event.CommandFinishedEvent, cmd *startedCommand) []zap.Field {
+	return []zap.Field{
+		zap.String("conn", m.conn),
+		zap.String("database", evt.DatabaseName),
+		zap.String("command", evt.CommandName),
+		zap.String("collection", cmd.collection),
+		zap.Duration("duration", evt.Duration),
+		zap.Int64("request_id", evt.RequestID),
+		zap.String("connection_id", evt.ConnectionID),
+		zap.String("details", cmd.command),
+	}
+}
+
+// collectionOf 命令的第一个字段为命令名，值为字符串时是集合名
+func collectionOf(command bson.Raw, name string) string {
+	value, err := command.LookupErr(name)
+	if err != nil {
+		return ""
+	}
+
+	if collection, ok := value.StringValueOK(); ok {
+		return collection
+	}
+	return ""
+}
+
+// allowed 命令是否需要监控
+func (c MonitorConfig) allowed(command string) bool {
+	if len(c.Commands) > 0 && !containsFold(c.Commands, command) {
+		return false
+	}
+	return !containsFold(c.IgnoreCommands, command)
+}
+
+// redact 把命令转换为字符串，隐藏敏感字段并截断过长的内容
+func (c MonitorConfig) redact(command bson.Raw) string {
+	var doc bson.D
+	if err := bson.Unmarshal(command, &doc); err != nil {
+		return ""
+	}
+
+	// lsid、$clusterTime 等驱动附加的字段没有参考价值
+	filtered := doc[:0]
+	for _, e := range doc {
+		if e.Key != "lsid" && e.Key != "$clusterTime" && e.Key != "$db" {
+			filtered = append(filtered, e)
+		}
+	}
+
+	data, err := bson.MarshalExtJSON(c.redactValue(filtered, 0), false, false)
+	if err != nil {
+		return ""
+	}
+
+	text := string(data)
+	if c.MaxLength > 0 && len(text) > c.MaxLength {
+		text = text[:c.MaxLength] + "...(truncated)"
+	}
+	return text
+}
+
+// redactValue 递归隐藏字段，第一个字段为命令名和集合名，不隐藏
+func (c MonitorConfig) redactValue(value interface{}, depth int) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		result := make(bson.D, len(v))
+		for i, e := range v {
+			if (depth > 0 || i > 0) && containsFold(c.Redact, e.Key) {
+				result[i] = bson.E{Key: e.Key, Value: redacted}
+				continue
+			}
+			result[i] = bson.E{Key: e.Key, Value: c.redactValue(e.Value, depth+1)}
+		}
+		return result
+	case bson.A:
+		result := make(bson.A, len(v))
+		for i, item := range v {
+			result[i] = c.redactValue(item, depth+1)
+		}
+		return result
+	default:
+		// "*" 隐藏全部字段值，只保留结构，用于排查查询形状
+		if depth > 1 && containsFold(c.Redact, "*") {
+			return redacted
+		}
+		return value
+	}
+}
+
+// containsFold 不区分大小写的包含
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,215 @@
+package mongo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultPageSize = 20  // 默认每页条数
+	maxPageSize     = 500 // 最大每页条数
+)
+
+// ErrCursor 分页游标格式错误
+var ErrCursor = errors.New("分页游标错误")
+
+// PageRequest 分页请求
+type PageRequest struct {
+	Page int `form:"page" json:"page"` // 页码，从 1 开始
+	Size int `form:"size" json:"size"` // 每页条数
+}
+
+// PageResult 分页结果
+type PageResult[T any] struct {
+	List  []T   `json:"list"`  // 当前页数据
+	Total int64 `json:"total"` // 总条数
+	Page  int   `json:"page"`  // 当前页码
+	Size  int   `json:"size"`  // 每页条数
+	Pages int   `json:"pages"` // 总页数
+}
+
+// CursorRequest 游标分页请求
+type CursorRequest struct {
+	Cursor string `form:"cursor" json:"cursor"` // 上一页返回的 next，为空时从第一页开始
+	Size   int    `form:"size" json:"size"`     // 每页条数
+}
+
+// CursorResult 游标分页结果
+type CursorResult[T any] struct {
+	List    []T    `json:"list"`     // 当前页数据
+	Next    string `json:"next"`     // 下一页的游标，没有更多数据时为空
+	HasMore bool   `json:"has_more"` // 是否还有更多数据
+}
+
+// normalizeSize 规范化每页条数
+func normalizeSize(size int) int {
+	if size < 1 {
+		return defaultPageSize
+	}
+	if size > maxPageSize {
+		return maxPageSize
+	}
+	return size
+}
+
+// Page 按页码分页，需要统计总数，页码较大时 skip 较慢，此时使用 CursorPage
+func (r *Repo[T]) Page(ctx context.Context, req PageRequest) (PageResult[T], error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	req.Size = normalizeSize(req.Size)
+
+	result := PageResult[T]{Page: req.Page, Size: req.Size, List: []T{}}
+
+	total, err := r.Count(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	result.Total = total
+	result.Pages = int(math.Ceil(float64(total) / float64(req.Size)))
+
+	// 超出范围的页码不再查询
+	if total == 0 || req.Page > result.Pages {
+		return result, nil
+	}
+
+	opts := options.Find().SetSkip(int64((req.Page - 1) * req.Size)).SetLimit(int64(req.Size))
+	result.List, err = r.find(ctx, opts)
+	return result, err
+}
+
+// cursorValue 游标中保存的上一页最后一条数据的排序值
+type cursorValue struct {
+	Value bson.RawValue `bson:"v"`
+	ID    bson.RawValue `bson:"id"`
+}
+
+// CursorPage 按游标分页，适合无限滚动等不需要总数的场景
+// 按第一个排序字段（未设置时为 _id）和 _id 定位，排序字段需要有索引
+func (r *Repo[T]) CursorPage(ctx context.Context, req CursorRequest) (CursorResult[T], error) {
+	size := normalizeSize(req.Size)
+	result := CursorResult[T]{List: []T{}}
+
+	field, order := "_id", 1
+	if len(r.sort) > 0 {
+		field, order = r.sort[0].Key, r.sort[0].Value.(int)
+	}
+
+	// 排序字段相同时按 _id 排序，保证顺序稳定
+	sort := bson.D{{Key: field, Value: order}}
+	if field != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: order})
+	}
+
+	query := r.clone()
+	query.sort = sort
+
+	if req.Cursor != "" {
+		after, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return result, err
+		}
+		query = query.Where(afterFilter(field, order, after))
+	}
+
+	coll, ctx, cancel, err := query.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return result, err
+	}
+
+	// 多取一条判断是否还有下一页
+	opts := options.Find().SetSort(sort).SetLimit(int64(size + 1))
+	if len(query.projection) > 0 {
+		opts.SetProjection(query.projection)
+	}
+
+	cursor, err := coll.Find(ctx, query.filter(), opts)
+	if err != nil {
+		return result, err
+	}
+
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
+		return result, err
+	}
+
+	if len(docs) > size {
+		docs = docs[:size]
+		result.HasMore = true
+	}
+
+	for _, raw := range docs {
+		var doc T
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return result, err
+		}
+		result.List = append(result.List, doc)
+	}
+
+	if result.HasMore {
+		last := docs[len(docs)-1]
+
+		// 文档没有排序字段时按 null 处理
+		value, err := last.LookupErr(strings.Split(field, ".")...)
+		if err != nil {
+			value = bson.RawValue{Type: bsontype.Null}
+		}
+
+		result.Next, err = encodeCursor(cursorValue{Value: value, ID: last.Lookup("_id")})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// afterFilter 位于游标之后的数据
+func afterFilter(field string, order int, after cursorValue) bson.D {
+	op := "$gt"
+	if order < 0 {
+		op = "$lt"
+	}
+
+	if field == "_id" {
+		return bson.D{{Key: "_id", Value: bson.D{{Key: op, Value: after.ID}}}}
+	}
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: field, Value: bson.D{{Key: op, Value: after.Value}}}},
+		bson.D{{Key: field, Value: after.Value}, {Key: "_id", Value: bson.D{{Key: op, Value: after.ID}}}},
+	}}}
+}
+
+// encodeCursor 编码游标
+func encodeCursor(value cursorValue) (string, error) {
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解码游标
+func decodeCursor(cursor string) (cursorValue, error) {
+	var value cursorValue
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return value, ErrCursor
+	}
+
+	if err := bson.Unmarshal(data, &value); err != nil || value.ID.Type == 0 {
+		return value, ErrCursor
+	}
+	return value, nil
+}
@@ -0,0 +1,364 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrEmptyFilter 修改或删除时未设置条件
+var ErrEmptyFilter = errors.New("修改或删除条件不能为空")
+
+// Repo 泛型集合仓储，T 为文档结构体（非指针），也可以使用 bson.M
+// 所有链式方法都返回新的实例，不会修改原实例，因此可以安全地复用一个基础查询
+//
+// 结构体带有 bson 名为 created_at / updated_at 的字段时自动维护，
+// 字段类型可以是 time.Time（及以它为底层类型的自定义类型）、primitive.DateTime 或 int64（Unix 秒）
+type Repo[T any] struct {
+	conn       string
+	collection string
+	filters    []interface{}
+	sort       bson.D
+	projection bson.D
+}
+
+// NewRepo 创建仓储，默认使用 Local 连接
+//
+//	logs, err := mongo.NewRepo[ChatLog]("t_chatgpt_log").
+//		Where(bson.M{"username": "demo"}).
+//		Sort("-created_at").
+//		Select("question", "answer").
+//		Find(ctx)
+func NewRepo[T any](collection string) *Repo[T] {
+	return &Repo[T]{conn: "Local", collection: collection}
+}
+
+// clone 复制实例
+func (r *Repo[T]) clone() *Repo[T] {
+	c := *r
+	c.filters = append([]interface{}(nil), r.filters...)
+	return &c
+}
+
+// SetDb 设置连接名称
+func (r *Repo[T]) SetDb(conn string) *Repo[T] {
+	c := r.clone()
+	c.conn = conn
+	return c
+}
+
+// Where 添加查询条件，多次调用时按 $and 组合
+func (r *Repo[T]) Where(filter interface{}) *Repo[T] {
+	c := r.clone()
+	if filter != nil {
+		c.filters = append(c.filters, filter)
+	}
+	return c
+}
+
+// Sort 设置排序，前缀 "-" 表示倒序，如 Sort("-created_at", "_id")
+func (r *Repo[T]) Sort(fields ...string) *Repo[T] {
+	c := r.clone()
+	c.sort = nil
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+
+		order := 1
+		if strings.HasPrefix(field, "-") {
+			order = -1
+		}
+		c.sort = append(c.sort, bson.E{Key: strings.TrimLeft(field, "+-"), Value: order})
+	}
+	return c
+}
+
+// Select 设置返回的字段，前缀 "-" 表示排除，如 Select("-password")
+// 除 _id 外不能同时包含和排除
+func (r *Repo[T]) Select(fields ...string) *Repo[T] {
+	c := r.clone()
+	c.projection = nil
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+
+		if strings.HasPrefix(field, "-") {
+			c.projection = append(c.projection, bson.E{Key: field[1:], Value: 0})
+		} else {
+			c.projection = append(c.projection, bson.E{Key: field, Value: 1})
+		}
+	}
+	return c
+}
+
+// Collection 获取集合，首次调用时创建连接
+func (r *Repo[T]) Collection() (*mongo.Collection, error) {
+	db, err := Client(r.conn)
+	if err != nil {
+		return nil, err
+	}
+	return db.Collection(r.collection), nil
+}
+
+// filter 组合查询条件
+func (r *Repo[T]) filter() interface{} {
+	switch len(r.filters) {
+	case 0:
+		return bson.D{}
+	case 1:
+		return r.filters[0]
+	default:
+		return bson.D{{Key: "$and", Value: r.filters}}
+	}
+}
+
+// begin 获取集合并按配置的超时时间设置 ctx 截止时间
+func (r *Repo[T]) begin(ctx context.Context) (*mongo.Collection, context.Context, context.CancelFunc, error) {
+	coll, err := r.Collection()
+	if err != nil {
+		return nil, ctx, func() {}, err
+	}
+
+	ctx, cancel := withTimeout(ctx, r.conn)
+	return coll, ctx, cancel, nil
+}
+
+// FindOne 查询单条数据，没有数据时返回 mongo.ErrNoDocuments
+func (r *Repo[T]) FindOne(ctx context.Context) (T, error) {
+	var doc T
+
+	coll, ctx, cancel, err := r.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return doc, err
+	}
+
+	opts := options.FindOne()
+	if len(r.sort) > 0 {
+		opts.SetSort(r.sort)
+	}
+	if len(r.projection) > 0 {
+		opts.SetProjection(r.projection)
+	}
+
+	err = coll.FindOne(ctx, r.filter(), opts).Decode(&doc)
+	return doc, err
+}
+
+// Find 查询多条数据
+func (r *Repo[T]) Find(ctx context.Context) ([]T, error) {
+	return r.find(ctx, options.Find())
+}
+
+// find 按选项查询，自动加上排序和返回字段
+func (r *Repo[T]) find(ctx context.Context, opts *options.FindOptions) ([]T, error) {
+	list := []T{}
+
+	coll, ctx, cancel, err := r.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return list, err
+	}
+
+	if len(r.sort) > 0 {
+		opts.SetSort(r.sort)
+	}
+	if len(r.projection) > 0 {
+		opts.SetProjection(r.projection)
+	}
+
+	cursor, err := coll.Find(ctx, r.filter(), opts)
+	if err != nil {
+		return list, err
+	}
+
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+// Count 统计
+func (r *Repo[T]) Count(ctx context.Context) (int64, error) {
+	coll, ctx, cancel, err := r.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	return coll.CountDocuments(ctx, r.filter())
+}
+
+// Insert 插入文档，created_at / updated_at 为零值时自动填充
+// 结构体的 _id 为 primitive.ObjectID 零值且带 omitempty 时由数据库生成，返回插入的 _id 列表
+func (r *Repo[T]) Insert(ctx context.Context, docs ...*T) ([]interface{}, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	coll, ctx, cancel, err := r.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		touchInsert(doc)
+		list[i] = doc
+	}
+
+	if len(list) == 1 {
+		result, err := coll.InsertOne(ctx, list[0])
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{result.InsertedID}, nil
+	}
+
+	result, err := coll.InsertMany(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedIDs, nil
+}
+
+// Update 按条件修改全部匹配的文档，返回修改的条数
+// update 可以是 $set 等操作符文档，也可以是普通文档（按 $set 处理，忽略 _id），自动更新 updated_at
+func (r *Repo[T]) Update(ctx context.Context, update interface{}) (int64, error) {
+	if len(r.filters) == 0 {
+		return 0, ErrEmptyFilter
+	}
+
+	doc, err := updateDocument[T](update, false)
+	if err != nil {
+		return 0, err
+	}
+
+	coll, ctx, cancel, err := r.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := coll.UpdateMany(ctx, r.filter(), doc)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// Upsert 按条件修改第一条匹配的文档，不存在时插入，自动维护 created_at / updated_at
+// update 的格式同 Update，插入时查询条件中的等值字段也会写入新文档
+func (r *Repo[T]) Upsert(ctx context.Context, update interface{}) (*mongo.UpdateResult, error) {
+	if len(r.filters) == 0 {
+		return nil, ErrEmptyFilter
+	}
+
+	doc, err := updateDocument[T](update, true)
+	if err != nil {
+		return nil, err
+	}
+
+	coll, ctx, cancel, err := r.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	return coll.UpdateOne(ctx, r.filter(), doc, options.Update().SetUpsert(true))
+}
+
+// Delete 按条件删除全部匹配的文档，返回删除的条数
+func (r *Repo[T]) Delete(ctx context.Context) (int64, error) {
+	if len(r.filters) == 0 {
+		return 0, ErrEmptyFilter
+	}
+
+	coll, ctx, cancel, err := r.begin(ctx)
+	defer cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := coll.DeleteMany(ctx, r.filter())
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// updateDocument 转换为操作符文档并加上时间字段
+func updateDocument[T any](update interface{}, upsert bool) (bson.D, error) {
+	data, err := bson.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	// 普通文档按 $set 处理，时间字段由下面统一维护（结构体的零值时间不应写入）；
+	// _id 不能修改，传入完整结构体时去掉
+	if len(doc) > 0 && !strings.HasPrefix(doc[0].Key, "$") {
+		fields := make(bson.D, 0, len(doc))
+		for _, e := range doc {
+			if e.Key != "_id" && e.Key != createdAtField && e.Key != updatedAtField {
+				fields = append(fields, e)
+			}
+		}
+		doc = bson.D{{Key: "$set", Value: fields}}
+	}
+
+	updatedAt, createdAt := timestampValues[T]()
+
+	if updatedAt != nil {
+		doc = appendOperator(doc, "$set", bson.E{Key: updatedAtField, Value: updatedAt})
+	}
+
+	// $set 中已有 created_at 时不能再出现在 $setOnInsert 中
+	if upsert && createdAt != nil && !hasOperatorField(doc, "$set", createdAtField) {
+		doc = appendOperator(doc, "$setOnInsert", bson.E{Key: createdAtField, Value: createdAt})
+	}
+
+	return doc, nil
+}
+
+// hasOperatorField 操作符中是否有该字段
+func hasOperatorField(doc bson.D, operator, key string) bool {
+	for _, e := range doc {
+		if fields, ok := e.Value.(bson.D); ok && e.Key == operator {
+			for _, f := range fields {
+				if f.Key == key {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// appendOperator 在操作符中追加字段，操作符中已有该字段时不覆盖
+func appendOperator(doc bson.D, operator string, field bson.E) bson.D {
+	for i, e := range doc {
+		if e.Key != operator {
+			continue
+		}
+
+		fields, ok := e.Value.(bson.D)
+		if !ok || hasOperatorField(doc, operator, field.Key) {
+			return doc
+		}
+
+		doc[i].Value = append(fields, field)
+		return doc
+	}
+
+	return append(doc, bson.E{Key: operator, Value: bson.D{field}})
+}
@@ -0,0 +1,256 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	objectIDType   = reflect.TypeOf(primitive.ObjectID{})
+	decimalType    = reflect.TypeOf(primitive.Decimal128{})
+	rawType        = reflect.TypeOf(bson.Raw{})
+	interfaceType  = reflect.TypeOf((*interface{})(nil)).Elem()
+	bsonDType      = reflect.TypeOf(bson.D{})
+	bsonEType      = reflect.TypeOf(bson.E{})
+	primitiveAType = reflect.TypeOf(primitive.A{})
+)
+
+// JSONSchema 按结构体生成 $jsonSchema 校验规则
+//
+// 字段名称取 bson 标签，类型按 Go 类型映射为 bsonType，指针字段允许 null；
+// 非指针且没有 omitempty 的字段为必填（_id 除外，由数据库生成）；
+// 不限制未声明的字段，interface{} 类型的字段不校验类型
+func JSONSchema[T any]() bson.D {
+	return objectSchema(reflect.TypeOf((*T)(nil)).Elem(), map[reflect.Type]bool{})
+}
+
+// objectSchema 结构体的校验规则，visiting 用于处理递归引用的类型
+func objectSchema(t reflect.Type, visiting map[reflect.Type]bool) bson.D {
+	t = indirect(t)
+	schema := bson.D{{Key: "bsonType", Value: "object"}}
+
+	if t.Kind() != reflect.Struct || visiting[t] {
+		return schema
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var (
+		required   bson.A
+		properties bson.D
+	)
+
+	eachField(t, "", func(name string, field reflect.StructField) {
+		// _id 由驱动生成时为 ObjectId，与结构体中的类型可能不同，不校验
+		if name == "_id" {
+			return
+		}
+
+		_, opts := bsonName(field)
+
+		properties = append(properties, bson.E{Key: name, Value: fieldSchema(field.Type, visiting)})
+
+		if field.Type.Kind() != reflect.Ptr && field.Type.Kind() != reflect.Interface &&
+			!strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	})
+
+	if len(required) > 0 {
+		schema = append(schema, bson.E{Key: "required", Value: required})
+	}
+	if len(properties) > 0 {
+		schema = append(schema, bson.E{Key: "properties", Value: properties})
+	}
+	return schema
+}
+
+// fieldSchema 字段的校验规则
+func fieldSchema(t reflect.Type, visiting map[reflect.Type]bool) bson.D {
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		nullable = true
+		t = indirect(t)
+	}
+
+	var schema bson.D
+	types := bsonTypes(t)
+
+	switch {
+	case types == nil:
+		// 不校验类型
+		return bson.D{}
+	case types[0] == "object" && t.Kind() == reflect.Struct:
+		schema = objectSchema(t, visiting)
+	case types[0] == "array":
+		schema = bson.D{{Key: "bsonType", Value: "array"}}
+		if items := fieldSchema(t.Elem(), visiting); len(items) > 0 {
+			schema = append(schema, bson.E{Key: "items", Value: items})
+		}
+	default:
+		schema = bson.D{{Key: "bsonType", Value: typeValue(types)}}
+	}
+
+	// slice 和 map 的零值编码为 null
+	if nullable || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		schema[0].Value = typeValue(append(toStrings(schema[0].Value), "null"))
+	}
+	return schema
+}
+
+// bsonTypes Go 类型对应的 bsonType，nil 表示不限制
+func bsonTypes(t reflect.Type) []string {
+	switch {
+	case t == interfaceType || t == rawType:
+		return nil
+	case t == timeType || t == dateTimeType || timeType.ConvertibleTo(t) && t.Kind() == reflect.Struct:
+		return []string{"date"}
+	case t == objectIDType:
+		return []string{"objectId"}
+	case t == decimalType:
+		return []string{"decimal"}
+	case t == bsonDType || t == bsonEType:
+		return []string{"object"}
+	case t == primitiveAType:
+		return []string{"array"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return []string{"string"}
+	case reflect.Bool:
+		return []string{"bool"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return []string{"int"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		// 能用 int32 表示的值编码为 int
+		return []string{"int", "long"}
+	case reflect.Float32, reflect.Float64:
+		return []string{"double", "int", "long"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return []string{"binData"}
+		}
+		return []string{"array"}
+	case reflect.Map, reflect.Struct:
+		return []string{"object"}
+	default:
+		return nil
+	}
+}
+
+// typeValue 只有一个类型时使用字符串
+func typeValue(types []string) interface{} {
+	if len(types) == 1 {
+		return types[0]
+	}
+
+	value := make(bson.A, len(types))
+	for i, item := range types {
+		value[i] = item
+	}
+	return value
+}
+
+// toStrings typeValue 的逆操作
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case bson.A:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			types = append(types, item.(string))
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// validatorOf 集合的校验规则文档
+func validatorOf(spec CollectionSpec) bson.D {
+	return bson.D{{Key: "$jsonSchema", Value: spec.Schema}}
+}
+
+// syncValidator 对比并设置集合的校验规则，集合不存在时创建
+func syncValidator(ctx context.Context, db *mongo.Database, spec CollectionSpec, apply bool) (string, error) {
+	specs, err := db.ListCollectionSpecifications(ctx, bson.D{{Key: "name", Value: spec.Collection}})
+	if err != nil {
+		return "", err
+	}
+
+	validator, err := bson.Marshal(validatorOf(spec))
+	if err != nil {
+		return "", err
+	}
+
+	if len(specs) == 0 {
+		if !apply {
+			return IndexMissing, nil
+		}
+
+		opts := options.CreateCollection().
+			SetValidator(validatorOf(spec)).
+			SetValidationLevel(spec.ValidationLevel).
+			SetValidationAction(spec.ValidationAction)
+		if err := db.CreateCollection(ctx, spec.Collection, opts); err != nil {
+			return "", err
+		}
+		return IndexCreated, nil
+	}
+
+	var current struct {
+		Validator        bson.Raw `bson:"validator"`
+		ValidationLevel  string   `bson:"validationLevel"`
+		ValidationAction string   `bson:"validationAction"`
+	}
+	if specs[0].Options != nil {
+		if err := bson.Unmarshal(specs[0].Options, &current); err != nil {
+			return "", err
+		}
+	}
+
+	// 未设置时数据库按 strict / error 处理
+	if current.ValidationLevel == "" {
+		current.ValidationLevel = "strict"
+	}
+	if current.ValidationAction == "" {
+		current.ValidationAction = "error"
+	}
+
+	if bytes.Equal(current.Validator, validator) &&
+		current.ValidationLevel == spec.ValidationLevel &&
+		current.ValidationAction == spec.ValidationAction {
+		return IndexOK, nil
+	}
+
+	if !apply {
+		if len(current.Validator) == 0 {
+			return IndexMissing, nil
+		}
+		return IndexChanged, nil
+	}
+
+	err = db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: spec.Collection},
+		{Key: "validator", Value: validatorOf(spec)},
+		{Key: "validationLevel", Value: spec.ValidationLevel},
+		{Key: "validationAction", Value: spec.ValidationAction},
+	}).Err()
+	if err != nil {
+		return "", err
+	}
+
+	if len(current.Validator) == 0 {
+		return IndexCreated, nil
+	}
+	return IndexRebuilt, nil
+}
@@ -0,0 +1,161 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 自动维护的时间字段
+const (
+	createdAtField = "created_at"
+	updatedAtField = "updated_at"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	dateTimeType = reflect.TypeOf(primitive.DateTime(0))
+)
+
+// timestampFields 结构体中时间字段的位置，没有时为 nil
+type timestampFields struct {
+	createdAt []int
+	updatedAt []int
+	createdTy reflect.Type
+	updatedTy reflect.Type
+}
+
+// 类型 => *timestampFields
+var timestampCache sync.Map
+
+// timestampsOf 解析类型中的 created_at / updated_at 字段
+func timestampsOf(t reflect.Type) *timestampFields {
+	if cached, ok := timestampCache.Load(t); ok {
+		return cached.(*timestampFields)
+	}
+
+	fields := &timestampFields{}
+	if t.Kind() == reflect.Struct {
+		findTimestamps(t, nil, fields)
+	}
+
+	timestampCache.Store(t, fields)
+	return fields
+}
+
+// findTimestamps 按 bson 标签查找字段，包含内嵌（inline）的结构体
+func findTimestamps(t reflect.Type, index []int, fields *timestampFields) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		path := append(append([]int(nil), index...), i)
+
+		name, opts, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && (name == "" || strings.Contains(opts, "inline")) {
+			findTimestamps(field.Type, path, fields)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		switch name {
+		case createdAtField:
+			fields.createdAt, fields.createdTy = path, field.Type
+		case updatedAtField:
+			fields.updatedAt, fields.updatedTy = path, field.Type
+		}
+	}
+}
+
+// touchInsert 插入前填充时间字段，created_at 已有值时保留
+func touchInsert[T any](doc *T) {
+	v := reflect.ValueOf(doc).Elem()
+	fields := timestampsOf(v.Type())
+	now := time.Now()
+
+	if fields.createdAt != nil {
+		if field := v.FieldByIndex(fields.createdAt); field.IsZero() {
+			setTime(field, now)
+		}
+	}
+
+	if fields.updatedAt != nil {
+		setTime(v.FieldByIndex(fields.updatedAt), now)
+	}
+}
+
+// timestampValues 返回修改时写入 updated_at / created_at 的值，没有该字段时为 nil
+func timestampValues[T any]() (updatedAt, createdAt interface{}) {
+	fields := timestampsOf(reflect.TypeOf((*T)(nil)).Elem())
+	now := time.Now()
+
+	if fields.updatedAt != nil {
+		updatedAt = bsonTimeValue(fields.updatedTy, now)
+	}
+	if fields.createdAt != nil {
+		createdAt = bsonTimeValue(fields.createdTy, now)
+	}
+	return updatedAt, createdAt
+}
+
+// bsonTimeValue 把时间转换为写入修改文档的值
+// 以 time.Time 为底层类型的自定义类型没有 bson 编码器，直接使用 time.Time
+func bsonTimeValue(t reflect.Type, now time.Time) interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == dateTimeType:
+		return primitive.NewDateTimeFromTime(now)
+	case timeType.ConvertibleTo(t) && t.Kind() == reflect.Struct:
+		return now
+	case t.Kind() == reflect.Int64 || t.Kind() == reflect.Int:
+		return now.Unix()
+	default:
+		return nil
+	}
+}
+
+// setTime 按字段类型写入时间
+func setTime(field reflect.Value, now time.Time) {
+	if value := timeValue(field.Type(), now); value != nil {
+		field.Set(reflect.ValueOf(value))
+	}
+}
+
+// timeValue 把时间转换为字段类型的值，不支持的类型返回 nil
+func timeValue(t reflect.Type, now time.Time) interface{} {
+	if t.Kind() == reflect.Ptr {
+		value := timeValue(t.Elem(), now)
+		if value == nil {
+			return nil
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(reflect.ValueOf(value))
+		return ptr.Interface()
+	}
+
+	switch {
+	case t == dateTimeType:
+		return primitive.NewDateTimeFromTime(now)
+	case timeType.ConvertibleTo(t) && t.Kind() == reflect.Struct:
+		return reflect.ValueOf(now).Convert(t).Interface()
+	case t.Kind() == reflect.Int64 || t.Kind() == reflect.Int:
+		return reflect.ValueOf(now.Unix()).Convert(t).Interface()
+	default:
+		return nil
+	}
+}
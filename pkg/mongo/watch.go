@@ -0,0 +1,291 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/event_bus"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	minWatchRetry = time.Second      // 出错后首次重试的间隔
+	maxWatchRetry = 30 * time.Second // 重试间隔上限
+)
+
+// resume token 失效的错误码：ChangeStreamHistoryLost / InvalidResumeToken / ChangeStreamFatalError
+var invalidTokenCodes = []int{286, 260, 280}
+
+// ChangeEvent 解码后的变更事件
+type ChangeEvent struct {
+	ID            string      `json:"id"`                       // resume token 中的 _data，可用于去重
+	Operation     string      `json:"operation"`                // insert / update / replace / delete / drop 等
+	Database      string      `json:"database"`                 // 数据库
+	Collection    string      `json:"collection"`               // 集合
+	DocumentID    interface{} `json:"document_id,omitempty"`    // 文档的 _id
+	FullDocument  bson.M      `json:"full_document,omitempty"`  // insert / replace 时的完整文档，FullDocument 开启时 update 也会返回
+	UpdatedFields bson.M      `json:"updated_fields,omitempty"` // update 修改的字段
+	RemovedFields []string    `json:"removed_fields,omitempty"` // update 删除的字段
+	ClusterTime   time.Time   `json:"cluster_time"`             // 操作时间
+}
+
+// changeDocument 变更流返回的原始文档
+type changeDocument struct {
+	ID            bson.Raw                  `bson:"_id"`
+	OperationType string                    `bson:"operationType"`
+	Ns            struct{ DB, Coll string } `bson:"ns"`
+	DocumentKey   struct {
+		ID interface{} `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      bson.M `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+	ClusterTime primitive.Timestamp `bson:"clusterTime"`
+}
+
+// WatchOptions 变更监听选项
+type WatchOptions struct {
+	Name         string                                           // 名称，用于保存 resume token，同名的监听共享进度
+	Conn         string                                           // mongo.yml 中的连接名，默认 Local
+	Collection   string                                           // 集合，为空时监听整个数据库
+	Pipeline     mongo.Pipeline                                   // 过滤管道，如 {{"$match", bson.D{{"operationType", "insert"}}}}
+	FullDocument bool                                             // update 时查询并返回完整文档
+	Tokens       TokenStore                                       // 保存 resume token，为空时不保存，重启后只接收新事件
+	Bus          event_bus.Bus                                    // 发布的事件总线，为空时使用 event_bus.Default()
+	Topic        string                                           // 发布的主题，默认 "mongo:" + 集合名（监听数据库时为数据库名）
+	Handler      func(ctx context.Context, evt ChangeEvent) error // 进程内处理，返回错误时不保存进度并重新监听
+}
+
+// Watcher 基于变更流（Change Stream）监听写入，解码后发布到事件总线
+// 处理成功后保存 resume token，出错时按指数退避自动重新监听并从上次的位置继续，事件至少投递一次
+// 需要副本集或分片集群；多个进程监听同一个集合时，可以配合 lock.LeaderElector 只由一个进程监听
+//
+//	watcher := mongo.NewWatcher(mongo.WatchOptions{
+//		Name:       "ws:t_chatgpt_log",
+//		Collection: "t_chatgpt_log",
+//		Tokens:     mongo.NewRedisTokenStore(redis.NewClient("Local"), ""),
+//	})
+//	go watcher.Run(ctx)
+type Watcher struct {
+	opts WatchOptions
+}
+
+// NewWatcher 创建变更监听
+func NewWatcher(opts WatchOptions) *Watcher {
+	if opts.Conn == "" {
+		opts.Conn = "Local"
+	}
+	if opts.Name == "" {
+		opts.Name = opts.Conn + ":" + opts.Collection
+	}
+	return &Watcher{opts: opts}
+}
+
+// Topic 发布的主题
+func (w *Watcher) Topic() string {
+	if w.opts.Topic != "" {
+		return w.opts.Topic
+	}
+	if w.opts.Collection != "" {
+		return "mongo:" + w.opts.Collection
+	}
+
+	db, err := Client(w.opts.Conn)
+	if err != nil {
+		return "mongo:" + w.opts.Conn
+	}
+	return "mongo:" + db.Name()
+}
+
+// Run 监听直到 ctx 取消，出错时自动重新监听
+func (w *Watcher) Run(ctx context.Context) {
+	delay := minWatchRetry
+
+	for ctx.Err() == nil {
+		handled, err := w.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// 处理过事件说明连接正常，重置重试间隔
+		if handled {
+			delay = minWatchRetry
+		}
+
+		variable.Logs.Warn("MongoDB 变更监听中断，稍后重试",
+			zap.String("name", w.opts.Name), zap.Duration("delay", delay), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > maxWatchRetry {
+			delay = maxWatchRetry
+		}
+	}
+}
+
+// watch 打开变更流并处理事件，返回是否处理过事件
+func (w *Watcher) watch(ctx context.Context) (bool, error) {
+	stream, err := w.open(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close(context.Background())
+
+	variable.Logs.Info("MongoDB 变更监听已启动", zap.String("name", w.opts.Name), zap.String("topic", w.Topic()))
+
+	handled := false
+	for stream.Next(ctx) {
+		if err := w.handle(ctx, stream.Current); err != nil {
+			return handled, err
+		}
+		handled = true
+
+		if err := w.saveToken(ctx, stream.ResumeToken()); err != nil {
+			return handled, err
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return handled, err
+	}
+	return handled, errors.New("变更流已关闭")
+}
+
+// open 打开变更流，有保存的 resume token 时从该位置继续，token 失效时丢弃并只接收新事件
+func (w *Watcher) open(ctx context.Context) (*mongo.ChangeStream, error) {
+	db, err := Client(w.opts.Conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var token bson.Raw
+	if w.opts.Tokens != nil {
+		if token, err = w.opts.Tokens.Load(ctx, w.opts.Name); err != nil {
+			return nil, fmt.Errorf("读取 resume token 失败: %w", err)
+		}
+	}
+
+	stream, err := w.openStream(ctx, db, token)
+	if err != nil && token != nil && isInvalidToken(err) {
+		variable.Logs.Warn("resume token 已失效，从当前位置开始监听，期间的变更会丢失",
+			zap.String("name", w.opts.Name), zap.Error(err))
+
+		if err := w.saveToken(ctx, nil); err != nil {
+			return nil, err
+		}
+		stream, err = w.openStream(ctx, db, nil)
+	}
+	return stream, err
+}
+
+// openStream 按选项打开集合或数据库的变更流
+func (w *Watcher) openStream(ctx context.Context, db *mongo.Database, token bson.Raw) (*mongo.ChangeStream, error) {
+	opts := options.ChangeStream()
+	if w.opts.FullDocument {
+		opts.SetFullDocument(options.UpdateLookup)
+	}
+	if token != nil {
+		// StartAfter 在集合被删除（invalidate）后仍然可以继续
+		opts.SetStartAfter(token)
+	}
+
+	pipeline := w.opts.Pipeline
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	if w.opts.Collection == "" {
+		return db.Watch(ctx, pipeline, opts)
+	}
+	return db.Collection(w.opts.Collection).Watch(ctx, pipeline, opts)
+}
+
+// handle 解码并发布单条事件
+func (w *Watcher) handle(ctx context.Context, raw bson.Raw) error {
+	evt, err := decodeChange(raw)
+	if err != nil {
+		return fmt.Errorf("解码变更事件失败: %w", err)
+	}
+
+	if w.opts.Handler != nil {
+		if err := w.opts.Handler(ctx, evt); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	bus := w.opts.Bus
+	if bus == nil {
+		bus = event_bus.Default()
+	}
+
+	_, err = bus.Publish(ctx, w.Topic(), data)
+	return err
+}
+
+// saveToken 保存 resume token
+func (w *Watcher) saveToken(ctx context.Context, token bson.Raw) error {
+	if w.opts.Tokens == nil {
+		return nil
+	}
+	if err := w.opts.Tokens.Save(ctx, w.opts.Name, token); err != nil {
+		return fmt.Errorf("保存 resume token 失败: %w", err)
+	}
+	return nil
+}
+
+// decodeChange 把变更流的原始文档转换为 ChangeEvent
+func decodeChange(raw bson.Raw) (ChangeEvent, error) {
+	var doc changeDocument
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return ChangeEvent{}, err
+	}
+
+	evt := ChangeEvent{
+		Operation:     doc.OperationType,
+		Database:      doc.Ns.DB,
+		Collection:    doc.Ns.Coll,
+		DocumentID:    doc.DocumentKey.ID,
+		FullDocument:  doc.FullDocument,
+		UpdatedFields: doc.UpdateDescription.UpdatedFields,
+		RemovedFields: doc.UpdateDescription.RemovedFields,
+		ClusterTime:   time.Unix(int64(doc.ClusterTime.T), 0),
+	}
+
+	if data, ok := doc.ID.Lookup("_data").StringValueOK(); ok {
+		evt.ID = data
+	}
+	return evt, nil
+}
+
+// isInvalidToken 是否是 resume token 失效的错误
+func isInvalidToken(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	for _, code := range invalidTokenCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TokenStore 保存变更流的 resume token
+type TokenStore interface {
+	// Load 读取 token，不存在时返回 nil
+	Load(ctx context.Context, name string) (bson.Raw, error)
+
+	// Save 保存 token，token 为 nil 时删除
+	Save(ctx context.Context, name string, token bson.Raw) error
+}
+
+// redisTokenStore 保存在 Redis 中
+type redisTokenStore struct {
+	client goredis.Cmdable
+	prefix string
+}
+
+// NewRedisTokenStore 创建 Redis token 存储，prefix 默认 "mongo:resume_token:"
+func NewRedisTokenStore(client goredis.Cmdable, prefix string) TokenStore {
+	if prefix == "" {
+		prefix = "mongo:resume_token:"
+	}
+	return &redisTokenStore{client: client, prefix: prefix}
+}
+
+// Load 读取 token
+func (s *redisTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	data, err := s.client.Get(ctx, s.prefix+name).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save 保存 token
+func (s *redisTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	if token == nil {
+		return s.client.Del(ctx, s.prefix+name).Err()
+	}
+	return s.client.Set(ctx, s.prefix+name, []byte(token), 0).Err()
+}
+
+// mongoTokenStore 保存在 MongoDB 集合中
+type mongoTokenStore struct {
+	conn       string
+	collection string
+}
+
+// NewMongoTokenStore 创建 MongoDB token 存储，collection 默认 "t_resume_token"
+// 每个监听一条文档，_id 为监听名称
+func NewMongoTokenStore(conn, collection string) TokenStore {
+	if conn == "" {
+		conn = "Local"
+	}
+	if collection == "" {
+		collection = "t_resume_token"
+	}
+	return &mongoTokenStore{conn: conn, collection: collection}
+}
+
+// Load 读取 token
+func (s *mongoTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	db, err := Client(s.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx, s.conn)
+	defer cancel()
+
+	var doc struct {
+		Token bson.Raw `bson:"token"`
+	}
+	err = db.Collection(s.collection).FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	return doc.Token, err
+}
+
+// Save 保存 token
+func (s *mongoTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	db, err := Client(s.conn)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, s.conn)
+	defer cancel()
+
+	coll := db.Collection(s.collection)
+	filter := bson.D{{Key: "_id", Value: name}}
+
+	if token == nil {
+		_, err = coll.DeleteOne(ctx, filter)
+		return err
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "token", Value: token},
+		{Key: "updated_at", Value: time.Now()},
+	}}}
+	_, err = coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
@@ -1,46 +1,48 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"sync"
 	"time"
 	"tool/pkg/event_manage"
+	"tool/pkg/registry"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-// 全局 sync.Map 变量
-var (
-	clients sync.Map
-)
+// clients 按连接名称管理的数据库客户端
+var clients *registry.Registry[*gorm.DB]
+
+func init() {
+	clients = registry.New(registry.Options[*gorm.DB]{
+		Kind:   "Mysql",
+		Create: createDBClient,
+		Close:  closeDBClient,
+		Ping: func(ctx context.Context, db *gorm.DB) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	})
+}
 
-// NewClient 初始化 GORM 客户端，并支持多个数据库连接
+// NewClient 获取 GORM 客户端，并支持多个数据库连接，创建失败时 panic
+// 需要处理错误时使用 Client
 func NewClient(name string) *gorm.DB {
-	db, loaded := clients.LoadOrStore(name, createDBClient(name))
-	if loaded {
-		// 检查连接是否有效
-		if !isValidConnection(db.(*gorm.DB)) {
-			log.Printf("数据库连接丢失，正在重新连接: %s", name)
-			db = createDBClient(name)
-			clients.Store(name, db)
-		} else {
-			printConnectionPoolStats(db.(*gorm.DB))
-		}
-	} else {
-		printConnectionPoolStats(db.(*gorm.DB))
+	db, err := clients.Get(name)
+	if err != nil {
+		panic(err)
 	}
-	return db.(*gorm.DB)
+	return db
 }
 
-// isValidConnection 检查数据库连接是否有效
-func isValidConnection(db *gorm.DB) bool {
-	sqlDB, err := db.DB()
-	if err != nil {
-		return false
-	}
-	return sqlDB.Ping() == nil
+// Client 获取 GORM 客户端，首次调用时创建连接
+func Client(name string) (*gorm.DB, error) {
+	return clients.Get(name)
 }
 
 // printConnectionPoolStats 打印连接池状态
@@ -56,38 +58,28 @@ func printConnectionPoolStats(db *gorm.DB) {
 }
 
 // createDBClient 创建新的数据库客户端
-func createDBClient(name string) *gorm.DB {
+func createDBClient(name string) (*gorm.DB, error) {
 
 	// 加载配置
-	config := loadConfig(name)
-
-	// 判断是否为空
-	if config.Host == "" {
-		panic(fmt.Sprintf("获取 Mysql 配置失败: %s", name))
+	config, err := loadConfig(name)
+	if err != nil {
+		return nil, err
 	}
 
-	// 构建 DSN (数据源名称)
-	dsn := config.User + ":" +
-		config.Pass + "@tcp(" +
-		config.Host + ":" +
-		config.Port + ")/" +
-		config.Database + "?charset=" +
-		config.Charset + "&parseTime=True&loc=Local"
-
 	// 打开数据库连接
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(mysql.Open(dsn(config.User, config.Pass, config.Host, config.Port, config.Database, config.Charset)), &gorm.Config{
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
-		Logger:                 redefineLog(),
+		Logger:                 redefineLog(config.SlowThreshold),
 	})
 	if err != nil {
-		panic(fmt.Sprintf("连接数据库失败: %v", err))
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
 	}
 
 	// 获取底层数据库连接
 	sqlDB, err := db.DB()
 	if err != nil {
-		panic(fmt.Sprintf("获取数据库实例失败: %v", err))
+		return nil, fmt.Errorf("获取数据库实例失败: %w", err)
 	}
 
 	// 设置数据库连接池配置
@@ -102,12 +94,34 @@ func createDBClient(name string) *gorm.DB {
 		d.Statement.RaiseErrorOnNotFound = false
 	})
 
-	// 创建事件管理工厂并注册销毁事件
+	// 按 SQL 指纹统计耗时，慢 SQL 采样 EXPLAIN
+	if config.QueryStats {
+		stats := &queryStats{conn: name, slowThreshold: config.SlowThreshold, explain: config.ExplainSlow}
+		if err := db.Use(stats); err != nil {
+			_ = sqlDB.Close()
+			return nil, fmt.Errorf("注册 SQL 统计失败: %w", err)
+		}
+	}
+
+	// 配置了副本时开启读写分离
+	if len(config.Replicas) > 0 {
+		r := newResolver(name, config)
+		if err := db.Use(r); err != nil {
+			_ = sqlDB.Close()
+			r.Close()
+			return nil, fmt.Errorf("注册读写分离失败: %w", err)
+		}
+		log.Printf("开启读写分离，数据库名称: %s, 副本数: %d", config.Database, len(r.replicas))
+	}
+
+	printConnectionPoolStats(db)
+
+	// 创建事件管理工厂并注册销毁事件，重连后仍然关闭当前的连接
 	eventManageFactory := event_manage.CreateEventManageFactory()
 	eventName := config.EventDestroyPrefix
 	if _, exists := eventManageFactory.Get(eventName); !exists {
 		eventManageFactory.Set(eventName, func(args ...interface{}) {
-			if err := sqlDB.Close(); err != nil {
+			if err := clients.Close(name); err != nil {
 				log.Printf("关闭 Mysql 连接失败: %v", err)
 				return
 			}
@@ -115,14 +129,24 @@ func createDBClient(name string) *gorm.DB {
 		})
 	}
 
-	return db
+	return db, nil
 }
 
-// GetDB 获取数据库连接实例
-func GetDB(name string) *gorm.DB {
-	db, exists := clients.Load(name)
-	if exists {
-		return db.(*gorm.DB)
+// closeDBClient 关闭数据库客户端及其读写分离插件
+func closeDBClient(name string, db *gorm.DB) error {
+	if r, ok := db.Config.Plugins[(&resolver{}).Name()].(*resolver); ok {
+		r.Close()
 	}
-	return nil
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// GetDB 获取已创建的数据库连接实例，不存在时返回 nil
+func GetDB(name string) *gorm.DB {
+	db, _ := clients.Load(name)
+	return db
 }
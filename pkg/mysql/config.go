@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"fmt"
+	"time"
 	"tool/global/variable"
 	"tool/pkg/yml_config"
 )
@@ -18,10 +19,28 @@ type DatabaseConfig struct {
 	SetMaxOpenConns    int    // 数据库的最大连接数量
 	SetConnMaxLifetime int    // 连接的最大可复用时间
 	EventDestroyPrefix string // 事件销毁前缀
+
+	SlowThreshold time.Duration // 慢 SQL 阈值，为 0 时不记录
+	QueryStats    bool          // 按 SQL 指纹统计耗时和行数
+	ExplainSlow   bool          // 对慢 SELECT 采样执行 EXPLAIN
+
+	Replicas             []ReplicaConfig // 只读副本，为空时读写都走主库
+	ReplicaCheckInterval int             // 副本健康检查间隔(秒)
+}
+
+// ReplicaConfig 只读副本配置，User、Pass、DataBase、Charset 为空时使用主库配置
+type ReplicaConfig struct {
+	User     string
+	Pass     string
+	Host     string
+	Port     string
+	Database string
+	Charset  string
+	Weight   int // 权重，默认 1
 }
 
 // 加载配置文件
-func loadConfig(conn string) DatabaseConfig {
+func loadConfig(conn string) (DatabaseConfig, error) {
 
 	mysqlConfig := yml_config.LoadConfig("mysql")
 
@@ -36,10 +55,17 @@ func loadConfig(conn string) DatabaseConfig {
 	// 	SetMaxIdleConns: 10
 	// 	SetMaxOpenConns: 128
 	// 	SetConnMaxLifetime: 60    # 连接不活动时的最大生存时间(秒)
-	// 	SlowThreshold: 30            # 慢 SQL 阈值(sql执行时间超过此时间单位（秒），就会触发系统日志记录)
+	// 	SlowThreshold: "200ms"       # 慢 SQL 阈值，支持 200ms、1s 等格式，纯数字按秒处理，0 不记录
+	// 	QueryStats: true             # 按 SQL 指纹统计耗时，在后台 /admin/sql/stats 查看
+	// 	ExplainSlow: true            # 对慢 SELECT 采样执行 EXPLAIN
+	// 	Replicas:                    # 只读副本(可选)
+	// 	  - Host: "127.0.0.1"
+	// 	    Port: 4307
+	// 	    Weight: 1
+	// 	ReplicaCheckInterval: 10     # 副本健康检查间隔(秒)
 
-	if mysqlConfig.GetString(conn+".User") == "" {
-		panic(fmt.Sprintf("Failed to get Mysql config: %s", conn))
+	if mysqlConfig.GetString(conn+".User") == "" || mysqlConfig.GetString(conn+".Host") == "" {
+		return DatabaseConfig{}, fmt.Errorf("获取 Mysql 配置失败: %s", conn)
 	}
 
 	config := DatabaseConfig{
@@ -53,7 +79,61 @@ func loadConfig(conn string) DatabaseConfig {
 		SetMaxOpenConns:    mysqlConfig.GetInt(conn + ".SetMaxOpenConns"),
 		SetConnMaxLifetime: mysqlConfig.GetInt(conn + ".SetConnMaxLifetime"),
 		EventDestroyPrefix: variable.EventDestroyPrefix + "Mysql_" + conn,
+
+		ReplicaCheckInterval: mysqlConfig.GetConfig(conn+".ReplicaCheckInterval", 10).(int),
+
+		SlowThreshold: yml_config.ParseDuration(conn+".SlowThreshold", mysqlConfig.GetConfig(conn+".SlowThreshold", "1s").(string)),
+		QueryStats:    mysqlConfig.GetConfig(conn+".QueryStats", true).(bool),
+		ExplainSlow:   mysqlConfig.GetConfig(conn+".ExplainSlow", true).(bool),
+	}
+
+	// 读取副本列表，按下标依次读取直到 Host 为空
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("%s.Replicas.%d.", conn, i)
+
+		host := mysqlConfig.GetString(prefix + "Host")
+		if host == "" {
+			break
+		}
+
+		replica := ReplicaConfig{
+			User:     mysqlConfig.GetString(prefix + "User"),
+			Pass:     mysqlConfig.GetString(prefix + "Pass"),
+			Host:     host,
+			Port:     mysqlConfig.GetString(prefix + "Port"),
+			Database: mysqlConfig.GetString(prefix + "DataBase"),
+			Charset:  mysqlConfig.GetString(prefix + "Charset"),
+			Weight:   mysqlConfig.GetConfig(prefix+"Weight", 1).(int),
+		}
+
+		if replica.User == "" {
+			replica.User, replica.Pass = config.User, config.Pass
+		}
+		if replica.Port == "" {
+			replica.Port = config.Port
+		}
+		if replica.Database == "" {
+			replica.Database = config.Database
+		}
+		if replica.Charset == "" {
+			replica.Charset = config.Charset
+		}
+		if replica.Weight < 1 {
+			replica.Weight = 1
+		}
+
+		config.Replicas = append(config.Replicas, replica)
 	}
 
-	return config
+	return config, nil
+}
+
+// dsn 构建数据源名称
+func dsn(user, pass, host, port, database, charset string) string {
+	return user + ":" +
+		pass + "@tcp(" +
+		host + ":" +
+		port + ")/" +
+		database + "?charset=" +
+		charset + "&parseTime=True&loc=Local"
 }
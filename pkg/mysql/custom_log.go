@@ -14,14 +14,15 @@ import (
 )
 
 // 创建自定义日志模块，对 gorm 日志进行拦截
-func redefineLog() gormLog.Interface {
-	return createCustomGormLog(
+// slowThreshold: 慢 SQL 阈值，为 0 时不记录慢 SQL
+func redefineLog(slowThreshold time.Duration) gormLog.Interface {
+	return createCustomGormLog(slowThreshold,
 		SetInfoStrFormat("[info] %s\n"), SetWarnStrFormat("[warn] %s\n"), SetErrStrFormat("[error] %s\n"),
 		SetTraceStrFormat("[traceStr] %s [%.3fms] [rows:%v] %s\n"), SetTracWarnStrFormat("[traceWarn] %s %s [%.3fms] [rows:%v] %s\n"), SetTracErrStrFormat("[traceErr] %s %s [%.3fms] [rows:%v] %s\n"))
 }
 
 // 自定义日志格式, 对 gorm 自带日志进行拦截重写
-func createCustomGormLog(options ...Options) gormLog.Interface {
+func createCustomGormLog(slowThreshold time.Duration, options ...Options) gormLog.Interface {
 	var (
 		infoStr      = "%s\n[info] "
 		warnStr      = "%s\n[warn] "
@@ -31,7 +32,7 @@ func createCustomGormLog(options ...Options) gormLog.Interface {
 		traceErrStr  = "%s %s\n[%.3fms] [rows:%v] %s"
 	)
 	logConf := gormLog.Config{
-		SlowThreshold: time.Second * variable.ConfigYml.GetDuration("Mysql.SlowThreshold"),
+		SlowThreshold: slowThreshold,
 		LogLevel:      gormLog.Warn,
 		Colorful:      false,
 	}
@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+var (
+	// 注释：/* ... */、-- ...、# ...，MySQL 中 -- 后面必须是空白或行尾，a--1 不是注释
+	commentPattern = regexp.MustCompile(`(?s)/\*.*?\*/|--(?:[ \t\r\f\v][^\n]*)?(?:\n|$)|#[^\n]*`)
+
+	// 字符串：'...'、"..."，支持反斜杠和重复引号转义
+	stringPattern = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*"`)
+
+	// 数字和十六进制，前面不能是标识符的字符，避免替换 t1、col_2 等
+	numberPattern = regexp.MustCompile(`(^|[^\w.$` + "`" + `])(?:0x[0-9a-f]+|-?\d+(?:\.\d+)?(?:e[+-]?\d+)?)\b`)
+
+	// IN (?, ?, ?) 合并为 IN (?+)
+	inListPattern = regexp.MustCompile(`\bin\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+
+	// VALUES (?, ?), (?, ?) 合并为 VALUES (?, ?)
+	valuesPattern = regexp.MustCompile(`\bvalues\s*(\([^()]*\))(?:\s*,\s*\([^()]*\))+`)
+
+	// 连续空白
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint 把 SQL 规范化为指纹，字面量替换为 ?，IN 列表和多行 VALUES 合并，用于聚合同一类语句
+//
+//	SELECT * FROM t_admin WHERE id IN (1, 2, 3) AND username = 'admin'
+//	=> select * from t_admin where id in (?+) and username = ?
+func Fingerprint(sql string) string {
+	sql = stringPattern.ReplaceAllString(sql, "?")
+	sql = commentPattern.ReplaceAllString(sql, " ")
+	sql = strings.ToLower(sql)
+	sql = numberPattern.ReplaceAllString(sql, "${1}?")
+	sql = spacePattern.ReplaceAllString(strings.TrimSpace(sql), " ")
+	sql = inListPattern.ReplaceAllString(sql, "in (?+)")
+	sql = valuesPattern.ReplaceAllString(sql, "values $1")
+	return sql
+}
+
+// fingerprintID 指纹的短 ID
+func fingerprintID(fingerprint string) string {
+	sum := md5.Sum([]byte(fingerprint))
+	return hex.EncodeToString(sum[:8])
+}
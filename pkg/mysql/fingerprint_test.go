@@ -0,0 +1,44 @@
+package mysql
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"in list and string", "SELECT * FROM t_admin WHERE id IN (1, 2, 3) AND username = 'admin'", "select * from t_admin where id in (?+) and username = ?"},
+		{"placeholders", "SELECT * FROM `h_user` WHERE `id` = ? AND `type` IN (?,?)", "select * from `h_user` where `id` = ? and `type` in (?+)"},
+		{"identifiers with digits", "SELECT col_2, t1.a FROM t1 WHERE x2 = 5", "select col_2, t1.a from t1 where x2 = ?"},
+		{"negative and float", "SELECT * FROM t WHERE a = -1 AND b > 1.5e3", "select * from t where a = ? and b > ?"},
+		{"hex", "SELECT * FROM t WHERE a = 0xFF", "select * from t where a = ?"},
+		{"escaped quotes", `SELECT 'it''s', 'a\'b', "c""d"`, "select ?, ?, ?"},
+		{"comment in string", "SELECT '-- not a comment', '/* x */' FROM t", "select ?, ? from t"},
+		{"block comment", "SELECT /* hint */ a FROM t", "select a from t"},
+		{"line comment", "SELECT a -- trailing\nFROM t", "select a from t"},
+		{"line comment at end", "SELECT a FROM t --", "select a from t"},
+		{"hash comment", "SELECT a FROM t # note", "select a from t"},
+		{"double minus is not a comment", "SELECT a--1 FROM t", "select a-? from t"},
+		{"double minus with column", "UPDATE t SET a = a--b WHERE id = 1", "update t set a = a--b where id = ?"},
+		{"multi values", "INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z')", "insert into t (a, b) values (?, ?)"},
+		{"whitespace", "SELECT\n\ta\n  FROM   t", "select a from t"},
+		{"limit", "SELECT * FROM t LIMIT 10 OFFSET 20", "select * from t limit ? offset ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fingerprint(tt.sql); got != tt.want {
+				t.Fatalf("Fingerprint(%q)\n got  %q\n want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprintID(t *testing.T) {
+	a := fingerprintID(Fingerprint("SELECT * FROM t WHERE id = 1"))
+	b := fingerprintID(Fingerprint("select *  from t where id = 42"))
+	if a != b || len(a) != 16 {
+		t.Fatalf("fingerprintID = %s, %s", a, b)
+	}
+}
@@ -0,0 +1,208 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tool/global/variable"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// lockingRead 原生语句中的加锁读取：FOR UPDATE、FOR SHARE、LOCK IN SHARE MODE
+var lockingRead = regexp.MustCompile(`(?i)\bfor\s+(update|share)\b|\block\s+in\s+share\s+mode\b`)
+
+// replica 只读副本
+type replica struct {
+	addr    string
+	db      *sql.DB
+	weight  int
+	healthy atomic.Bool
+}
+
+// resolver 读写分离插件
+//
+// 查询走健康的副本（按权重随机），写入、事务、加锁读取（FOR UPDATE）走主库；
+// 同一个粘滞上下文（见 WithSticky）内发生过写入后，后续读取也走主库，避免读到未同步的数据；
+// 副本健康检查失败时自动摘除，恢复后重新加入
+type resolver struct {
+	name     string
+	replicas []*replica
+	interval time.Duration
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// newResolver 创建读写分离插件，连接失败的副本先标记为不健康，由健康检查恢复
+func newResolver(name string, config DatabaseConfig) *resolver {
+	r := &resolver{
+		name:     name,
+		interval: time.Duration(config.ReplicaCheckInterval) * time.Second,
+		stop:     make(chan struct{}),
+	}
+
+	if r.interval <= 0 {
+		r.interval = 10 * time.Second
+	}
+
+	for _, rc := range config.Replicas {
+		// sql.Open 不会建立连接，只在 DSN 格式错误时返回错误
+		db, err := sql.Open("mysql", dsn(rc.User, rc.Pass, rc.Host, rc.Port, rc.Database, rc.Charset))
+		if err != nil {
+			variable.Logs.Error("Mysql 副本配置错误", zap.String("conn", name), zap.String("addr", rc.Host+":"+rc.Port), zap.Error(err))
+			continue
+		}
+
+		db.SetMaxIdleConns(config.SetMaxIdleConns)
+		db.SetMaxOpenConns(config.SetMaxOpenConns)
+		db.SetConnMaxLifetime(time.Duration(config.SetConnMaxLifetime) * time.Second)
+
+		rep := &replica{addr: rc.Host + ":" + rc.Port, db: db, weight: rc.Weight}
+		rep.healthy.Store(db.Ping() == nil)
+		r.replicas = append(r.replicas, rep)
+	}
+
+	return r
+}
+
+// Name 插件名称
+func (r *resolver) Name() string {
+	return "mysql:resolver"
+}
+
+// Initialize 注册回调并启动健康检查
+func (r *resolver) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("mysql:resolver_query", r.routeRead); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("mysql:resolver_row", r.routeRead); err != nil {
+		return err
+	}
+	if err := db.Callback().Raw().Before("gorm:raw").Register("mysql:resolver_raw", r.routeRead); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().Before("gorm:create").Register("mysql:resolver_create", markWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("mysql:resolver_update", markWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("mysql:resolver_delete", markWrite); err != nil {
+		return err
+	}
+
+	go r.healthCheck()
+
+	return nil
+}
+
+// Close 停止健康检查并关闭副本连接
+func (r *resolver) Close() {
+	r.once.Do(func() {
+		close(r.stop)
+		for _, rep := range r.replicas {
+			_ = rep.db.Close()
+		}
+	})
+}
+
+// routeRead 查询语句路由到副本
+//
+// Exec、Raw 执行的原生语句在回调前已经有 SQL，只有 SELECT 可以读副本，其余按写入处理
+func (r *resolver) routeRead(db *gorm.DB) {
+	if sql := strings.TrimSpace(db.Statement.SQL.String()); sql != "" {
+		if len(sql) < 6 || !strings.EqualFold(sql[:6], "select") {
+			markWrite(db)
+			return
+		}
+	}
+
+	if !r.readable(db) {
+		return
+	}
+
+	if rep := r.pick(); rep != nil {
+		db.Statement.ConnPool = rep.db
+	}
+}
+
+// readable 当前语句是否可以读副本
+func (r *resolver) readable(db *gorm.DB) bool {
+	// 事务中的语句必须在同一个连接上执行
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return false
+	}
+
+	// SELECT ... FOR UPDATE / LOCK IN SHARE MODE，包括原生语句
+	if _, ok := db.Statement.Clauses["FOR"]; ok {
+		return false
+	}
+	if lockingRead.MatchString(db.Statement.SQL.String()) {
+		return false
+	}
+
+	return !usePrimary(db.Statement.Context)
+}
+
+// pick 按权重从健康的副本中随机选择一个，没有健康的副本时返回 nil
+func (r *resolver) pick() *replica {
+	total := 0
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			total += rep.weight
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	n := rand.Intn(total)
+	for _, rep := range r.replicas {
+		if !rep.healthy.Load() {
+			continue
+		}
+		if n < rep.weight {
+			return rep
+		}
+		n -= rep.weight
+	}
+
+	return nil
+}
+
+// healthCheck 定时检查副本，状态变化时记录日志
+func (r *resolver) healthCheck() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, rep := range r.replicas {
+			ctx, cancel := context.WithTimeout(context.Background(), r.interval/2)
+			healthy := rep.db.PingContext(ctx) == nil
+			cancel()
+
+			if rep.healthy.Swap(healthy) == healthy {
+				continue
+			}
+
+			if healthy {
+				variable.Logs.Info("Mysql 副本恢复", zap.String("conn", r.name), zap.String("addr", rep.addr))
+			} else {
+				variable.Logs.Warn("Mysql 副本不可用，已摘除", zap.String("conn", r.name), zap.String("addr", rep.addr))
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// testResolver 只有一个健康副本的插件，sql.Open 不会建立连接
+func testResolver(t *testing.T) (*resolver, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("mysql", dsn("root", "", "127.0.0.1", "1", "test", "utf8mb4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	rep := &replica{addr: "127.0.0.1:1", db: db, weight: 1}
+	rep.healthy.Store(true)
+	return &resolver{replicas: []*replica{rep}}, db
+}
+
+func TestRouteRead(t *testing.T) {
+	tx := &sql.Tx{}
+
+	tests := []struct {
+		name    string
+		sql     string
+		clauses map[string]clause.Clause
+		pool    gorm.ConnPool
+		replica bool
+	}{
+		{name: "query", replica: true},
+		{name: "raw select", sql: "SELECT * FROM t WHERE id = ?", replica: true},
+		{name: "raw for update", sql: "SELECT * FROM t WHERE id = ? FOR UPDATE"},
+		{name: "raw for share", sql: "select * from t for share"},
+		{name: "raw lock in share mode", sql: "SELECT * FROM t LOCK IN SHARE MODE"},
+		{name: "raw update", sql: "UPDATE t SET a = 1"},
+		{name: "for clause", clauses: map[string]clause.Clause{"FOR": {}}},
+		{name: "transaction", pool: tx},
+		{name: "raw select in transaction", sql: "SELECT 1", pool: tx},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, replicaDB := testResolver(t)
+
+			db := &gorm.DB{Statement: &gorm.Statement{Context: context.Background(), Clauses: tt.clauses, ConnPool: tt.pool}}
+			db.Statement.SQL.WriteString(tt.sql)
+
+			r.routeRead(db)
+			if got := db.Statement.ConnPool == gorm.ConnPool(replicaDB); got != tt.replica {
+				t.Fatalf("routed to replica = %v, want %v", got, tt.replica)
+			}
+		})
+	}
+}
+
+func TestRouteReadSticky(t *testing.T) {
+	r, replicaDB := testResolver(t)
+	ctx := WithSticky(context.Background())
+
+	// 写入后同一上下文的读取走主库
+	write := &gorm.DB{Statement: &gorm.Statement{Context: ctx}}
+	write.Statement.SQL.WriteString("INSERT INTO t VALUES (1)")
+	r.routeRead(write)
+
+	read := &gorm.DB{Statement: &gorm.Statement{Context: ctx}}
+	r.routeRead(read)
+	if read.Statement.ConnPool == gorm.ConnPool(replicaDB) {
+		t.Fatal("read after write routed to replica")
+	}
+}
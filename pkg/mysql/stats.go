@@ -0,0 +1,295 @@
+package mysql
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/metrics"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormLog "gorm.io/gorm/logger"
+)
+
+const (
+	statsStartKey   = "mysql:stats_start" // 语句开始时间
+	statsSkipKey    = "mysql:stats_skip"  // 不统计的语句，如 EXPLAIN
+	explainInterval = 10 * time.Minute    // 同一指纹 EXPLAIN 的最小间隔
+	explainTimeout  = 5 * time.Second     // EXPLAIN 超时时间
+	maxSampleLength = 2000                // 保存的 SQL 样例最大长度
+)
+
+// queryTimer 按 "连接/指纹ID" 统计的 SQL 耗时，通过 expvar 导出为 mysql_queries
+var queryTimer = metrics.NewTimer("mysql_queries")
+
+// explainSem 限制同时执行的 EXPLAIN 数量，避免慢 SQL 集中出现时加重数据库负担
+var explainSem = make(chan struct{}, 2)
+
+// fingerprintInfo 指纹的附加信息
+type fingerprintInfo struct {
+	conn        string
+	fingerprint string
+	sample      string
+	rows        int64
+	slow        int64
+	explain     []map[string]interface{}
+	explainedAt time.Time
+	explaining  bool
+}
+
+var (
+	infosMu sync.Mutex
+	infos   = make(map[string]*fingerprintInfo) // "连接/指纹ID" => 附加信息
+)
+
+// QueryStat 单个 SQL 指纹的统计
+type QueryStat struct {
+	metrics.Stat
+	Conn        string                   `json:"conn"`
+	Fingerprint string                   `json:"fingerprint"`
+	Sample      string                   `json:"sample"`   // 最近一次慢 SQL（没有时为最近一次）的语句，参数为占位符，不包含绑定的值
+	Rows        int64                    `json:"rows"`     // 影响或返回的总行数
+	AvgRows     float64                  `json:"avg_rows"` // 平均行数
+	Slow        int64                    `json:"slow"`     // 慢 SQL 次数
+	Explain     []map[string]interface{} `json:"explain,omitempty"`
+	ExplainedAt *time.Time               `json:"explained_at,omitempty"`
+}
+
+// queryStats 统计插件，按指纹聚合耗时和行数，对慢 SQL 采样 EXPLAIN
+type queryStats struct {
+	conn          string
+	slowThreshold time.Duration
+	explain       bool
+}
+
+// Name 插件名称
+func (s *queryStats) Name() string {
+	return "mysql:stats"
+}
+
+// Initialize 在全部语句类型前后注册回调
+func (s *queryStats) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	for _, err := range []error{
+		callback.Query().Before("*").Register("mysql:stats_before_query", s.before),
+		callback.Query().After("*").Register("mysql:stats_after_query", s.after),
+		callback.Create().Before("*").Register("mysql:stats_before_create", s.before),
+		callback.Create().After("*").Register("mysql:stats_after_create", s.after),
+		callback.Update().Before("*").Register("mysql:stats_before_update", s.before),
+		callback.Update().After("*").Register("mysql:stats_after_update", s.after),
+		callback.Delete().Before("*").Register("mysql:stats_before_delete", s.before),
+		callback.Delete().After("*").Register("mysql:stats_after_delete", s.after),
+		callback.Row().Before("*").Register("mysql:stats_before_row", s.before),
+		callback.Row().After("*").Register("mysql:stats_after_row", s.after),
+		callback.Raw().Before("*").Register("mysql:stats_before_raw", s.before),
+		callback.Raw().After("*").Register("mysql:stats_after_raw", s.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before 记录开始时间
+func (s *queryStats) before(db *gorm.DB) {
+	db.InstanceSet(statsStartKey, time.Now())
+}
+
+// after 按指纹记录耗时和行数
+func (s *queryStats) after(db *gorm.DB) {
+	if _, skip := db.Get(statsSkipKey); skip {
+		return
+	}
+
+	value, ok := db.InstanceGet(statsStartKey)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(value.(time.Time))
+
+	sql := db.Statement.SQL.String()
+	if sql == "" {
+		return
+	}
+
+	fingerprint := Fingerprint(sql)
+	label := s.conn + "/" + fingerprintID(fingerprint)
+	failed := db.Error != nil && db.Error != gorm.ErrRecordNotFound
+	slow := s.slowThreshold > 0 && elapsed >= s.slowThreshold
+
+	queryTimer.Observe(label, elapsed, failed)
+
+	infosMu.Lock()
+	info, ok := infos[label]
+	if !ok {
+		info = &fingerprintInfo{conn: s.conn, fingerprint: fingerprint}
+		infos[label] = info
+	}
+	info.rows += db.Statement.RowsAffected
+	if slow {
+		info.slow++
+	}
+	if slow || info.sample == "" {
+		// 只保存带占位符的语句，绑定的值可能是用户名、令牌等敏感数据
+		info.sample = truncate(sql, maxSampleLength)
+	}
+
+	// 慢查询按间隔采样 EXPLAIN，只处理 SELECT，不在事务中执行
+	explain := slow && s.explain && !info.explaining && time.Since(info.explainedAt) >= explainInterval &&
+		isSelect(sql) && !inTransaction(db)
+	if explain {
+		info.explaining = true
+	}
+	infosMu.Unlock()
+
+	if explain {
+		vars := append([]interface{}(nil), db.Statement.Vars...)
+		go s.runExplain(db, label, sql, vars)
+	}
+}
+
+// runExplain 执行 EXPLAIN 并保存结果
+func (s *queryStats) runExplain(db *gorm.DB, label, sql string, vars []interface{}) {
+	defer func() {
+		infosMu.Lock()
+		infos[label].explaining = false
+		infos[label].explainedAt = time.Now()
+		infosMu.Unlock()
+	}()
+
+	select {
+	case explainSem <- struct{}{}:
+		defer func() { <-explainSem }()
+	default:
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), explainTimeout)
+	defer cancel()
+
+	var rows []map[string]interface{}
+	err := db.Session(&gorm.Session{NewDB: true, Context: ctx, Logger: gormLog.Discard}).
+		Set(statsSkipKey, true).
+		Raw("EXPLAIN "+sql, vars...).
+		Scan(&rows).Error
+	if err != nil {
+		variable.Logs.Warn("慢 SQL EXPLAIN 失败", zap.String("conn", s.conn), zap.String("sql", sql), zap.Error(err))
+		return
+	}
+
+	// 驱动返回的字符串字段为 []byte，转换后便于输出 JSON
+	for _, row := range rows {
+		for key, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[key] = string(b)
+			}
+		}
+	}
+
+	infosMu.Lock()
+	infos[label].explain = rows
+	infosMu.Unlock()
+}
+
+// TopQueries 按指标倒序返回前 n 个 SQL 指纹的统计，n <= 0 时返回全部
+// orderBy: total（总耗时，默认）/ count / avg / p95 / p99 / max / rows / slow
+func TopQueries(n int, orderBy string) []QueryStat {
+	stats := queryTimer.Stats()
+
+	infosMu.Lock()
+	list := make([]QueryStat, 0, len(stats))
+	for _, stat := range stats {
+		info, ok := infos[stat.Label]
+		if !ok {
+			continue
+		}
+
+		item := QueryStat{
+			Stat:        stat,
+			Conn:        info.conn,
+			Fingerprint: info.fingerprint,
+			Sample:      info.sample,
+			Rows:        info.rows,
+			AvgRows:     float64(info.rows) / float64(stat.Count),
+			Slow:        info.slow,
+			Explain:     info.explain,
+		}
+		if !info.explainedAt.IsZero() && info.explain != nil {
+			explainedAt := info.explainedAt
+			item.ExplainedAt = &explainedAt
+		}
+		list = append(list, item)
+	}
+	infosMu.Unlock()
+
+	metric := func(s QueryStat) float64 {
+		switch orderBy {
+		case "count":
+			return float64(s.Count)
+		case "avg":
+			return s.AvgMs
+		case "p95":
+			return s.P95Ms
+		case "p99":
+			return s.P99Ms
+		case "max":
+			return s.MaxMs
+		case "rows":
+			return float64(s.Rows)
+		case "slow":
+			return float64(s.Slow)
+		default:
+			return s.TotalMs
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return metric(list[i]) > metric(list[j])
+	})
+
+	if n > 0 && len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// ResetQueryStats 清空 SQL 统计
+func ResetQueryStats() {
+	infosMu.Lock()
+	defer infosMu.Unlock()
+
+	queryTimer.Reset()
+	for label, info := range infos {
+		// 正在执行的 EXPLAIN 结束时还会访问，保留条目
+		if info.explaining {
+			*info = fingerprintInfo{conn: info.conn, fingerprint: info.fingerprint, explaining: true}
+			continue
+		}
+		delete(infos, label)
+	}
+}
+
+// isSelect 是否是 SELECT 语句
+func isSelect(sql string) bool {
+	sql = strings.TrimSpace(sql)
+	return len(sql) >= 6 && strings.EqualFold(sql[:6], "select")
+}
+
+// inTransaction 是否在事务中
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "...(truncated)"
+}
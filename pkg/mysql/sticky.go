@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+type stickyKey struct{}
+
+// sticky 粘滞状态，同一个请求内共享
+type sticky struct {
+	wrote   atomic.Bool // 是否发生过写入
+	primary bool        // 是否强制读主库
+}
+
+// WithSticky 开启粘滞读：上下文内发生写入后，后续读取都走主库
+// 通常由 HTTP 中间件为每个请求调用一次
+func WithSticky(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(stickyKey{}).(*sticky); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, stickyKey{}, &sticky{})
+}
+
+// UsePrimary 强制上下文内的读取都走主库，用于对一致性要求高的查询
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey{}, &sticky{primary: true})
+}
+
+// usePrimary 读取是否需要走主库
+func usePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	s, ok := ctx.Value(stickyKey{}).(*sticky)
+	return ok && (s.primary || s.wrote.Load())
+}
+
+// markWrite 记录上下文内发生了写入
+func markWrite(db *gorm.DB) {
+	if db.Statement.Context == nil {
+		return
+	}
+	if s, ok := db.Statement.Context.Value(stickyKey{}).(*sticky); ok {
+		s.wrote.Store(true)
+	}
+}
@@ -4,35 +4,51 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sync"
 	"time"
 	"tool/pkg/event_manage"
+	"tool/pkg/registry"
 
 	"github.com/go-redis/redis/v8"
 )
 
-// RedisClient 是一个全局的 Redis 客户端
-var (
-	clients sync.Map
-)
+// clients 按连接名称管理的 Redis 客户端
+var clients *registry.Registry[redis.UniversalClient]
+
+func init() {
+	clients = registry.New(registry.Options[redis.UniversalClient]{
+		Kind:   "Redis",
+		Create: createClient,
+		Close: func(name string, client redis.UniversalClient) error {
+			return client.Close()
+		},
+		Ping: func(ctx context.Context, client redis.UniversalClient) error {
+			return client.Ping(ctx).Err()
+		},
+	})
+}
 
-// createClient 创建 Redis 客户端
-func createClient(name string) *redis.Client {
+// createClient 按部署模式创建 Redis 客户端
+func createClient(name string) (redis.UniversalClient, error) {
 
 	// 加载配置
-	config := loadConfig(name)
+	config, err := loadConfig(name)
+	if err != nil {
+		return nil, err
+	}
 
-	// 判断是否为空
-	if config.Host == "" {
-		panic(fmt.Sprintf("Failed to get Redis config: %s", name))
+	tlsConfig, err := config.TLS.tlsConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	options := &redis.Options{
-		Network:            "tcp",
-		Addr:               config.Host,
-		Username:           "",
+	options := &redis.UniversalOptions{
+		Username:           config.Username,
 		Password:           config.Auth,
 		DB:                 config.IndexDb,
+		SentinelUsername:   config.SentinelUsername,
+		SentinelPassword:   config.SentinelAuth,
+		MasterName:         config.MasterName,
+		TLSConfig:          tlsConfig,
 		MaxRetries:         3,
 		MinRetryBackoff:    8 * time.Millisecond,
 		MaxRetryBackoff:    512 * time.Millisecond,
@@ -46,51 +62,72 @@ func createClient(name string) *redis.Client {
 		IdleCheckFrequency: 1 * time.Minute,
 	}
 
-	for i := 0; i < config.ConnFailRetryTimes; i++ {
-		client := redis.NewClient(options)
+	// 至少尝试一次
+	attempts := config.ConnFailRetryTimes
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for i := 0; i < attempts; i++ {
+		client := newUniversalClient(config, options)
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		err := client.Ping(ctx).Err()
+		err = client.Ping(ctx).Err()
+		cancel()
+
 		if err == nil {
-			log.Printf("Successfully connected to Redis")
+			log.Printf("Successfully connected to Redis (%s)", config.Mode)
 
+			// 注册销毁事件，重连后仍然关闭当前的连接
 			eventManageFactory := event_manage.CreateEventManageFactory()
 			if _, exists := eventManageFactory.Get(config.EventDestroyPrefix); !exists {
 				eventManageFactory.Set(config.EventDestroyPrefix, func(args ...interface{}) {
-					_ = client.Close()
+					_ = clients.Close(name)
 					log.Printf("Destroying Redis connection")
 				})
 			}
 
-			return client
+			return client, nil
 		}
 
-		log.Printf("Failed to connect to Redis, retrying... (attempt %d)", i+1)
-		time.Sleep(time.Duration(config.ConnFailRetryInterval) * time.Second)
+		_ = client.Close()
+
+		if i+1 < attempts {
+			log.Printf("Failed to connect to Redis, retrying... (attempt %d)", i+1)
+			time.Sleep(time.Duration(config.ConnFailRetryInterval) * time.Second)
+		}
 	}
 
-	panic(fmt.Sprintf("Failed to connect to Redis, reached maximum retry attempts"))
+	return nil, fmt.Errorf("连接 Redis 失败，已达到最大重试次数: %w", err)
 }
 
-// isValidConnection 检查 Redis 连接是否有效
-func isValidConnection(client *redis.Client) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	err := client.Ping(ctx).Err()
-	return err == nil
+// newUniversalClient 按部署模式创建客户端
+// 不使用 redis.NewUniversalClient，它按地址个数推断模式，单个种子节点的集群会被当作单节点
+func newUniversalClient(config RedisConfig, options *redis.UniversalOptions) redis.UniversalClient {
+	switch config.Mode {
+	case ModeSentinel:
+		options.Addrs = config.SentinelAddrs
+		return redis.NewFailoverClient(options.Failover())
+	case ModeCluster:
+		options.Addrs = config.ClusterAddrs
+		return redis.NewClusterClient(options.Cluster())
+	default:
+		options.Addrs = []string{config.Host}
+		return redis.NewClient(options.Simple())
+	}
 }
 
-// NewClient 初始化 Redis 客户端，并支持多个 Redis 连接
-func NewClient(name string) *redis.Client {
-	client, loaded := clients.LoadOrStore(name, createClient(name))
-	if loaded {
-		// 检查连接是否有效
-		if !isValidConnection(client.(*redis.Client)) {
-			log.Printf("Redis 连接丢失，正在重新连接: %s", name)
-			client = createClient(name)
-			clients.Store(name, client)
-		}
+// NewClient 获取 Redis 客户端，并支持多个 Redis 连接，创建失败时 panic
+// 按 Mode 配置返回单节点、哨兵或集群客户端；需要处理错误时使用 Client
+func NewClient(name string) redis.UniversalClient {
+	client, err := clients.Get(name)
+	if err != nil {
+		panic(err)
 	}
-	return client.(*redis.Client)
+	return client
+}
+
+// Client 获取 Redis 客户端，首次调用时创建连接
+func Client(name string) (redis.UniversalClient, error) {
+	return clients.Get(name)
 }
@@ -1,45 +1,91 @@
 package redis
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"tool/global/variable"
 	"tool/pkg/yml_config"
 )
 
+// 部署模式
+const (
+	ModeStandalone = "standalone" // 单节点
+	ModeSentinel   = "sentinel"   // 哨兵
+	ModeCluster    = "cluster"    // 集群
+)
+
 type RedisConfig struct {
-	Host                  string // Redis 服务器地址，格式为 "host:port"。
-	Auth                  string // 可选的密码。如果不需要密码认证，请留空。
-	IndexDb               int    // 数据库编号。默认为 0。
-	PoolSize              int    // 每个 CPU 的最大连接数。默认为 10。
+	Mode                  string   // 部署模式 standalone / sentinel / cluster，默认 standalone
+	Host                  string   // Redis 服务器地址，格式为 "host:port"。standalone 模式使用
+	Username              string   // ACL 用户名，Redis 6 以上使用，不需要时留空
+	Auth                  string   // 可选的密码。如果不需要密码认证，请留空。
+	IndexDb               int      // 数据库编号。默认为 0。cluster 模式不支持
+	MasterName            string   // 哨兵监控的主节点名称，sentinel 模式使用
+	SentinelAddrs         []string // 哨兵地址，sentinel 模式使用
+	SentinelUsername      string   // 哨兵的 ACL 用户名
+	SentinelAuth          string   // 哨兵的密码
+	ClusterAddrs          []string // 集群种子节点，cluster 模式使用，其余节点自动发现
+	TLS                   TLSConfig
+	PoolSize              int    // 每个 CPU 的最大连接数。默认为 10。cluster 模式为每个节点
 	MinIdleConns          int    // 最小空闲连接数。在建立新连接较慢时很有用。
 	ConnFailRetryTimes    int    // 放弃前的最大重试次数。默认为不重试。
 	ConnFailRetryInterval int    // 重试之间的最小退避时间。默认为 8 毫秒；-1 禁用退避。
 	EventDestroyPrefix    string // 事件销毁前缀
 }
 
-func loadConfig(conn string) RedisConfig {
+// TLSConfig TLS 连接配置
+type TLSConfig struct {
+	Enable             bool   // 是否开启 TLS
+	CaFile             string // CA 证书，为空时使用系统证书
+	CertFile           string // 客户端证书，双向认证时使用
+	KeyFile            string // 客户端私钥
+	ServerName         string // 校验的服务端名称，为空时使用连接地址
+	InsecureSkipVerify bool   // 跳过证书校验，仅用于测试环境
+}
+
+func loadConfig(conn string) (RedisConfig, error) {
 
 	// 加载配置文件
 	redisConfig := yml_config.LoadConfig("redis")
 
 	// 查找配置文件中的 Redis 配置
 	// Local:
+	// 	Mode: "standalone"      #standalone / sentinel / cluster
 	// 	Host: "127.0.0.1:6311"
+	// 	Username: ""            #ACL 用户名
 	// 	Auth: ""
 	// 	IndexDb: 0
+	// 	MasterName: "mymaster"  #sentinel 模式
+	// 	SentinelAddrs: ["127.0.0.1:26379"]
+	// 	ClusterAddrs: ["127.0.0.1:7000"]  #cluster 模式
+	// 	TLS:
+	// 	  Enable: false
 	// 	ConnFailRetryTimes: 1    #连接失败重试次数
 	// 	ConnFailRetryInterval: 2 #连接失败重试间隔秒数
 	// 	PoolSize: 5             #连接池大小
 	// 	MinIdleConns: 2          #最小空闲连接数
 
-	if redisConfig.GetString(conn+".Host") == "" {
-		panic(fmt.Sprintf("Failed to get Redis config: %s", conn))
-	}
-
 	config := RedisConfig{
-		Host:                  redisConfig.GetString(conn + ".Host"),
-		Auth:                  redisConfig.GetString(conn + ".Auth"),
-		IndexDb:               redisConfig.GetInt(conn + ".IndexDb"),
+		Mode:             redisConfig.GetString(conn + ".Mode"),
+		Host:             redisConfig.GetString(conn + ".Host"),
+		Username:         redisConfig.GetString(conn + ".Username"),
+		Auth:             redisConfig.GetString(conn + ".Auth"),
+		IndexDb:          redisConfig.GetInt(conn + ".IndexDb"),
+		MasterName:       redisConfig.GetString(conn + ".MasterName"),
+		SentinelAddrs:    redisConfig.GetStringSlice(conn + ".SentinelAddrs"),
+		SentinelUsername: redisConfig.GetString(conn + ".SentinelUsername"),
+		SentinelAuth:     redisConfig.GetString(conn + ".SentinelAuth"),
+		ClusterAddrs:     redisConfig.GetStringSlice(conn + ".ClusterAddrs"),
+		TLS: TLSConfig{
+			Enable:             redisConfig.GetBool(conn + ".TLS.Enable"),
+			CaFile:             redisConfig.GetString(conn + ".TLS.CaFile"),
+			CertFile:           redisConfig.GetString(conn + ".TLS.CertFile"),
+			KeyFile:            redisConfig.GetString(conn + ".TLS.KeyFile"),
+			ServerName:         redisConfig.GetString(conn + ".TLS.ServerName"),
+			InsecureSkipVerify: redisConfig.GetBool(conn + ".TLS.InsecureSkipVerify"),
+		},
 		PoolSize:              redisConfig.GetInt(conn + ".PoolSize"),
 		MinIdleConns:          redisConfig.GetInt(conn + ".MinIdleConns"),
 		ConnFailRetryTimes:    redisConfig.GetInt(conn + ".ConnFailRetryTimes"),
@@ -47,5 +93,62 @@ func loadConfig(conn string) RedisConfig {
 		EventDestroyPrefix:    variable.EventDestroyPrefix + "Redis_" + conn,
 	}
 
-	return config
+	if config.Mode == "" {
+		config.Mode = ModeStandalone
+	}
+
+	switch config.Mode {
+	case ModeStandalone:
+		if config.Host == "" {
+			return RedisConfig{}, fmt.Errorf("获取 Redis 配置失败: %s", conn)
+		}
+	case ModeSentinel:
+		if config.MasterName == "" || len(config.SentinelAddrs) == 0 {
+			return RedisConfig{}, fmt.Errorf("Redis 配置 %s 缺少 MasterName 或 SentinelAddrs", conn)
+		}
+	case ModeCluster:
+		if len(config.ClusterAddrs) == 0 {
+			return RedisConfig{}, fmt.Errorf("Redis 配置 %s 缺少 ClusterAddrs", conn)
+		}
+	default:
+		return RedisConfig{}, fmt.Errorf("Redis 配置 %s 的 Mode 不支持: %s", conn, config.Mode)
+	}
+
+	return config, nil
+}
+
+// tlsConfig 按配置创建 TLS 配置，未开启时返回 nil
+func (c TLSConfig) tlsConfig() (*tls.Config, error) {
+	if !c.Enable {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CaFile != "" {
+		pem, err := os.ReadFile(c.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Redis CA 证书失败: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("解析 Redis CA 证书失败: %s", c.CaFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Redis 客户端证书失败: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
 }
@@ -0,0 +1,258 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Options 注册表参数
+type Options[T any] struct {
+	Kind     string                                    // 客户端类型，用于日志，如 "Mysql"
+	Create   func(name string) (T, error)              // 按连接名称创建客户端
+	Close    func(name string, client T) error         // 关闭客户端
+	Ping     func(ctx context.Context, client T) error // 健康检查，为 nil 时不检查
+	Replace  bool                                      // 检查失败时重新创建并替换，驱动自身会重连时（database/sql、go-redis、mongo-driver、gomemcache）不需要开启，只记录日志
+	Grace    time.Duration                             // 被替换的客户端延迟关闭的时间，默认 1 分钟
+	Interval time.Duration                             // 健康检查间隔，默认 30 秒
+	Timeout  time.Duration                             // 单次健康检查超时，默认 5 秒
+}
+
+// Registry 按连接名称管理客户端
+//
+// 首次 Get 时才创建客户端，同一名称的并发创建只会执行一次；
+// 后台定时检查已创建的客户端，开启 Replace 时失败后重新创建并原子替换。
+// 被替换的客户端可能仍有请求在使用，等待 Grace 后关闭，Close 或 Shutdown 时立即关闭；
+// 开启 Replace 时调用方不应长期持有客户端，应每次通过 Get 获取
+type Registry[T any] struct {
+	opts    Options[T]
+	clients sync.Map // 连接名称 => T
+	group   singleflight.Group
+	start   sync.Once
+	stopped sync.Once
+	stop    chan struct{}
+
+	mu      sync.Mutex
+	retired map[string][]*retired[T] // 被替换、尚未关闭的客户端
+}
+
+// retired 被替换的客户端，timer 到期后关闭
+type retired[T any] struct {
+	client T
+	timer  *time.Timer
+}
+
+// New 创建注册表
+func New[T any](opts Options[T]) *Registry[T] {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Grace <= 0 {
+		opts.Grace = time.Minute
+	}
+
+	return &Registry[T]{opts: opts, stop: make(chan struct{})}
+}
+
+// Get 获取客户端，不存在时创建
+func (r *Registry[T]) Get(name string) (T, error) {
+	if client, ok := r.clients.Load(name); ok {
+		return client.(T), nil
+	}
+
+	client, err, _ := r.group.Do(name, func() (interface{}, error) {
+		// 等待期间其他调用可能已经创建完成
+		if client, ok := r.clients.Load(name); ok {
+			return client, nil
+		}
+
+		client, err := r.opts.Create(name)
+		if err != nil {
+			return nil, err
+		}
+
+		r.clients.Store(name, client)
+		r.start.Do(func() {
+			if r.opts.Ping != nil {
+				go r.healthCheck()
+			}
+		})
+
+		return client, nil
+	})
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return client.(T), nil
+}
+
+// Load 获取已创建的客户端，不会创建
+func (r *Registry[T]) Load(name string) (T, bool) {
+	if client, ok := r.clients.Load(name); ok {
+		return client.(T), true
+	}
+
+	var zero T
+	return zero, false
+}
+
+// Range 遍历已创建的客户端
+func (r *Registry[T]) Range(fn func(name string, client T) bool) {
+	r.clients.Range(func(key, value interface{}) bool {
+		return fn(key.(string), value.(T))
+	})
+}
+
+// Close 关闭并移除客户端，包括已被替换的客户端，之后再次 Get 会重新创建
+func (r *Registry[T]) Close(name string) error {
+	r.mu.Lock()
+	list := r.retired[name]
+	delete(r.retired, name)
+	r.mu.Unlock()
+
+	for _, old := range list {
+		old.timer.Stop()
+	}
+
+	if r.opts.Close == nil {
+		r.clients.Delete(name)
+		return nil
+	}
+
+	for _, old := range list {
+		_ = r.opts.Close(name, old.client)
+	}
+
+	client, ok := r.clients.LoadAndDelete(name)
+	if !ok {
+		return nil
+	}
+	return r.opts.Close(name, client.(T))
+}
+
+// healthCheck 定时检查全部客户端
+func (r *Registry[T]) healthCheck() {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		r.clients.Range(func(key, value interface{}) bool {
+			r.check(key.(string), value.(T))
+			return true
+		})
+	}
+}
+
+// check 检查单个客户端，失败时重新创建并替换
+func (r *Registry[T]) check(name string, client T) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.Timeout)
+	err := r.opts.Ping(ctx, client)
+	cancel()
+
+	if err == nil {
+		return
+	}
+
+	if !r.opts.Replace {
+		log.Printf("%s 健康检查失败: %s, %v", r.opts.Kind, name, err)
+		return
+	}
+
+	log.Printf("%s 连接丢失，正在重新连接: %s, %v", r.opts.Kind, name, err)
+
+	// 与 Get 共用 singleflight，避免与首次创建并发
+	_, err, _ = r.group.Do(name, func() (interface{}, error) {
+		fresh, err := r.opts.Create(name)
+		if err != nil {
+			return nil, err
+		}
+
+		// 期间客户端已被 Close 时不再放回
+		if !r.clients.CompareAndSwap(name, client, fresh) {
+			if r.opts.Close != nil {
+				_ = r.opts.Close(name, fresh)
+			}
+			return nil, nil
+		}
+
+		r.retire(name, client)
+		return fresh, nil
+	})
+
+	if err != nil {
+		log.Printf("%s 重新连接失败，保留原连接: %s, %v", r.opts.Kind, name, err)
+	}
+}
+
+// retire 旧客户端上可能仍有进行中的请求，等待 Grace 后关闭
+func (r *Registry[T]) retire(name string, client T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.retired == nil {
+		r.retired = make(map[string][]*retired[T])
+	}
+
+	old := &retired[T]{client: client}
+	old.timer = time.AfterFunc(r.opts.Grace, func() {
+		// 已被 Close 取走时由 Close 负责关闭
+		r.mu.Lock()
+		list := r.retired[name]
+		found := false
+		for i, item := range list {
+			if item == old {
+				r.retired[name] = append(list[:i:i], list[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if len(r.retired[name]) == 0 {
+			delete(r.retired, name)
+		}
+		r.mu.Unlock()
+
+		if found && r.opts.Close != nil {
+			_ = r.opts.Close(name, client)
+		}
+	})
+	r.retired[name] = append(r.retired[name], old)
+}
+
+// Shutdown 停止健康检查并关闭全部客户端
+func (r *Registry[T]) Shutdown() {
+	r.stopped.Do(func() {
+		close(r.stop)
+	})
+
+	r.clients.Range(func(key, _ interface{}) bool {
+		_ = r.Close(key.(string))
+		return true
+	})
+
+	// 已被 Close 移除的连接名称下仍可能有被替换的客户端
+	r.mu.Lock()
+	names := make([]string, 0, len(r.retired))
+	for name := range r.retired {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+
+	for _, name := range names {
+		_ = r.Close(name)
+	}
+}
@@ -0,0 +1,78 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Fixture 数据文件，支持 YAML 和 JSON
+//
+//	table: h_user          # Mysql 表名或 Mongo 集合名
+//	key: [username]        # 唯一键，重复执行时按唯一键更新
+//	rows:
+//	  - username: demo
+//	    type: 1
+type Fixture struct {
+	Table string                   `json:"table" yaml:"table"`
+	Key   []string                 `json:"key" yaml:"key"`
+	Rows  []map[string]interface{} `json:"rows" yaml:"rows"`
+}
+
+// LoadFixture 加载数据文件，按扩展名识别格式
+func LoadFixture(file string) (*Fixture, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.Unmarshal(content, &fixture)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(content, &fixture)
+	default:
+		return nil, fmt.Errorf("不支持的数据文件格式: %s", file)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("解析数据文件 %s 失败: %w", file, err)
+	}
+
+	if fixture.Table == "" || len(fixture.Key) == 0 {
+		return nil, fmt.Errorf("数据文件 %s 缺少 table 或 key", file)
+	}
+
+	return &fixture, nil
+}
+
+// MysqlFixture 返回导入数据文件到 Mysql 的填充函数
+func MysqlFixture(file string) func(ctx context.Context, db *gorm.DB) error {
+	return func(ctx context.Context, db *gorm.DB) error {
+		fixture, err := LoadFixture(file)
+		if err != nil || len(fixture.Rows) == 0 {
+			return err
+		}
+		return UpsertMysql(ctx, db.Table(fixture.Table), fixture.Rows, fixture.Key)
+	}
+}
+
+// MongoFixture 返回导入数据文件到 Mongo 的填充函数，只使用 key 的第一个字段
+func MongoFixture(file string) func(ctx context.Context, db *mongoDriver.Database) error {
+	return func(ctx context.Context, db *mongoDriver.Database) error {
+		fixture, err := LoadFixture(file)
+		if err != nil {
+			return err
+		}
+		return UpsertMongo(ctx, db.Collection(fixture.Table), fixture.Key[0], fixture.Rows)
+	}
+}
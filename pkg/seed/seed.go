@@ -0,0 +1,139 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"tool/pkg/mongo"
+	"tool/pkg/mysql"
+
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownSeeder = errors.New("数据填充不存在")
+	ErrCycle         = errors.New("数据填充存在循环依赖")
+)
+
+// Seeder 数据填充，Mysql 和 Mongo 二选一
+// 填充需要是幂等的，重复执行不会产生重复数据，可使用 UpsertMysql / UpsertMongo
+type Seeder struct {
+	Name    string   // 名称，全局唯一
+	Conn    string   // 连接名称，默认 Local
+	Depends []string // 依赖的填充，会先于当前填充执行
+
+	Mysql func(ctx context.Context, db *gorm.DB) error
+	Mongo func(ctx context.Context, db *mongoDriver.Database) error
+}
+
+var (
+	mu      sync.Mutex
+	seeders = make(map[string]Seeder)
+)
+
+// Register 注册数据填充，通常在 database/seeds 包的 init 中调用
+func Register(s Seeder) {
+	if s.Conn == "" {
+		s.Conn = "Local"
+	}
+	if (s.Mysql == nil) == (s.Mongo == nil) {
+		panic(fmt.Sprintf("数据填充 %s 需要且只能设置 Mysql 或 Mongo 其中之一", s.Name))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := seeders[s.Name]; exists {
+		panic(fmt.Sprintf("数据填充重复注册: %s", s.Name))
+	}
+	seeders[s.Name] = s
+}
+
+// List 全部数据填充，按执行顺序排列
+func List() ([]Seeder, error) {
+	return resolve(nil)
+}
+
+// Run 执行数据填充，names 为空时执行全部，依赖会自动执行
+// 返回已执行的填充名称
+func Run(ctx context.Context, names ...string) ([]string, error) {
+	list, err := resolve(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for _, s := range list {
+		if s.Mysql != nil {
+			err = s.Mysql(ctx, mysql.NewClient(s.Conn).WithContext(mysql.UsePrimary(ctx)))
+		} else {
+			err = s.Mongo(ctx, mongo.NewClient(s.Conn))
+		}
+
+		if err != nil {
+			return done, fmt.Errorf("执行数据填充 %s 失败: %w", s.Name, err)
+		}
+
+		done = append(done, s.Name)
+	}
+
+	return done, nil
+}
+
+// resolve 按依赖关系排序，依赖在前；同层按名称排序，保证执行顺序稳定
+func resolve(names []string) ([]Seeder, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(names) == 0 {
+		for name := range seeders {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var (
+		list  []Seeder
+		state = make(map[string]int) // 0 未访问，1 访问中，2 已完成
+		visit func(name string, path []string) error
+	)
+
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("%w: %v", ErrCycle, append(path, name))
+		case 2:
+			return nil
+		}
+
+		s, ok := seeders[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownSeeder, name)
+		}
+
+		state[name] = 1
+
+		depends := append([]string(nil), s.Depends...)
+		sort.Strings(depends)
+		for _, depend := range depends {
+			if err := visit(depend, append(path, name)); err != nil {
+				return err
+			}
+		}
+
+		state[name] = 2
+		list = append(list, s)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
@@ -0,0 +1,80 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpsertMysql 插入数据，唯一键冲突时更新 updates 中的列
+// rows 可以是模型切片或 []map[string]interface{}（需配合 db.Table 使用）
+// updates 为空时：模型按全部字段更新，map 按除 keys 外的全部列更新
+func UpsertMysql(ctx context.Context, db *gorm.DB, rows interface{}, keys []string, updates ...string) error {
+	onConflict := clause.OnConflict{}
+	for _, key := range keys {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: key})
+	}
+
+	if maps, ok := rows.([]map[string]interface{}); ok && len(updates) == 0 {
+		updates = mapColumns(maps, keys)
+	}
+
+	if len(updates) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(updates)
+	} else {
+		onConflict.UpdateAll = true
+	}
+
+	return db.WithContext(ctx).Clauses(onConflict).Create(rows).Error
+}
+
+// UpsertMongo 按 key 字段替换文档，不存在时插入
+func UpsertMongo(ctx context.Context, coll *mongoDriver.Collection, key string, docs []map[string]interface{}) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	models := make([]mongoDriver.WriteModel, 0, len(docs))
+	for i, doc := range docs {
+		value, ok := doc[key]
+		if !ok {
+			return fmt.Errorf("第 %d 个文档缺少字段: %s", i+1, key)
+		}
+
+		models = append(models, mongoDriver.NewReplaceOneModel().
+			SetFilter(bson.M{key: value}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+
+	_, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+	return err
+}
+
+// mapColumns map 数据中除 keys 外的全部列
+func mapColumns(rows []map[string]interface{}, keys []string) []string {
+	skip := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		skip[key] = true
+	}
+
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range rows {
+		for column := range row {
+			if !skip[column] && !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	sort.Strings(columns)
+	return columns
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// AliyunDriver 阿里云 OSS 驱动
+type AliyunDriver struct {
+	bucket  *oss.Bucket
+	baseURL string
+
+	accessKeyID     string // 表单直传签名
+	accessKeySecret string
+	putBucket       *oss.Bucket // PUT 直传签名，V1 签名不包含 Content-Length，使用 V2 签名
+}
+
+// newAliyunDriver 创建阿里云 OSS 驱动
+func newAliyunDriver(config DiskConfig) (*AliyunDriver, error) {
+	// 超时为 0 时使用 SDK 默认值
+	var options []oss.ClientOption
+	if config.ConnectTimeout > 0 || config.ReadWriteTimeout > 0 {
+		options = append(options, oss.Timeout(int64(config.ConnectTimeout), int64(config.ReadWriteTimeout)))
+	}
+
+	client, err := oss.New(config.Endpoint, config.AccessKeyID, config.AccessKeySecret, options...)
+	if err != nil {
+		return nil, fmt.Errorf("创建 OSS 客户端失败: %w", err)
+	}
+
+	bucket, err := client.Bucket(config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("获取 OSS 存储桶失败: %w", err)
+	}
+
+	// 只用于生成签名地址，不发送请求
+	putClient, err := oss.New(config.Endpoint, config.AccessKeyID, config.AccessKeySecret,
+		oss.AuthVersion(oss.AuthV2), oss.AdditionalHeaders([]string{"content-length"}))
+	if err != nil {
+		return nil, fmt.Errorf("创建 OSS 客户端失败: %w", err)
+	}
+	putBucket, err := putClient.Bucket(config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("获取 OSS 存储桶失败: %w", err)
+	}
+
+	return &AliyunDriver{
+		bucket:          bucket,
+		baseURL:         config.BaseURL,
+		accessKeyID:     config.AccessKeyID,
+		accessKeySecret: config.AccessKeySecret,
+		putBucket:       putBucket,
+	}, nil
+}
+
+// Put 上传对象
+func (d *AliyunDriver) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = contentTypeOf(key)
+	}
+
+	options := []oss.Option{oss.WithContext(ctx), oss.ContentType(contentType)}
+	if opts.Size >= 0 {
+		options = append(options, oss.ContentLength(opts.Size))
+	}
+	if opts.CacheControl != "" {
+		options = append(options, oss.CacheControl(opts.CacheControl))
+	}
+
+	if err := d.bucket.PutObject(key, r, options...); err != nil {
+		return ObjectInfo{}, aliyunError(err)
+	}
+
+	return d.Stat(ctx, key)
+}
+
+// Get 下载对象
+func (d *AliyunDriver) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	result, err := d.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: key}, []oss.Option{oss.WithContext(ctx)})
+	if err != nil {
+		return nil, ObjectInfo{}, aliyunError(err)
+	}
+	return result.Response.Body, headerInfo(key, result.Response.Headers), nil
+}
+
+// Delete 删除对象，OSS 删除不存在的对象也返回成功
+func (d *AliyunDriver) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	return aliyunError(d.bucket.DeleteObject(key, oss.WithContext(ctx)))
+}
+
+// Stat 获取对象元信息
+func (d *AliyunDriver) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	header, err := d.bucket.GetObjectDetailedMeta(key, oss.WithContext(ctx))
+	if err != nil {
+		return ObjectInfo{}, aliyunError(err)
+	}
+	return headerInfo(key, header), nil
+}
+
+// List ListObjectsV2，游标为 ContinuationToken
+func (d *AliyunDriver) List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error) {
+	options := []oss.Option{
+		oss.WithContext(ctx),
+		oss.Prefix(strings.TrimPrefix(prefix, "/")),
+		oss.MaxKeys(opts.limit()),
+	}
+	if opts.Cursor != "" {
+		options = append(options, oss.ContinuationToken(opts.Cursor))
+	}
+
+	list, err := d.bucket.ListObjectsV2(options...)
+	if err != nil {
+		return ListResult{}, aliyunError(err)
+	}
+
+	result := ListResult{Objects: make([]ObjectInfo, 0, len(list.Objects))}
+	for _, item := range list.Objects {
+		result.Objects = append(result.Objects, ObjectInfo{
+			Key:         item.Key,
+			Size:        item.Size,
+			ContentType: contentTypeOf(item.Key),
+			ETag:        strings.Trim(item.ETag, `"`),
+			ModTime:     item.LastModified,
+		})
+	}
+	if list.IsTruncated {
+		result.Next = list.NextContinuationToken
+	}
+	return result, nil
+}
+
+// SignedURL 签名地址
+func (d *AliyunDriver) SignedURL(ctx context.Context, key string, opts SignOptions) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	opts = opts.normalize()
+
+	var options []oss.Option
+	if opts.ContentType != "" {
+		options = append(options, oss.ContentType(opts.ContentType))
+	}
+
+	url, err := d.bucket.SignURL(key, oss.HTTPMethod(opts.Method), int64(opts.Expires/time.Second), options...)
+	if err != nil {
+		return "", aliyunError(err)
+	}
+	return url, nil
+}
+
+// URL 公开访问地址
+func (d *AliyunDriver) URL(key string) string {
+	if d.baseURL == "" {
+		return ""
+	}
+	return d.baseURL + "/" + escapePath(key)
+}
+
+// aliyunError 对象不存在时返回 ErrNotFound
+func aliyunError(err error) error {
+	var serviceErr oss.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound && serviceErr.Code != "NoSuchBucket" {
+		return ErrNotFound
+	}
+	return err
+}
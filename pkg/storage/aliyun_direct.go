@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// 阿里云 OSS 表单上传和上传回调
+// https://help.aliyun.com/zh/oss/developer-reference/postobject
+// https://help.aliyun.com/zh/oss/developer-reference/callback
+
+// aliyunCallbackBody 回调的请求体，变量由 OSS 替换
+const aliyunCallbackBody = `{"bucket":${bucket},"key":${object},"etag":${etag},"size":${size},"content_type":${mimeType}}`
+
+// aliyunPublicKeyHosts 回调签名公钥只能从官方地址下载，防止伪造请求指定自己的公钥
+var aliyunPublicKeyHosts = []string{"http://gosspublic.alicdn.com/", "https://gosspublic.alicdn.com/"}
+
+// aliyunPublicKeys 按地址缓存的回调签名公钥
+var aliyunPublicKeys sync.Map
+
+// PresignUpload POST 时生成表单上传的 policy，PUT 时生成签名地址；配置了 CallbackURL 时上传成功后 OSS 会回调
+func (d *AliyunDriver) PresignUpload(ctx context.Context, key string, policy UploadPolicy) (PresignedUpload, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+
+	policy = policy.normalize()
+	expiresAt := time.Now().Add(policy.Expires)
+
+	var callback string
+	if policy.CallbackURL != "" {
+		document, err := json.Marshal(map[string]string{
+			"callbackUrl":      policy.CallbackURL,
+			"callbackBody":     aliyunCallbackBody,
+			"callbackBodyType": "application/json",
+		})
+		if err != nil {
+			return PresignedUpload{}, err
+		}
+		callback = base64.StdEncoding.EncodeToString(document)
+	}
+
+	if policy.Method == http.MethodPut {
+		if policy.Size <= 0 {
+			return PresignedUpload{}, ErrSizeRequired
+		}
+
+		// 签名 Content-Length，上传的大小必须一致
+		headers := map[string]string{"Content-Length": strconv.FormatInt(policy.Size, 10)}
+		options := []oss.Option{oss.ContentLength(policy.Size)}
+		if policy.ContentType != "" {
+			headers["Content-Type"] = policy.ContentType
+			options = append(options, oss.ContentType(policy.ContentType))
+		}
+		if callback != "" {
+			headers["x-oss-callback"] = callback
+			options = append(options, oss.Callback(callback))
+		}
+
+		signed, err := d.putBucket.SignURL(key, oss.HTTPPut, int64(policy.Expires/time.Second), options...)
+		if err != nil {
+			return PresignedUpload{}, aliyunError(err)
+		}
+		return PresignedUpload{Method: http.MethodPut, URL: signed, Headers: headers, ExpiresAt: expiresAt}, nil
+	}
+
+	fields := map[string]string{
+		"key":                   key,
+		"success_action_status": "200",
+	}
+
+	conditions := []any{map[string]string{"bucket": d.bucket.BucketName}}
+	for name, value := range fields {
+		conditions = append(conditions, []string{"eq", "$" + name, value})
+	}
+	if policy.ContentType != "" {
+		fields["Content-Type"] = policy.ContentType
+		conditions = append(conditions, []string{"eq", "$Content-Type", policy.ContentType})
+	}
+	if policy.MaxSize > 0 {
+		conditions = append(conditions, []any{"content-length-range", 0, policy.MaxSize})
+	}
+	if callback != "" {
+		fields["callback"] = callback
+	}
+
+	document, err := json.Marshal(map[string]any{
+		"expiration": expiresAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(document)
+	mac := hmac.New(sha1.New, []byte(d.accessKeySecret))
+	mac.Write([]byte(encoded))
+
+	fields["OSSAccessKeyId"] = d.accessKeyID
+	fields["policy"] = encoded
+	fields["Signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return PresignedUpload{
+		Method:    http.MethodPost,
+		URL:       d.bucketURL(),
+		Fields:    fields,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// bucketURL 存储桶地址
+func (d *AliyunDriver) bucketURL() string {
+	endpoint := d.bucket.Client.Config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return u.Scheme + "://" + d.bucket.BucketName + "." + u.Host
+}
+
+// aliyunCallback 回调的请求体
+type aliyunCallback struct {
+	Bucket      string      `json:"bucket"`
+	Key         string      `json:"key"`
+	ETag        string      `json:"etag"`
+	Size        json.Number `json:"size"`
+	ContentType string      `json:"content_type"`
+}
+
+// VerifyNotification 校验 OSS 上传回调的 RSA 签名，签名内容为 "路径?查询参数\n请求体"，
+// 公钥从 x-oss-pub-key-url 指定的官方地址下载
+func (d *AliyunDriver) VerifyNotification(ctx context.Context, r *http.Request, body []byte) ([]ObjectInfo, error) {
+	signature, err := base64.StdEncoding.DecodeString(r.Header.Get("Authorization"))
+	if err != nil || len(signature) == 0 {
+		return nil, ErrInvalidNotification
+	}
+
+	keyURL, err := base64.StdEncoding.DecodeString(r.Header.Get("X-Oss-Pub-Key-Url"))
+	if err != nil {
+		return nil, ErrInvalidNotification
+	}
+	publicKey, err := aliyunPublicKey(ctx, string(keyURL))
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := url.PathUnescape(r.URL.EscapedPath())
+	if err != nil {
+		return nil, ErrInvalidNotification
+	}
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+
+	digest := md5.Sum(append([]byte(path+"\n"), body...))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.MD5, digest[:], signature); err != nil {
+		return nil, ErrInvalidNotification
+	}
+
+	var callback aliyunCallback
+	if err := json.Unmarshal(body, &callback); err != nil || callback.Bucket != d.bucket.BucketName {
+		return nil, ErrInvalidNotification
+	}
+	size, _ := strconv.ParseInt(callback.Size.String(), 10, 64)
+
+	return []ObjectInfo{{
+		Key:         callback.Key,
+		Size:        size,
+		ContentType: callback.ContentType,
+		ETag:        strings.Trim(callback.ETag, `"`),
+	}}, nil
+}
+
+// aliyunPublicKey 下载并缓存回调签名公钥
+func aliyunPublicKey(ctx context.Context, keyURL string) (*rsa.PublicKey, error) {
+	allowed := false
+	for _, prefix := range aliyunPublicKeyHosts {
+		if strings.HasPrefix(keyURL, prefix) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, ErrInvalidNotification
+	}
+
+	if key, ok := aliyunPublicKeys.Load(keyURL); ok {
+		return key.(*rsa.PublicKey), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, keyURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载 OSS 回调公钥失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载 OSS 回调公钥失败: %s", resp.Status)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("OSS 回调公钥格式错误")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("OSS 回调公钥格式错误: %w", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("OSS 回调公钥格式错误")
+	}
+
+	aliyunPublicKeys.Store(keyURL, key)
+	return key, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// imur SDK 使用的分片上传标识
+func (d *AliyunDriver) imur(key, uploadID string) oss.InitiateMultipartUploadResult {
+	return oss.InitiateMultipartUploadResult{Bucket: d.bucket.BucketName, Key: key, UploadID: uploadID}
+}
+
+// InitMultipart 创建分片上传
+func (d *AliyunDriver) InitMultipart(ctx context.Context, key string, opts PutOptions) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = contentTypeOf(key)
+	}
+
+	options := []oss.Option{oss.WithContext(ctx), oss.ContentType(contentType)}
+	if opts.CacheControl != "" {
+		options = append(options, oss.CacheControl(opts.CacheControl))
+	}
+
+	result, err := d.bucket.InitiateMultipartUpload(key, options...)
+	if err != nil {
+		return "", aliyunError(err)
+	}
+	return result.UploadID, nil
+}
+
+// UploadPart 上传分片
+func (d *AliyunDriver) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return Part{}, err
+	}
+
+	part, err := d.bucket.UploadPart(d.imur(key, uploadID), r, size, number, oss.WithContext(ctx))
+	if err != nil {
+		return Part{}, aliyunError(err)
+	}
+	return Part{Number: part.PartNumber, ETag: part.ETag, Size: size}, nil
+}
+
+// CompleteMultipart 合并分片
+func (d *AliyunDriver) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	uploaded := make([]oss.UploadPart, 0, len(parts))
+	for _, part := range parts {
+		uploaded = append(uploaded, oss.UploadPart{PartNumber: part.Number, ETag: part.ETag})
+	}
+
+	if _, err := d.bucket.CompleteMultipartUpload(d.imur(key, uploadID), uploaded, oss.WithContext(ctx)); err != nil {
+		return ObjectInfo{}, aliyunError(err)
+	}
+	return d.Stat(ctx, key)
+}
+
+// AbortMultipart 取消分片上传
+func (d *AliyunDriver) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	err = aliyunError(d.bucket.AbortMultipartUpload(d.imur(key, uploadID), oss.WithContext(ctx)))
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
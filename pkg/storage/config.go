@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"tool/global/variable"
+)
+
+// DiskConfig 磁盘配置，不同驱动使用其中的部分字段
+type DiskConfig struct {
+	Name             string // 磁盘名称
+	Driver           string // local / s3 / aliyun，默认 aliyun，兼容旧配置
+	Dir              string // 上传目录
+	BaseURL          string // 公开访问地址前缀
+	Root             string // local: 存储根目录，相对路径基于项目根目录
+	Secret           string // local: SignedURL 签名密钥
+	Endpoint         string // s3 / aliyun: 服务地址
+	Region           string // s3: 区域，默认 us-east-1
+	AccessKeyID      string // s3 / aliyun
+	AccessKeySecret  string // s3 / aliyun
+	Bucket           string // s3 / aliyun: 存储桶
+	PathStyle        bool   // s3: 使用路径形式访问存储桶，MinIO 需要开启
+	NotifyToken      string // s3: 上传通知（MinIO webhook 的 auth_token）的 Bearer Token
+	ConnectTimeout   int    // 连接超时(秒)
+	ReadWriteTimeout int    // 读写超时(秒)
+}
+
+// loadConfig 加载磁盘配置
+//
+//	Oss:
+//	  Local:
+//	    Driver: "local"
+//	    Root: "storage/app"
+//	    BaseURL: "/files/Local"
+//	    Secret: "xxx"
+//	    Dir: "uploads"
+//	  Minio:
+//	    Driver: "s3"
+//	    Endpoint: "http://127.0.0.1:9000"
+//	    Region: "us-east-1"
+//	    AccessKeyId: "xxx"
+//	    AccessKeySecret: "xxx"
+//	    BucketName: "xxx"
+//	    PathStyle: true
+//	    NotifyToken: "xxx"
+//	    Dir: "uploads"
+func loadConfig(name string) (DiskConfig, error) {
+	prefix := "Oss." + name + "."
+
+	config := DiskConfig{
+		Name:             name,
+		Driver:           strings.ToLower(variable.ConfigYml.GetString(prefix + "Driver")),
+		Dir:              strings.Trim(variable.ConfigYml.GetString(prefix+"Dir"), "/"),
+		BaseURL:          strings.TrimSuffix(variable.ConfigYml.GetString(prefix+"BaseURL"), "/"),
+		Root:             variable.ConfigYml.GetString(prefix + "Root"),
+		Secret:           variable.ConfigYml.GetString(prefix + "Secret"),
+		Endpoint:         variable.ConfigYml.GetString(prefix + "Endpoint"),
+		Region:           variable.ConfigYml.GetString(prefix + "Region"),
+		AccessKeyID:      variable.ConfigYml.GetString(prefix + "AccessKeyId"),
+		AccessKeySecret:  variable.ConfigYml.GetString(prefix + "AccessKeySecret"),
+		Bucket:           variable.ConfigYml.GetString(prefix + "BucketName"),
+		PathStyle:        variable.ConfigYml.GetBool(prefix + "PathStyle"),
+		NotifyToken:      variable.ConfigYml.GetString(prefix + "NotifyToken"),
+		ConnectTimeout:   variable.ConfigYml.GetInt(prefix + "ConnectTimeout"),
+		ReadWriteTimeout: variable.ConfigYml.GetInt(prefix + "ReadWriteTimeout"),
+	}
+
+	if config.Driver == "" {
+		config.Driver = "aliyun"
+	}
+
+	switch config.Driver {
+	case "local":
+		if config.Root == "" {
+			return config, fmt.Errorf("获取存储配置失败: %s, 缺少 Root", name)
+		}
+	case "s3", "aliyun":
+		if config.Endpoint == "" || config.Bucket == "" {
+			return config, fmt.Errorf("获取存储配置失败: %s, 缺少 Endpoint 或 BucketName", name)
+		}
+	default:
+		return config, fmt.Errorf("不支持的存储驱动: %s, %s", name, config.Driver)
+	}
+
+	return config, nil
+}
+
+// DiskDir 磁盘配置的上传目录
+func DiskDir(name string) string {
+	return strings.Trim(variable.ConfigYml.GetString("Oss."+name+".Dir"), "/")
+}
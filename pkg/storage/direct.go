@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidNotification = errors.New("storage: invalid upload notification")
+	ErrSizeRequired        = errors.New("storage: size is required for PUT uploads")
+)
+
+// DirectUploader 客户端直传到存储，内容不经过应用服务，驱动可选实现
+type DirectUploader interface {
+	// PresignUpload 生成直传请求，key 为对象的完整 key
+	PresignUpload(ctx context.Context, key string, policy UploadPolicy) (PresignedUpload, error)
+}
+
+// Notifier 校验存储服务的上传通知，驱动可选实现
+type Notifier interface {
+	// VerifyNotification 校验签名并返回通知中已上传的对象，body 为请求体，校验失败时返回 ErrInvalidNotification
+	VerifyNotification(ctx context.Context, r *http.Request, body []byte) ([]ObjectInfo, error)
+}
+
+// UploadPolicy 直传限制
+type UploadPolicy struct {
+	Method      string        // POST（默认）表单上传，可以限制大小范围；PUT 请求体为文件内容
+	Size        int64         // PUT 时的文件大小，必须大于 0，签名 Content-Length
+	MaxSize     int64         // POST 时的最大字节数，0 表示不限制
+	ContentType string        // 上传时必须使用的 Content-Type，为空时不限制
+	Expires     time.Duration // 有效期，默认 15 分钟
+	CallbackURL string        // 上传成功后存储服务回调的地址，驱动支持时使用（阿里云 OSS）
+}
+
+// normalize 填充默认值
+func (p UploadPolicy) normalize() UploadPolicy {
+	if p.Method == "" {
+		p.Method = http.MethodPost
+	}
+	if p.Expires <= 0 {
+		p.Expires = defaultSignExpires
+	}
+	return p
+}
+
+// PresignedUpload 直传请求
+//
+// Method 为 POST 时以 multipart/form-data 提交，先写入 Fields 中的字段，最后是 file 字段；
+// 为 PUT 时请求体为文件内容，需要带上 Headers 中的请求头
+type PresignedUpload struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tool/global/variable"
+)
+
+// LocalDriver 本地文件系统驱动，SignedURL 使用 HMAC 签名，由 Verify 校验
+type LocalDriver struct {
+	root    string
+	baseURL string
+	secret  string
+}
+
+// newLocalDriver 创建本地驱动，根目录不存在时创建
+func newLocalDriver(config DiskConfig) (*LocalDriver, error) {
+	root := config.Root
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(variable.BasePath, root)
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+
+	return &LocalDriver{root: root, baseURL: config.BaseURL, secret: config.Secret}, nil
+}
+
+// path 对象在磁盘上的路径，分片目录不能作为对象访问
+func (d *LocalDriver) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if key == multipartDir || strings.HasPrefix(key, multipartDir+"/") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+// Put 先写入同目录的临时文件再重命名，读取方不会看到写了一半的文件
+func (d *LocalDriver) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	name, err := d.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return ObjectInfo{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return d.Stat(ctx, key)
+}
+
+// Get 打开文件
+func (d *LocalDriver) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	name, err := d.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, ObjectInfo{}, localError(err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, ObjectInfo{}, err
+	}
+	if stat.IsDir() {
+		file.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	return file, d.info(key, stat), nil
+}
+
+// Delete 删除文件
+func (d *LocalDriver) Delete(ctx context.Context, key string) error {
+	name, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Stat 文件信息
+func (d *LocalDriver) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	name, err := d.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	stat, err := os.Stat(name)
+	if err != nil {
+		return ObjectInfo{}, localError(err)
+	}
+	if stat.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	return d.info(key, stat), nil
+}
+
+// List 遍历前缀所在目录，游标为上一页最后一个 key
+func (d *LocalDriver) List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error) {
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	// 从前缀中最后一个 "/" 之前的目录开始遍历
+	dir := d.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		sub, err := d.path(prefix[:i])
+		if err != nil {
+			return ListResult{}, err
+		}
+		dir = sub
+	}
+
+	limit := opts.limit()
+	var objects []ObjectInfo
+
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == multipartDir && filepath.Dir(name) == d.root {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) || key <= opts.Cursor {
+			return nil
+		}
+
+		stat, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		objects = append(objects, d.info(key, stat))
+		return nil
+	})
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	// WalkDir 按文件名排序，"a/b" 和 "a-b" 的先后与 key 的字符串顺序不同
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	result := ListResult{Objects: objects}
+	if len(objects) > limit {
+		result.Objects = objects[:limit]
+		result.Next = objects[limit-1].Key
+	}
+	return result, nil
+}
+
+// SignedURL 在 URL 后附加过期时间和签名，需要配置 Secret 和 BaseURL
+func (d *LocalDriver) SignedURL(ctx context.Context, key string, opts SignOptions) (string, error) {
+	if d.secret == "" || d.baseURL == "" {
+		return "", ErrNotSupported
+	}
+
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	opts = opts.normalize()
+	expires := strconv.FormatInt(time.Now().Add(opts.Expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", d.sign(opts.Method, key, expires))
+
+	return d.URL(key) + "?" + query.Encode(), nil
+}
+
+// Verify 校验 SignedURL 的签名和有效期
+func (d *LocalDriver) Verify(method, key string, query url.Values) error {
+	if d.secret == "" {
+		return ErrNotSupported
+	}
+
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return errors.New("storage: signed url expired")
+	}
+
+	expected := d.sign(strings.ToUpper(method), key, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return errors.New("storage: invalid signature")
+	}
+	return nil
+}
+
+// URL 公开访问地址
+func (d *LocalDriver) URL(key string) string {
+	if d.baseURL == "" {
+		return ""
+	}
+	return d.baseURL + "/" + escapePath(key)
+}
+
+// Root 存储根目录
+func (d *LocalDriver) Root() string {
+	return d.root
+}
+
+// sign 签名内容为 "方法\nkey\n过期时间"
+func (d *LocalDriver) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, []byte(d.secret))
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// info 文件信息，ETag 由修改时间和大小生成
+func (d *LocalDriver) info(key string, stat fs.FileInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: contentTypeOf(key),
+		ETag:        fmt.Sprintf("%x-%x", stat.ModTime().UnixNano(), stat.Size()),
+		ModTime:     stat.ModTime(),
+	}
+}
+
+// localError 转换文件不存在的错误
+func localError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// contentTypeOf 按扩展名推断 Content-Type
+func contentTypeOf(key string) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// escapePath 逐段转义 key，保留 "/"
+func escapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// contextReader 读取时检查 ctx，请求取消后停止写入
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
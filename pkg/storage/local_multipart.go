@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// multipartDir 本地分片的保存目录，位于根目录下，List 时跳过
+const multipartDir = ".multipart"
+
+// partDir 分片上传的目录，上传 ID 只能是十六进制，避免跳出目录
+func (d *LocalDriver) partDir(uploadID string) (string, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return "", errors.New("storage: invalid upload id")
+	}
+	return filepath.Join(d.root, multipartDir, uploadID), nil
+}
+
+// InitMultipart 创建分片目录
+func (d *LocalDriver) InitMultipart(ctx context.Context, key string, opts PutOptions) (string, error) {
+	if _, err := cleanKey(key); err != nil {
+		return "", err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id)
+
+	dir, _ := d.partDir(uploadID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// UploadPart 分片保存为以分片号命名的文件
+func (d *LocalDriver) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	dir, err := d.partDir(uploadID)
+	if err != nil {
+		return Part{}, err
+	}
+	if number < 1 || number > MaxParts {
+		return Part{}, fmt.Errorf("storage: invalid part number %d", number)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return Part{}, localError(err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		return Part{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Part{}, err
+	}
+	if size >= 0 && written != size {
+		return Part{}, fmt.Errorf("storage: part %d size mismatch, want %d got %d", number, size, written)
+	}
+
+	name := filepath.Join(dir, strconv.Itoa(number))
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return Part{}, err
+	}
+
+	stat, err := os.Stat(name)
+	if err != nil {
+		return Part{}, err
+	}
+	return Part{Number: number, ETag: fmt.Sprintf("%x-%x", stat.ModTime().UnixNano(), written), Size: written}, nil
+}
+
+// CompleteMultipart 按分片号顺序拼接分片，完成后删除分片目录
+func (d *LocalDriver) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) (ObjectInfo, error) {
+	dir, err := d.partDir(uploadID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	sorted := append([]Part(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+
+	readers := make([]io.Reader, 0, len(sorted))
+	for _, part := range sorted {
+		file, err := os.Open(filepath.Join(dir, strconv.Itoa(part.Number)))
+		if err != nil {
+			return ObjectInfo{}, localError(err)
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+
+	info, err := d.Put(ctx, key, io.MultiReader(readers...), PutOptions{Size: -1})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	_ = os.RemoveAll(dir)
+	return info, nil
+}
+
+// AbortMultipart 删除分片目录
+func (d *LocalDriver) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	dir, err := d.partDir(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
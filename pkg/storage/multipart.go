@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+// 分片大小，S3 要求除最后一片外不小于 5MB
+const (
+	DefaultPartSize = 8 << 20
+	MinPartSize     = 5 << 20
+	MaxParts        = 10000
+)
+
+// Multipart 分片上传，驱动可选实现
+type Multipart interface {
+	// InitMultipart 创建分片上传，返回上传 ID
+	InitMultipart(ctx context.Context, key string, opts PutOptions) (string, error)
+	// UploadPart 上传分片，number 从 1 开始，同一分片重复上传时覆盖
+	UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error)
+	// CompleteMultipart 按分片号顺序合并分片
+	CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) (ObjectInfo, error)
+	// AbortMultipart 取消分片上传并删除已上传的分片
+	AbortMultipart(ctx context.Context, key, uploadID string) error
+}
+
+// Part 已上传的分片
+type Part struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// PutStream 流式写入，内容不超过一个分片时直接 Put，否则驱动支持时按分片上传，失败时取消分片上传
+// 长度未知时也不会写入临时文件，内存中最多保留一个分片；本地磁盘的 Put 本身就是流式写入，直接 Put
+func PutStream(ctx context.Context, d Driver, key string, r io.Reader, opts PutOptions, partSize int64) (ObjectInfo, error) {
+	if partSize < MinPartSize {
+		partSize = DefaultPartSize
+	}
+
+	multipart, ok := d.(Multipart)
+	if _, local := d.(*LocalDriver); !ok || local || (opts.Size >= 0 && opts.Size <= partSize) {
+		return d.Put(ctx, key, r, opts)
+	}
+
+	// 先读取一个分片，不足一个分片时直接 Put；缓冲区按读取的内容增长，小文件不会分配整个分片
+	var first bytes.Buffer
+	if opts.Size > partSize {
+		first.Grow(int(partSize))
+	}
+	n, err := io.CopyN(&first, r, partSize)
+	if err == io.EOF {
+		opts.Size = n
+		return d.Put(ctx, key, &first, opts)
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	buf := first.Bytes()
+
+	uploadID, err := multipart.InitMultipart(ctx, key, opts)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	parts, err := uploadParts(ctx, multipart, key, uploadID, r, buf)
+	if err != nil {
+		// 请求已取消时仍然需要清理分片
+		_ = multipart.AbortMultipart(context.WithoutCancel(ctx), key, uploadID)
+		return ObjectInfo{}, err
+	}
+
+	info, err := multipart.CompleteMultipart(ctx, key, uploadID, parts)
+	if err != nil {
+		_ = multipart.AbortMultipart(context.WithoutCancel(ctx), key, uploadID)
+		return ObjectInfo{}, err
+	}
+	return info, nil
+}
+
+// uploadParts 依次上传分片，buf 中已经读取了第一个完整的分片
+func uploadParts(ctx context.Context, multipart Multipart, key, uploadID string, r io.Reader, buf []byte) ([]Part, error) {
+	var parts []Part
+	n := len(buf)
+
+	for number := 1; ; number++ {
+		if number > MaxParts {
+			return nil, errors.New("storage: too many parts")
+		}
+
+		part, err := multipart.UploadPart(ctx, key, uploadID, number, bytes.NewReader(buf[:n]), int64(n))
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+
+		n, err = io.ReadFull(r, buf)
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// recordDriver 记录调用的驱动，Put 和分片都写入内存
+type recordDriver struct {
+	Driver
+	puts  int
+	parts []int64
+	data  bytes.Buffer
+}
+
+func (d *recordDriver) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	d.puts++
+	n, err := d.data.ReadFrom(r)
+	return ObjectInfo{Key: key, Size: n}, err
+}
+
+func (d *recordDriver) InitMultipart(ctx context.Context, key string, opts PutOptions) (string, error) {
+	return "upload", nil
+}
+
+func (d *recordDriver) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	n, err := d.data.ReadFrom(r)
+	d.parts = append(d.parts, n)
+	return Part{Number: number, Size: n}, err
+}
+
+func (d *recordDriver) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) (ObjectInfo, error) {
+	return ObjectInfo{Key: key, Size: int64(d.data.Len())}, nil
+}
+
+func (d *recordDriver) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	return nil
+}
+
+func TestPutStream(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 2*MinPartSize+10)
+
+	tests := []struct {
+		name  string
+		size  int64
+		data  []byte
+		puts  int
+		parts []int64
+	}{
+		{"small unknown size", -1, content[:10], 1, nil},
+		{"one part exactly", -1, content[:MinPartSize], 0, []int64{MinPartSize}},
+		{"unknown size", -1, content, 0, []int64{MinPartSize, MinPartSize, 10}},
+		{"known size", int64(len(content)), content, 0, []int64{MinPartSize, MinPartSize, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &recordDriver{}
+			info, err := PutStream(context.Background(), d, "a.bin", bytes.NewReader(tt.data), PutOptions{Size: tt.size}, MinPartSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size != int64(len(tt.data)) || !bytes.Equal(d.data.Bytes(), tt.data) {
+				t.Fatalf("size = %d, want %d", info.Size, len(tt.data))
+			}
+			if d.puts != tt.puts || len(d.parts) != len(tt.parts) {
+				t.Fatalf("puts = %d, parts = %v, want %d, %v", d.puts, d.parts, tt.puts, tt.parts)
+			}
+			for i := range tt.parts {
+				if d.parts[i] != tt.parts[i] {
+					t.Fatalf("parts = %v, want %v", d.parts, tt.parts)
+				}
+			}
+		})
+	}
+}
+
+func TestPutStreamLocal(t *testing.T) {
+	d, err := newLocalDriver(DiskConfig{Root: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 本地磁盘直接写入，不经过分片目录
+	content := bytes.Repeat([]byte("x"), MinPartSize+10)
+	info, err := PutStream(context.Background(), d, "a.bin", bytes.NewReader(content), PutOptions{Size: -1}, MinPartSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Fatalf("size = %d, want %d", info.Size, len(content))
+	}
+	if _, err := os.Stat(filepath.Join(d.root, multipartDir)); !os.IsNotExist(err) {
+		t.Fatalf("multipart dir exists: %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Driver S3 兼容驱动，直接使用 REST API 和 SigV4 签名，可以用 MinIO 测试
+type S3Driver struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	baseURL   string
+	signer    signer
+	client    *http.Client
+
+	notifyToken string // 上传通知的 Bearer Token
+}
+
+// newS3Driver 创建 S3 兼容驱动
+func newS3Driver(config DiskConfig) (*S3Driver, error) {
+	endpoint := config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("存储 Endpoint 格式错误: %w", err)
+	}
+
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: time.Duration(config.ConnectTimeout) * time.Second}).DialContext
+	}
+	if config.ReadWriteTimeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(config.ReadWriteTimeout) * time.Second
+	}
+
+	return &S3Driver{
+		endpoint:  u,
+		bucket:    config.Bucket,
+		pathStyle: config.PathStyle,
+		baseURL:   config.BaseURL,
+		signer: signer{
+			accessKey: config.AccessKeyID,
+			secretKey: config.AccessKeySecret,
+			region:    region,
+			service:   "s3",
+		},
+		client:      &http.Client{Transport: transport},
+		notifyToken: config.NotifyToken,
+	}, nil
+}
+
+// objectURL 对象地址，key 为空时为存储桶地址
+func (d *S3Driver) objectURL(key string, query url.Values) *url.URL {
+	u := *d.endpoint
+
+	p := "/" + key
+	if d.pathStyle {
+		p = "/" + d.bucket + "/" + key
+	} else {
+		u.Host = d.bucket + "." + u.Host
+	}
+
+	u.Path = p
+	u.RawPath = uriEncode(p, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do 签名并发送请求，body 不为 nil 时需要提供 size
+func (d *S3Driver) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = unsignedPayload
+	}
+	d.signer.signRequest(req, payloadHash, time.Now())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, s3Error(resp)
+	}
+	return resp, nil
+}
+
+// Put 上传对象，S3 不支持分块传输编码，大小未知时按分片上传
+func (d *S3Driver) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if opts.Size < 0 {
+		return PutStream(ctx, d, key, r, opts, DefaultPartSize)
+	}
+
+	header := putHeader(key, opts)
+
+	// 不关闭调用方的 Reader；长度为 0 时 http.Request 会把 body 视为未知长度，改为 http.NoBody
+	body := io.NopCloser(r)
+	if opts.Size == 0 {
+		body = http.NoBody
+	}
+
+	resp, err := d.do(ctx, http.MethodPut, key, nil, header, body, opts.Size)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+
+	return ObjectInfo{
+		Key:         key,
+		Size:        opts.Size,
+		ContentType: header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+		ModTime:     time.Now(),
+	}, nil
+}
+
+// Get 下载对象
+func (d *S3Driver) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	resp, err := d.do(ctx, http.MethodGet, key, nil, nil, nil, 0)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return resp.Body, headerInfo(key, resp.Header), nil
+}
+
+// Delete 删除对象，S3 删除不存在的对象也返回成功
+func (d *S3Driver) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.do(ctx, http.MethodDelete, key, nil, nil, nil, 0)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Stat 通过 HEAD 获取对象信息
+func (d *S3Driver) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	resp, err := d.do(ctx, http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	return headerInfo(key, resp.Header), nil
+}
+
+// listResult ListObjectsV2 响应
+type listResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List ListObjectsV2，游标为 ContinuationToken
+func (d *S3Driver) List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error) {
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("prefix", strings.TrimPrefix(prefix, "/"))
+	query.Set("max-keys", strconv.Itoa(opts.limit()))
+	if opts.Cursor != "" {
+		query.Set("continuation-token", opts.Cursor)
+	}
+
+	resp, err := d.do(ctx, http.MethodGet, "", query, nil, nil, 0)
+	if err != nil {
+		return ListResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var list listResult
+	if err := xml.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return ListResult{}, fmt.Errorf("解析对象列表失败: %w", err)
+	}
+
+	result := ListResult{Objects: make([]ObjectInfo, 0, len(list.Contents))}
+	for _, item := range list.Contents {
+		result.Objects = append(result.Objects, ObjectInfo{
+			Key:         item.Key,
+			Size:        item.Size,
+			ContentType: contentTypeOf(item.Key),
+			ETag:        strings.Trim(item.ETag, `"`),
+			ModTime:     item.LastModified,
+		})
+	}
+	if list.IsTruncated {
+		result.Next = list.NextContinuationToken
+	}
+	return result, nil
+}
+
+// SignedURL 查询参数签名的地址，最长 7 天
+func (d *S3Driver) SignedURL(ctx context.Context, key string, opts SignOptions) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	opts = opts.normalize()
+	if opts.Expires > 7*24*time.Hour {
+		opts.Expires = 7 * 24 * time.Hour
+	}
+
+	// 指定了类型时签名 Content-Type，上传时必须使用相同的类型
+	var headers map[string]string
+	if opts.ContentType != "" {
+		headers = map[string]string{"content-type": opts.ContentType}
+	}
+
+	return d.signer.presign(opts.Method, d.objectURL(key, nil), opts.Expires, time.Now(), headers), nil
+}
+
+// URL 公开访问地址
+func (d *S3Driver) URL(key string) string {
+	if d.baseURL == "" {
+		return ""
+	}
+	return d.baseURL + "/" + escapePath(key)
+}
+
+// s3ErrorBody 错误响应
+type s3ErrorBody struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// s3Error 转换错误响应，对象不存在时返回 ErrNotFound，HEAD 请求没有响应体
+func s3Error(resp *http.Response) error {
+	var body s3ErrorBody
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = xml.Unmarshal(data, &body)
+
+	if resp.StatusCode == http.StatusNotFound && (body.Code == "" || body.Code == "NoSuchKey") {
+		return ErrNotFound
+	}
+	if body.Code != "" {
+		return fmt.Errorf("storage: s3 %s: %s", body.Code, body.Message)
+	}
+	return fmt.Errorf("storage: s3 status %d", resp.StatusCode)
+}
+
+// headerInfo 从响应头获取对象信息
+func headerInfo(key string, header http.Header) ObjectInfo {
+	info := ObjectInfo{
+		Key:         key,
+		ContentType: header.Get("Content-Type"),
+		ETag:        strings.Trim(header.Get("ETag"), `"`),
+	}
+	info.Size, _ = strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	info.ModTime, _ = http.ParseTime(header.Get("Last-Modified"))
+	return info
+}
+
+// putHeader 写入时的请求头
+func putHeader(key string, opts PutOptions) http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", opts.ContentType)
+	if opts.ContentType == "" {
+		header.Set("Content-Type", contentTypeOf(key))
+	}
+	if opts.CacheControl != "" {
+		header.Set("Cache-Control", opts.CacheControl)
+	}
+	return header
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 表单上传
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+
+// PresignUpload POST 时生成表单上传的 policy，PUT 时生成签名地址，最长 7 天
+func (d *S3Driver) PresignUpload(ctx context.Context, key string, policy UploadPolicy) (PresignedUpload, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+
+	policy = policy.normalize()
+	if policy.Expires > 7*24*time.Hour {
+		policy.Expires = 7 * 24 * time.Hour
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(policy.Expires)
+
+	if policy.Method == http.MethodPut {
+		if policy.Size <= 0 {
+			return PresignedUpload{}, ErrSizeRequired
+		}
+
+		// 签名 Content-Length，上传的大小必须一致
+		headers := map[string]string{"Content-Length": strconv.FormatInt(policy.Size, 10)}
+		if policy.ContentType != "" {
+			headers["Content-Type"] = policy.ContentType
+		}
+
+		return PresignedUpload{
+			Method:    http.MethodPut,
+			URL:       d.signer.presign(http.MethodPut, d.objectURL(key, nil), policy.Expires, now, headers),
+			Headers:   headers,
+			ExpiresAt: expiresAt,
+		}, nil
+	}
+
+	amzDate := now.UTC().Format(sigDateFormat)
+	fields := map[string]string{
+		"key":                   key,
+		"success_action_status": "201",
+		"x-amz-algorithm":       sigAlgorithm,
+		"x-amz-credential":      d.signer.accessKey + "/" + d.signer.scope(now),
+		"x-amz-date":            amzDate,
+	}
+
+	conditions := []any{map[string]string{"bucket": d.bucket}}
+	for name, value := range fields {
+		conditions = append(conditions, []string{"eq", "$" + name, value})
+	}
+	if policy.ContentType != "" {
+		fields["Content-Type"] = policy.ContentType
+		conditions = append(conditions, []string{"eq", "$Content-Type", policy.ContentType})
+	}
+	if policy.MaxSize > 0 {
+		conditions = append(conditions, []any{"content-length-range", 0, policy.MaxSize})
+	}
+
+	document, err := json.Marshal(map[string]any{
+		"expiration": expiresAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(document)
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(d.signer.signingKey(now), encoded))
+
+	return PresignedUpload{
+		Method:    http.MethodPost,
+		URL:       d.objectURL("", nil).String(),
+		Fields:    fields,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// s3Event S3 事件通知，MinIO webhook 使用相同的格式
+type s3Event struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key         string `json:"key"`
+				Size        int64  `json:"size"`
+				ETag        string `json:"eTag"`
+				ContentType string `json:"contentType"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// VerifyNotification 校验 MinIO webhook 通知，Authorization 需要与配置的 NotifyToken 一致，
+// 只返回本存储桶的对象创建事件
+func (d *S3Driver) VerifyNotification(ctx context.Context, r *http.Request, body []byte) ([]ObjectInfo, error) {
+	if d.notifyToken == "" {
+		return nil, ErrNotSupported
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if subtle.ConstantTimeCompare([]byte(token), []byte(d.notifyToken)) != 1 {
+		return nil, ErrInvalidNotification
+	}
+
+	var event s3Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, ErrInvalidNotification
+	}
+
+	var objects []ObjectInfo
+	for _, record := range event.Records {
+		// AWS 为 ObjectCreated:Put，MinIO 为 s3:ObjectCreated:Put
+		if !strings.Contains(record.EventName, "ObjectCreated:") || record.S3.Bucket.Name != d.bucket {
+			continue
+		}
+
+		// 通知中的 key 经过 URL 编码
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return nil, ErrInvalidNotification
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:         key,
+			Size:        record.S3.Object.Size,
+			ContentType: record.S3.Object.ContentType,
+			ETag:        strings.Trim(record.S3.Object.ETag, `"`),
+		})
+	}
+	return objects, nil
+}
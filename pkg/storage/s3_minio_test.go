@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MinIO 集成测试，需要设置以下环境变量，未设置时跳过
+//
+//	STORAGE_TEST_S3_ENDPOINT    如 http://127.0.0.1:9000
+//	STORAGE_TEST_S3_ACCESS_KEY
+//	STORAGE_TEST_S3_SECRET_KEY
+//	STORAGE_TEST_S3_BUCKET      需要提前创建
+//
+// 本地可以用 docker run -p 9000:9000 minio/minio server /data 启动
+func minioDriver(t *testing.T) *S3Driver {
+	t.Helper()
+
+	endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_S3_ENDPOINT 未设置，跳过 MinIO 集成测试")
+	}
+
+	d, err := newS3Driver(DiskConfig{
+		Driver:          "s3",
+		Endpoint:        endpoint,
+		Region:          os.Getenv("STORAGE_TEST_S3_REGION"),
+		AccessKeyID:     os.Getenv("STORAGE_TEST_S3_ACCESS_KEY"),
+		AccessKeySecret: os.Getenv("STORAGE_TEST_S3_SECRET_KEY"),
+		Bucket:          os.Getenv("STORAGE_TEST_S3_BUCKET"),
+		PathStyle:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// testPrefix 每次测试使用独立的前缀，结束时清理
+func testPrefix(t *testing.T, d *S3Driver) string {
+	t.Helper()
+
+	prefix := "storage-test/" + strconv.FormatInt(time.Now().UnixNano(), 36) + "/"
+	t.Cleanup(func() {
+		ctx := context.Background()
+		result, err := d.List(ctx, prefix, ListOptions{})
+		if err != nil {
+			return
+		}
+		for _, object := range result.Objects {
+			_ = d.Delete(ctx, object.Key)
+		}
+	})
+	return prefix
+}
+
+func TestMinIOObject(t *testing.T) {
+	d := minioDriver(t)
+	prefix := testPrefix(t, d)
+	ctx := context.Background()
+
+	key := prefix + "a b/中文.txt"
+	content := []byte("hello minio")
+
+	info, err := d.Put(ctx, key, bytes.NewReader(content), PutOptions{Size: int64(len(content)), ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Fatalf("Put size = %d, want %d", info.Size, len(content))
+	}
+
+	stat, err := d.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if stat.Size != int64(len(content)) || stat.ContentType != "text/plain" {
+		t.Fatalf("Stat = %+v", stat)
+	}
+
+	rc, _, err := d.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		name := prefix + "list/" + strconv.Itoa(i)
+		if _, err := d.Put(ctx, name, strings.NewReader("x"), PutOptions{Size: 1}); err != nil {
+			t.Fatalf("Put %s: %v", name, err)
+		}
+	}
+
+	first, err := d.List(ctx, prefix+"list/", ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(first.Objects) != 2 || first.Next == "" {
+		t.Fatalf("List first page = %+v", first)
+	}
+	second, err := d.List(ctx, prefix+"list/", ListOptions{Limit: 2, Cursor: first.Next})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(second.Objects) != 1 || second.Next != "" {
+		t.Fatalf("List second page = %+v", second)
+	}
+
+	if err := d.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := d.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat after Delete = %v, want ErrNotFound", err)
+	}
+	if err := d.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete missing object: %v", err)
+	}
+}
+
+func TestMinIOMultipart(t *testing.T) {
+	d := minioDriver(t)
+	prefix := testPrefix(t, d)
+	ctx := context.Background()
+
+	// 大小未知时按分片上传，最后一个分片不足 MinPartSize
+	content := bytes.Repeat([]byte("0123456789"), (DefaultPartSize+MinPartSize)/10)
+	key := prefix + "multipart.bin"
+
+	if _, err := d.Put(ctx, key, bytes.NewReader(content), PutOptions{Size: -1}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	stat, err := d.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if stat.Size != int64(len(content)) {
+		t.Fatalf("Stat size = %d, want %d", stat.Size, len(content))
+	}
+}
+
+func TestMinIOSignedURL(t *testing.T) {
+	d := minioDriver(t)
+	prefix := testPrefix(t, d)
+	ctx := context.Background()
+
+	key := prefix + "signed.txt"
+	content := []byte("signed url")
+
+	putURL, err := d.SignedURL(ctx, key, SignOptions{Method: http.MethodPut, ContentType: "text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPut, putURL, bytes.NewReader(content))
+	req.Header.Set("Content-Type", "text/plain")
+	expectStatus(t, req, http.StatusOK)
+
+	getURL, err := d.SignedURL(ctx, key, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, getURL, nil)
+	if body := expectStatus(t, req, http.StatusOK); !bytes.Equal(body, content) {
+		t.Fatalf("GET signed url = %q, want %q", body, content)
+	}
+}
+
+func TestMinIOPresignUpload(t *testing.T) {
+	d := minioDriver(t)
+	prefix := testPrefix(t, d)
+	ctx := context.Background()
+
+	content := []byte("direct upload")
+
+	t.Run("put", func(t *testing.T) {
+		key := prefix + "put.txt"
+		upload, err := d.PresignUpload(ctx, key, UploadPolicy{Method: http.MethodPut, Size: int64(len(content)), ContentType: "text/plain"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest(upload.Method, upload.URL, bytes.NewReader(content))
+		for name, value := range upload.Headers {
+			req.Header.Set(name, value)
+		}
+		expectStatus(t, req, http.StatusOK)
+
+		// 大小与签名不一致时拒绝
+		other := append(bytes.Clone(content), '!')
+		req, _ = http.NewRequest(upload.Method, upload.URL, bytes.NewReader(other))
+		req.Header.Set("Content-Type", "text/plain")
+		expectStatus(t, req, http.StatusForbidden)
+	})
+
+	t.Run("post", func(t *testing.T) {
+		key := prefix + "post.txt"
+		upload, err := d.PresignUpload(ctx, key, UploadPolicy{MaxSize: int64(len(content)), ContentType: "text/plain"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectStatus(t, postForm(t, upload, content), http.StatusCreated)
+
+		stat, err := d.Stat(ctx, key)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if stat.Size != int64(len(content)) {
+			t.Fatalf("Stat size = %d, want %d", stat.Size, len(content))
+		}
+
+		// 超过 MaxSize 时拒绝
+		expectStatus(t, postForm(t, upload, append(bytes.Clone(content), '!')), http.StatusBadRequest)
+	})
+}
+
+// postForm 按 PresignedUpload 构造表单上传请求
+func postForm(t *testing.T, upload PresignedUpload, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range upload.Fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	part, err := w.CreateFormFile("file", "upload.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(content)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(upload.Method, upload.URL, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+// expectStatus 发送请求并检查状态码，返回响应体
+func expectStatus(t *testing.T, req *http.Request, status int) []byte {
+	t.Helper()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s = %d, want %d: %s", req.Method, req.URL.Path, resp.StatusCode, status, body)
+	}
+	return body
+}
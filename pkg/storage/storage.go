@@ -0,0 +1,145 @@
+// Package storage 对象存储抽象，按配置中的磁盘名称选择本地文件系统、S3 兼容（MinIO 等）或阿里云 OSS
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"tool/pkg/registry"
+)
+
+var (
+	ErrNotFound     = errors.New("storage: object not found")
+	ErrNotSupported = errors.New("storage: operation not supported")
+	ErrInvalidKey   = errors.New("storage: invalid object key")
+)
+
+// Driver 对象存储驱动，key 为 "/" 分隔的相对路径，如 uploads/2024/01/a.png
+type Driver interface {
+	// Put 写入对象，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error)
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser，不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Delete 删除对象，不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// Stat 获取对象信息，不存在时返回 ErrNotFound
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// List 按前缀分页列出对象，按 key 升序
+	List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error)
+	// SignedURL 生成带签名和有效期的访问地址
+	SignedURL(ctx context.Context, key string, opts SignOptions) (string, error)
+	// URL 公开访问地址，没有配置时返回空字符串
+	URL(key string) string
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ETag        string    `json:"etag"`
+	ModTime     time.Time `json:"mod_time"`
+}
+
+// PutOptions 写入参数
+type PutOptions struct {
+	Size         int64  // 内容长度，未知时为 -1
+	ContentType  string // 为空时按扩展名推断
+	CacheControl string
+}
+
+// ListOptions 列表参数
+type ListOptions struct {
+	Limit  int    // 单页数量，默认 1000
+	Cursor string // 上一页返回的 Next，为空时从头开始
+}
+
+// ListResult 列表结果
+type ListResult struct {
+	Objects []ObjectInfo `json:"objects"`
+	Next    string       `json:"next"` // 下一页的游标，为空时没有更多
+}
+
+// SignOptions 签名参数
+type SignOptions struct {
+	Method      string        // GET（默认）或 PUT
+	Expires     time.Duration // 有效期，默认 15 分钟
+	ContentType string        // PUT 时限制上传的 Content-Type
+}
+
+// 默认值
+const (
+	defaultListLimit   = 1000
+	defaultSignExpires = 15 * time.Minute
+)
+
+// disks 按磁盘名称管理的驱动
+var disks = registry.New(registry.Options[Driver]{
+	Kind:   "Storage",
+	Create: createDriver,
+})
+
+// Disk 获取配置中的磁盘，首次调用时创建驱动
+func Disk(name string) (Driver, error) {
+	return disks.Get(name)
+}
+
+// createDriver 按配置创建驱动
+func createDriver(name string) (Driver, error) {
+	config, err := loadConfig(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Driver {
+	case "local":
+		return newLocalDriver(config)
+	case "s3":
+		return newS3Driver(config)
+	default:
+		return newAliyunDriver(config)
+	}
+}
+
+// Join 拼接磁盘目录和文件名，得到对象 key
+func Join(elem ...string) string {
+	return strings.TrimPrefix(path.Join(elem...), "/")
+}
+
+// cleanKey 规范化 key，不允许跳出根目录
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// normalize 签名参数的默认值
+func (o SignOptions) normalize() SignOptions {
+	o.Method = strings.ToUpper(o.Method)
+	if o.Method == "" {
+		o.Method = http.MethodGet
+	}
+	if o.Expires <= 0 {
+		o.Expires = defaultSignExpires
+	}
+	return o
+}
+
+// limit 单页数量的默认值
+func (o ListOptions) limit() int {
+	if o.Limit <= 0 || o.Limit > defaultListLimit {
+		return defaultListLimit
+	}
+	return o.Limit
+}
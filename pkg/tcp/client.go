@@ -1,46 +1,285 @@
 package tcp
 
 import (
-	"bufio"
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"net"
-	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
 )
 
-// TCPClient 结构体表示TCP客户端
+// ErrNotConnected 客户端未连接
+var ErrNotConnected = errors.New("tcp: client not connected")
+
+// ClientConfig 定义了 TCP 客户端的配置
+type ClientConfig struct {
+	Addr           string        // 服务器地址
+	Framer         Framer        // 拆包方式，需与服务端一致，默认长度前缀
+	Codec          Codec         // 消息体编解码，需与服务端一致，默认 JSON
+	Router         *Router       // 服务端推送消息的路由
+	DialTimeout    time.Duration // 连接超时，默认 5 秒
+	RequestTimeout time.Duration // ctx 未设置截止时间时的请求超时，默认 10 秒
+	WriteTimeout   time.Duration // 写超时，默认 10 秒
+	MinBackoff     time.Duration // 重连最小间隔，默认 500 毫秒
+	MaxBackoff     time.Duration // 重连最大间隔，默认 30 秒
+	MaxRetries     int           // 单次连接 / 重连的最大尝试次数，0 表示不限
+	Logger         *zap.Logger   // 日志，默认 variable.Logs
+}
+
+// TCPClient 支持断线重连和请求 / 响应的 TCP 客户端
 type TCPClient struct {
-	address string
+	config  ClientConfig
+	mu      sync.RWMutex
+	conn    *Conn
+	seq     atomic.Uint32
+	pending sync.Map // seq => chan response
+	closed  atomic.Bool
+}
+
+// response 请求的响应
+type response struct {
+	packet Packet
+	err    error
 }
 
 // NewTCPClient 创建一个新的TCPClient
-func NewTCPClient(address string) *TCPClient {
-	return &TCPClient{address: address}
+func NewTCPClient(config ClientConfig) *TCPClient {
+	if config.Framer == nil {
+		config.Framer = LengthFieldFramer{}
+	}
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+	if config.Router == nil {
+		config.Router = NewRouter()
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 10 * time.Second
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 10 * time.Second
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = 500 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 30 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = defaultLogger()
+	}
+
+	return &TCPClient{config: config}
+}
+
+// Router 获取推送消息路由
+func (c *TCPClient) Router() *Router {
+	return c.config.Router
 }
 
-// Connect 连接到服务器并发送消息
-func (c *TCPClient) Connect(message string) {
-	// 连接到服务器
-	conn, err := net.Dial("tcp", c.address)
+// Connect 连接到服务器，失败时按退避策略重试，直到成功、ctx 取消或达到最大次数
+func (c *TCPClient) Connect(ctx context.Context) error {
+	backoff := c.config.MinBackoff
+
+	for attempt := 1; ; attempt++ {
+		if c.closed.Load() {
+			return ErrConnClosed
+		}
+
+		dialer := net.Dialer{Timeout: c.config.DialTimeout}
+		raw, err := dialer.DialContext(ctx, "tcp", c.config.Addr)
+		if err == nil {
+			conn := newConn(raw, c.config.Framer, c.config.Codec, c.config.WriteTimeout)
+
+			// 拨号期间客户端可能已被关闭，Close 先设置 closed 再读取 conn，这里在锁内检查
+			c.mu.Lock()
+			if c.closed.Load() {
+				c.mu.Unlock()
+				_ = conn.Close()
+				return ErrConnClosed
+			}
+			c.conn = conn
+			c.mu.Unlock()
+
+			go c.readLoop(conn)
+
+			c.config.Logger.Info("TCP client connected", zap.String("addr", c.config.Addr))
+			return nil
+		}
+
+		c.config.Logger.Warn("TCP client connect failed",
+			zap.String("addr", c.config.Addr),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+
+		if c.config.MaxRetries > 0 && attempt >= c.config.MaxRetries {
+			return fmt.Errorf("tcp connect %s: %w", c.config.Addr, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > c.config.MaxBackoff {
+			backoff = c.config.MaxBackoff
+		}
+	}
+}
+
+// readLoop 读取响应和推送，连接断开后自动重连
+func (c *TCPClient) readLoop(conn *Conn) {
+	for {
+		packet, err := conn.readPacket()
+		if err != nil {
+			break
+		}
+
+		if packet.Seq != 0 {
+			if ch, ok := c.pending.LoadAndDelete(packet.Seq); ok {
+				ch.(chan response) <- response{packet: packet}
+			}
+			continue
+		}
+
+		c.handle(conn, packet)
+	}
+
+	_ = conn.Close()
+
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+	}
+	c.mu.Unlock()
+
+	// 断开后等待中的请求全部失败
+	c.pending.Range(func(key, value any) bool {
+		c.pending.Delete(key)
+		value.(chan response) <- response{err: ErrConnClosed}
+		return true
+	})
+
+	if c.closed.Load() {
+		return
+	}
+
+	c.config.Logger.Warn("TCP client disconnected, reconnecting", zap.String("addr", c.config.Addr))
+
+	if err := c.Connect(context.Background()); err != nil {
+		c.config.Logger.Error("TCP client reconnect failed", zap.String("addr", c.config.Addr), zap.Error(err))
+	}
+}
+
+// handle 分发服务端推送，处理函数 panic 时只记录日志，不影响读循环
+func (c *TCPClient) handle(conn *Conn, packet Packet) {
+	ctx := &Context{Conn: conn, Packet: packet, codec: c.config.Codec}
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return c.config.Router.dispatch(ctx)
+	}()
+
 	if err != nil {
-		fmt.Println("Error connecting:", err.Error())
-		os.Exit(1)
+		c.config.Logger.Error("TCP client handle push failed", zap.Uint32("msgID", packet.MsgID), zap.Error(err))
 	}
-	defer conn.Close()
+}
+
+// current 获取当前连接
+func (c *TCPClient) current() (*Conn, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	// 发送消息到服务器
-	_, err = conn.Write(bytes.NewBufferString(message + "\n").Bytes())
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil, ErrNotConnected
+	}
+	return c.conn, nil
+}
+
+// Send 发送单向消息
+func (c *TCPClient) Send(msgID uint32, v any) error {
+	conn, err := c.current()
 	if err != nil {
-		fmt.Println("Error writing to server:", err.Error())
-		return
+		return err
 	}
+	return conn.Send(msgID, v)
+}
 
-	// 读取服务器的响应（如果有）
-	response, err := bufio.NewReader(conn).ReadString('\n')
+// Request 发送请求并等待响应，resp 为空时忽略响应内容
+func (c *TCPClient) Request(ctx context.Context, msgID uint32, req any, resp any) error {
+	conn, err := c.current()
 	if err != nil {
-		fmt.Println("Error reading response:", err.Error())
-		return
+		return err
 	}
 
-	fmt.Printf("Response from server: %s\n", response)
+	body, err := c.config.Codec.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.RequestTimeout)
+		defer cancel()
+	}
+
+	// 序号 0 保留给单向消息
+	seq := c.seq.Add(1)
+	if seq == 0 {
+		seq = c.seq.Add(1)
+	}
+
+	ch := make(chan response, 1)
+	c.pending.Store(seq, ch)
+	defer c.pending.Delete(seq)
+
+	if err := conn.writePacket(Packet{MsgID: msgID, Seq: seq, Body: body}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.err != nil {
+			return res.err
+		}
+
+		if res.packet.Flag == flagError {
+			return &RemoteError{MsgID: msgID, Message: string(res.packet.Body)}
+		}
+
+		if resp == nil {
+			return nil
+		}
+		return c.config.Codec.Unmarshal(res.packet.Body, resp)
+	}
+}
+
+// Close 关闭客户端，不再重连
+func (c *TCPClient) Close() error {
+	c.closed.Store(true)
+
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
 }
@@ -0,0 +1,184 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试用消息ID
+const (
+	msgEcho  uint32 = 1
+	msgFail  uint32 = 2
+	msgKick  uint32 = 3
+	msgPanic uint32 = 10
+	msgPush  uint32 = 11
+)
+
+// testServer 最简单的服务端：回显请求，msgKick 时断开连接，连接建立时先推送 msgPanic 再推送 msgPush
+type testServer struct {
+	listener net.Listener
+	accepted atomic.Int32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{listener: listener}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			raw, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.accepted.Add(1)
+			go s.serve(newConn(raw, LengthFieldFramer{}, JSONCodec{}, time.Second))
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn *Conn) {
+	defer conn.Close()
+
+	_ = conn.Send(msgPanic, nil)
+	_ = conn.Send(msgPush, "pushed")
+
+	for {
+		packet, err := conn.readPacket()
+		if err != nil {
+			return
+		}
+
+		switch packet.MsgID {
+		case msgEcho:
+			_ = conn.writePacket(Packet{MsgID: packet.MsgID, Seq: packet.Seq, Body: packet.Body})
+		case msgFail:
+			_ = conn.writePacket(Packet{MsgID: packet.MsgID, Seq: packet.Seq, Flag: flagError, Body: []byte("failed")})
+		case msgKick:
+			return
+		}
+	}
+}
+
+func newTestClient(t *testing.T, s *testServer, router *Router) *TCPClient {
+	t.Helper()
+
+	client := NewTCPClient(ClientConfig{
+		Addr:           s.listener.Addr().String(),
+		Router:         router,
+		RequestTimeout: time.Second,
+		MinBackoff:     10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	})
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestClientRequest(t *testing.T) {
+	client := newTestClient(t, newTestServer(t), nil)
+
+	var resp string
+	if err := client.Request(context.Background(), msgEcho, "hello", &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp != "hello" {
+		t.Fatalf("resp = %q, want hello", resp)
+	}
+
+	var remote *RemoteError
+	if err := client.Request(context.Background(), msgFail, nil, nil); !errors.As(err, &remote) || remote.Message != "failed" {
+		t.Fatalf("err = %v, want RemoteError", err)
+	}
+}
+
+func TestClientPushPanic(t *testing.T) {
+	pushed := make(chan string, 1)
+	router := NewRouter().
+		Handle(msgPanic, func(ctx *Context) error {
+			panic("boom")
+		}).
+		Handle(msgPush, func(ctx *Context) error {
+			var body string
+			if err := ctx.Bind(&body); err != nil {
+				return err
+			}
+			pushed <- body
+			return nil
+		})
+
+	client := newTestClient(t, newTestServer(t), router)
+
+	// 前一个处理函数 panic 后仍然继续处理推送
+	select {
+	case body := <-pushed:
+		if body != "pushed" {
+			t.Fatalf("push = %q", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("push not received after handler panic")
+	}
+
+	if err := client.Request(context.Background(), msgEcho, "ok", nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	s := newTestServer(t)
+	client := newTestClient(t, s, nil)
+
+	if err := client.Send(msgKick, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 断开后自动重连，重连期间请求失败
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		var resp string
+		err := client.Request(context.Background(), msgEcho, "again", &resp)
+		if err == nil && s.accepted.Load() >= 2 {
+			if resp != "again" {
+				t.Fatalf("resp = %q", resp)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("client did not reconnect: accepted = %d, err = %v", s.accepted.Load(), err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	s := newTestServer(t)
+	client := newTestClient(t, s, nil)
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Send(msgEcho, nil); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("send after close = %v", err)
+	}
+
+	// 关闭后不再重连
+	time.Sleep(100 * time.Millisecond)
+	if n := s.accepted.Load(); n != 1 {
+		t.Fatalf("accepted = %d after close, want 1", n)
+	}
+	if err := client.Connect(context.Background()); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("connect after close = %v", err)
+	}
+}
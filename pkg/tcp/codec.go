@@ -0,0 +1,87 @@
+package tcp
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec 消息体编解码接口
+type Codec interface {
+	// Name 编解码器名称
+	Name() string
+
+	// Marshal 编码
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal 解码
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec JSON 编解码
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	if len(data) == 0 || v == nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// protoMessage 兼容 protobuf（gogo / 生成代码）风格的消息
+type protoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// BinaryCodec 二进制编解码
+// 支持 []byte、实现 encoding.BinaryMarshaler / BinaryUnmarshaler 的类型，以及 protobuf 风格的 Marshal / Unmarshal 方法
+type BinaryCodec struct{}
+
+func (BinaryCodec) Name() string {
+	return "binary"
+}
+
+func (BinaryCodec) Marshal(v any) ([]byte, error) {
+	switch msg := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return msg, nil
+	case *[]byte:
+		return *msg, nil
+	case encoding.BinaryMarshaler:
+		return msg.MarshalBinary()
+	case protoMessage:
+		return msg.Marshal()
+	default:
+		return nil, fmt.Errorf("binary codec: unsupported type %T", v)
+	}
+}
+
+func (BinaryCodec) Unmarshal(data []byte, v any) error {
+	switch msg := v.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*msg = append((*msg)[:0], data...)
+		return nil
+	case encoding.BinaryUnmarshaler:
+		return msg.UnmarshalBinary(data)
+	case protoMessage:
+		return msg.Unmarshal(data)
+	default:
+		return fmt.Errorf("binary codec: unsupported type %T", v)
+	}
+}
@@ -0,0 +1,73 @@
+package tcp
+
+import (
+	"bytes"
+	"testing"
+)
+
+// binaryMessage 实现 encoding.BinaryMarshaler
+type binaryMessage struct{ data []byte }
+
+func (m binaryMessage) MarshalBinary() ([]byte, error) { return m.data, nil }
+
+func (m *binaryMessage) UnmarshalBinary(data []byte) error {
+	m.data = append([]byte(nil), data...)
+	return nil
+}
+
+func TestJSONCodec(t *testing.T) {
+	codec := JSONCodec{}
+
+	data, err := codec.Marshal(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]int
+	if err := codec.Unmarshal(data, &got); err != nil || got["a"] != 1 {
+		t.Fatalf("Unmarshal = %v, %v", got, err)
+	}
+
+	// nil 和空内容
+	if data, err := codec.Marshal(nil); err != nil || data != nil {
+		t.Fatalf("Marshal(nil) = %v, %v", data, err)
+	}
+	if err := codec.Unmarshal(nil, &got); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBinaryCodec(t *testing.T) {
+	codec := BinaryCodec{}
+	raw := []byte{0, 1, 2}
+
+	tests := []struct {
+		name string
+		in   any
+	}{
+		{"bytes", raw},
+		{"bytes pointer", &raw},
+		{"binary marshaler", binaryMessage{data: raw}},
+	}
+	for _, tt := range tests {
+		data, err := codec.Marshal(tt.in)
+		if err != nil || !bytes.Equal(data, raw) {
+			t.Errorf("%s: Marshal = %v, %v", tt.name, data, err)
+		}
+	}
+
+	var out []byte
+	if err := codec.Unmarshal(raw, &out); err != nil || !bytes.Equal(out, raw) {
+		t.Fatalf("Unmarshal bytes = %v, %v", out, err)
+	}
+	var msg binaryMessage
+	if err := codec.Unmarshal(raw, &msg); err != nil || !bytes.Equal(msg.data, raw) {
+		t.Fatalf("Unmarshal binary = %v, %v", msg.data, err)
+	}
+
+	if _, err := codec.Marshal("string"); err == nil {
+		t.Fatal("unsupported type should fail")
+	}
+	if err := codec.Unmarshal(raw, new(string)); err == nil {
+		t.Fatal("unsupported type should fail")
+	}
+}
@@ -0,0 +1,126 @@
+package tcp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrConnClosed 连接已关闭
+var ErrConnClosed = errors.New("tcp: connection closed")
+
+// Conn 一个长连接，服务端和客户端共用
+type Conn struct {
+	id           uint64
+	raw          net.Conn
+	reader       *bufio.Reader
+	framer       Framer
+	codec        Codec
+	writeTimeout time.Duration
+	writeMu      sync.Mutex
+	lastActive   atomic.Int64
+	closed       atomic.Bool
+	closeOnce    sync.Once
+	properties   sync.Map
+}
+
+var connID atomic.Uint64
+
+// newConn 包装 net.Conn
+func newConn(raw net.Conn, framer Framer, codec Codec, writeTimeout time.Duration) *Conn {
+	c := &Conn{
+		id:           connID.Add(1),
+		raw:          raw,
+		reader:       bufio.NewReader(raw),
+		framer:       framer,
+		codec:        codec,
+		writeTimeout: writeTimeout,
+	}
+	c.touch()
+	return c
+}
+
+// ID 连接ID，进程内唯一
+func (c *Conn) ID() uint64 {
+	return c.id
+}
+
+// RemoteAddr 对端地址
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.raw.RemoteAddr()
+}
+
+// LastActive 最后一次收到消息的时间
+func (c *Conn) LastActive() time.Time {
+	return time.Unix(0, c.lastActive.Load())
+}
+
+// Set 设置连接属性（如登录后的用户ID）
+func (c *Conn) Set(key string, value any) {
+	c.properties.Store(key, value)
+}
+
+// Get 获取连接属性
+func (c *Conn) Get(key string) (any, bool) {
+	return c.properties.Load(key)
+}
+
+// Send 发送单向消息
+func (c *Conn) Send(msgID uint32, v any) error {
+	body, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.writePacket(Packet{MsgID: msgID, Body: body})
+}
+
+// Close 关闭连接
+func (c *Conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.closed.Store(true)
+		err = c.raw.Close()
+	})
+	return err
+}
+
+// IsClosed 连接是否已关闭
+func (c *Conn) IsClosed() bool {
+	return c.closed.Load()
+}
+
+// touch 刷新活跃时间
+func (c *Conn) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// readPacket 读取一个消息
+func (c *Conn) readPacket() (Packet, error) {
+	frame, err := c.framer.ReadFrame(c.reader)
+	if err != nil {
+		return Packet{}, err
+	}
+
+	c.touch()
+	return decodePacket(frame)
+}
+
+// writePacket 写入一个消息，并发安全
+func (c *Conn) writePacket(p Packet) error {
+	if c.IsClosed() {
+		return ErrConnClosed
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.writeTimeout > 0 {
+		_ = c.raw.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+
+	return c.framer.WriteFrame(c.raw, encodePacket(p))
+}
@@ -0,0 +1,167 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrFrameTooLarge 帧长度超过限制
+var ErrFrameTooLarge = errors.New("tcp: frame too large")
+
+// Framer 拆包 / 封包接口
+type Framer interface {
+	// ReadFrame 从连接中读取一个完整的帧
+	ReadFrame(r *bufio.Reader) ([]byte, error)
+
+	// WriteFrame 写入一个帧
+	WriteFrame(w io.Writer, payload []byte) error
+}
+
+// LengthFieldFramer 长度前缀拆包，帧格式为 4 字节大端长度 + 内容
+type LengthFieldFramer struct {
+	MaxFrameSize uint32 // 单帧最大长度，0 表示默认 4MB
+}
+
+func (f LengthFieldFramer) maxSize() uint32 {
+	if f.MaxFrameSize == 0 {
+		return 4 << 20
+	}
+	return f.MaxFrameSize
+}
+
+func (f LengthFieldFramer) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > f.maxSize() {
+		return nil, fmt.Errorf("%w: %d", ErrFrameTooLarge, size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (f LengthFieldFramer) WriteFrame(w io.Writer, payload []byte) error {
+	if uint32(len(payload)) > f.maxSize() {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, len(payload))
+	}
+
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+// 分隔符拆包的转义字节，内容中出现的分隔符和转义字节会被转义，因此可以承载任意二进制内容
+const (
+	escapeByte    = 0x1b
+	escapedDelim  = 0x01
+	escapedEscape = 0x02
+)
+
+// DelimiterFramer 分隔符拆包，适用于以换行等分隔的文本协议
+type DelimiterFramer struct {
+	Delimiter    byte // 分隔符，默认 '\n'
+	MaxFrameSize int  // 单帧最大长度，0 表示默认 64KB
+}
+
+func (f DelimiterFramer) delimiter() byte {
+	if f.Delimiter == 0 {
+		return '\n'
+	}
+	return f.Delimiter
+}
+
+func (f DelimiterFramer) maxSize() int {
+	if f.MaxFrameSize == 0 {
+		return 64 << 10
+	}
+	return f.MaxFrameSize
+}
+
+func (f DelimiterFramer) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	var frame []byte
+
+	for {
+		line, err := r.ReadSlice(f.delimiter())
+		frame = append(frame, line...)
+
+		// 转义后长度最多为原内容的两倍
+		if len(frame) > 2*f.maxSize()+1 {
+			return nil, fmt.Errorf("%w: %d", ErrFrameTooLarge, len(frame))
+		}
+
+		if err == nil {
+			return f.unescape(frame[:len(frame)-1])
+		}
+
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+	}
+}
+
+func (f DelimiterFramer) WriteFrame(w io.Writer, payload []byte) error {
+	if len(payload) > f.maxSize() {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, len(payload))
+	}
+
+	frame := make([]byte, 0, len(payload)+1)
+	for _, b := range payload {
+		switch b {
+		case f.delimiter():
+			frame = append(frame, escapeByte, escapedDelim)
+		case escapeByte:
+			frame = append(frame, escapeByte, escapedEscape)
+		default:
+			frame = append(frame, b)
+		}
+	}
+
+	_, err := w.Write(append(frame, f.delimiter()))
+	return err
+}
+
+// unescape 还原转义内容
+func (f DelimiterFramer) unescape(frame []byte) ([]byte, error) {
+	if bytes.IndexByte(frame, escapeByte) < 0 {
+		return frame, nil
+	}
+
+	payload := make([]byte, 0, len(frame))
+	for i := 0; i < len(frame); i++ {
+		if frame[i] != escapeByte {
+			payload = append(payload, frame[i])
+			continue
+		}
+
+		i++
+		if i >= len(frame) {
+			return nil, errors.New("tcp: invalid escape sequence")
+		}
+
+		switch frame[i] {
+		case escapedDelim:
+			payload = append(payload, f.delimiter())
+		case escapedEscape:
+			payload = append(payload, escapeByte)
+		default:
+			return nil, errors.New("tcp: invalid escape sequence")
+		}
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,171 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLengthFieldFramer(t *testing.T) {
+	framer := LengthFieldFramer{MaxFrameSize: 64}
+
+	tests := []struct {
+		name     string
+		payloads [][]byte
+	}{
+		{"empty", [][]byte{{}}},
+		{"single", [][]byte{[]byte("hello")}},
+		{"multiple", [][]byte{[]byte("a"), []byte("bc"), {0, 0, 0, 4}, bytes.Repeat([]byte("x"), 64)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			for _, payload := range tt.payloads {
+				if err := framer.WriteFrame(&buf, payload); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 每次只读取 1 字节，模拟拆分到达的数据
+			r := bufio.NewReader(iotest.OneByteReader(&buf))
+			for _, want := range tt.payloads {
+				got, err := framer.ReadFrame(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("frame = %q, want %q", got, want)
+				}
+			}
+			if _, err := framer.ReadFrame(r); err != io.EOF {
+				t.Fatalf("after last frame = %v, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestLengthFieldFramerErrors(t *testing.T) {
+	framer := LengthFieldFramer{MaxFrameSize: 8}
+
+	if err := framer.WriteFrame(io.Discard, make([]byte, 9)); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("write oversize = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+		err   error
+	}{
+		{"oversize", []byte{0, 0, 0, 9}, ErrFrameTooLarge},
+		{"short header", []byte{0, 0}, io.ErrUnexpectedEOF},
+		{"short payload", []byte{0, 0, 0, 4, 'a'}, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		_, err := framer.ReadFrame(bufio.NewReader(bytes.NewReader(tt.input)))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDelimiterFramer(t *testing.T) {
+	tests := []struct {
+		name     string
+		framer   DelimiterFramer
+		payloads [][]byte
+	}{
+		{"text", DelimiterFramer{}, [][]byte{[]byte("hello"), []byte("world")}},
+		{"empty", DelimiterFramer{}, [][]byte{{}, []byte("a")}},
+		{"escaped delimiter", DelimiterFramer{}, [][]byte{[]byte("a\nb\n"), []byte("\n")}},
+		{"escaped escape", DelimiterFramer{}, [][]byte{{escapeByte, escapedDelim}, {escapeByte}, {escapeByte, '\n', escapeByte}}},
+		{"binary", DelimiterFramer{}, [][]byte{{0, 1, 2, 0xff}}},
+		{"custom delimiter", DelimiterFramer{Delimiter: '|'}, [][]byte{[]byte("a|b"), []byte("\n")}},
+		{"max size", DelimiterFramer{MaxFrameSize: 8}, [][]byte{bytes.Repeat([]byte{'\n'}, 8)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			for _, payload := range tt.payloads {
+				if err := tt.framer.WriteFrame(&buf, payload); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 缓冲区最小为 16 字节，长帧会触发多次 ReadSlice
+			r := bufio.NewReaderSize(iotest.OneByteReader(&buf), 16)
+			for _, want := range tt.payloads {
+				got, err := tt.framer.ReadFrame(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("frame = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDelimiterFramerLongFrame(t *testing.T) {
+	framer := DelimiterFramer{}
+	payload := bytes.Repeat([]byte("0123456789\n"), 1000)
+
+	var buf bytes.Buffer
+	if err := framer.WriteFrame(&buf, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := framer.ReadFrame(bufio.NewReaderSize(&buf, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatal("long frame mismatch")
+	}
+}
+
+func TestDelimiterFramerErrors(t *testing.T) {
+	framer := DelimiterFramer{MaxFrameSize: 4}
+
+	if err := framer.WriteFrame(io.Discard, make([]byte, 5)); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("write oversize = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+		err   error
+	}{
+		{"oversize", append(bytes.Repeat([]byte("x"), 20), '\n'), ErrFrameTooLarge},
+		{"missing delimiter", []byte("abc"), io.EOF},
+		{"dangling escape", []byte{'a', escapeByte, '\n'}, nil},
+		{"invalid escape", []byte{escapeByte, 0x7f, '\n'}, nil},
+	}
+	for _, tt := range tests {
+		_, err := framer.ReadFrame(bufio.NewReaderSize(bytes.NewReader(tt.input), 16))
+		if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestPacket(t *testing.T) {
+	p := Packet{MsgID: 7, Seq: 42, Flag: flagError, Body: []byte("body")}
+
+	got, err := decodePacket(encodePacket(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MsgID != p.MsgID || got.Seq != p.Seq || got.Flag != p.Flag || !bytes.Equal(got.Body, p.Body) {
+		t.Fatalf("packet = %+v, want %+v", got, p)
+	}
+
+	if _, err := decodePacket(make([]byte, headerSize-1)); !errors.Is(err, ErrInvalidPacket) {
+		t.Fatalf("short packet = %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package tcp
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConnManager 管理服务端的所有连接
+type ConnManager struct {
+	conns sync.Map
+	count atomic.Int64
+}
+
+// add 添加连接
+func (m *ConnManager) add(c *Conn) {
+	m.conns.Store(c.ID(), c)
+	m.count.Add(1)
+}
+
+// remove 移除连接
+func (m *ConnManager) remove(c *Conn) {
+	if _, ok := m.conns.LoadAndDelete(c.ID()); ok {
+		m.count.Add(-1)
+	}
+}
+
+// Get 根据连接ID获取连接
+func (m *ConnManager) Get(id uint64) (*Conn, bool) {
+	c, ok := m.conns.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return c.(*Conn), true
+}
+
+// Len 当前连接数
+func (m *ConnManager) Len() int {
+	return int(m.count.Load())
+}
+
+// Range 遍历所有连接，fn 返回 false 时停止
+func (m *ConnManager) Range(fn func(c *Conn) bool) {
+	m.conns.Range(func(_, value any) bool {
+		return fn(value.(*Conn))
+	})
+}
+
+// Broadcast 向所有连接发送单向消息
+func (m *ConnManager) Broadcast(msgID uint32, v any) {
+	m.Range(func(c *Conn) bool {
+		_ = c.Send(msgID, v)
+		return true
+	})
+}
@@ -0,0 +1,61 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// 包头长度：消息ID(4) + 序号(4) + 标志(1)
+const headerSize = 9
+
+// 包标志
+const (
+	flagNormal uint8 = 0 // 普通消息
+	flagError  uint8 = 1 // 处理出错，内容为错误信息
+)
+
+// ErrInvalidPacket 包格式错误
+var ErrInvalidPacket = errors.New("tcp: invalid packet")
+
+// Packet 一个完整的业务消息
+// Seq 为 0 表示单向消息（推送），非 0 表示请求，服务端回复时使用相同的 Seq
+type Packet struct {
+	MsgID uint32
+	Seq   uint32
+	Flag  uint8
+	Body  []byte
+}
+
+// encodePacket 编码消息
+func encodePacket(p Packet) []byte {
+	buf := make([]byte, headerSize+len(p.Body))
+	binary.BigEndian.PutUint32(buf[0:4], p.MsgID)
+	binary.BigEndian.PutUint32(buf[4:8], p.Seq)
+	buf[8] = p.Flag
+	copy(buf[headerSize:], p.Body)
+	return buf
+}
+
+// decodePacket 解码消息
+func decodePacket(frame []byte) (Packet, error) {
+	if len(frame) < headerSize {
+		return Packet{}, ErrInvalidPacket
+	}
+
+	return Packet{
+		MsgID: binary.BigEndian.Uint32(frame[0:4]),
+		Seq:   binary.BigEndian.Uint32(frame[4:8]),
+		Flag:  frame[8],
+		Body:  frame[headerSize:],
+	}, nil
+}
+
+// RemoteError 对端处理消息时返回的错误
+type RemoteError struct {
+	MsgID   uint32
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "tcp: remote error: " + e.Message
+}
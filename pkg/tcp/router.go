@@ -0,0 +1,86 @@
+package tcp
+
+import (
+	"fmt"
+	"sync"
+)
+
+// HandlerFunc 消息处理函数，返回错误时会以错误包回复请求方
+type HandlerFunc func(ctx *Context) error
+
+// Context 单条消息的处理上下文
+type Context struct {
+	Conn   *Conn  // 当前连接
+	Packet Packet // 收到的消息
+	codec  Codec
+}
+
+// MsgID 消息ID
+func (c *Context) MsgID() uint32 {
+	return c.Packet.MsgID
+}
+
+// Bind 将消息体解码到 v
+func (c *Context) Bind(v any) error {
+	return c.codec.Unmarshal(c.Packet.Body, v)
+}
+
+// Reply 回复请求，单向消息（Seq 为 0）无需回复时直接忽略
+func (c *Context) Reply(v any) error {
+	if c.Packet.Seq == 0 {
+		return nil
+	}
+
+	body, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.Conn.writePacket(Packet{MsgID: c.Packet.MsgID, Seq: c.Packet.Seq, Body: body})
+}
+
+// Router 按消息ID分发消息
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[uint32]HandlerFunc
+	notFound HandlerFunc
+}
+
+// NewRouter 创建消息路由
+func NewRouter() *Router {
+	return &Router{handlers: make(map[uint32]HandlerFunc)}
+}
+
+// Handle 注册消息处理函数
+func (r *Router) Handle(msgID uint32, handler HandlerFunc) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[msgID] = handler
+	return r
+}
+
+// NotFound 设置未注册消息的处理函数
+func (r *Router) NotFound(handler HandlerFunc) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notFound = handler
+	return r
+}
+
+// dispatch 分发消息
+func (r *Router) dispatch(ctx *Context) error {
+	r.mu.RLock()
+	handler, ok := r.handlers[ctx.Packet.MsgID]
+	if !ok {
+		handler = r.notFound
+	}
+	r.mu.RUnlock()
+
+	if handler == nil {
+		return fmt.Errorf("no handler for message %d", ctx.Packet.MsgID)
+	}
+
+	return handler(ctx)
+}
@@ -1,65 +1,258 @@
 package tcp
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"net"
-	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/ants"
+
+	"go.uber.org/zap"
 )
 
-// TCPServer 结构体表示TCP服务器
+// ServerConfig 定义了 TCP 服务器的配置
+type ServerConfig struct {
+	Addr         string             // 监听地址
+	Framer       Framer             // 拆包方式，默认长度前缀
+	Codec        Codec              // 消息体编解码，默认 JSON
+	Router       *Router            // 消息路由
+	IdleTimeout  time.Duration      // 连接空闲超时，超时未收到消息则断开，默认 5 分钟
+	WriteTimeout time.Duration      // 写超时，默认 10 秒
+	MaxConn      int                // 最大连接数，默认 1024
+	Pool         ants.AntsInterface // 连接协程池，为空时按 MaxConn 创建
+	Logger       *zap.Logger        // 日志，默认 variable.Logs
+	OnConnect    func(c *Conn)      // 连接建立回调
+	OnClose      func(c *Conn)      // 连接关闭回调
+}
+
+// TCPServer 长连接 TCP 服务器
 type TCPServer struct {
-	address string
+	config   ServerConfig
+	manager  *ConnManager
+	pool     ants.AntsInterface
+	ownPool  bool
+	listener net.Listener
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	closing  atomic.Bool
 }
 
 // NewTCPServer 创建一个新的TCPServer
-func NewTCPServer(address string) *TCPServer {
-	return &TCPServer{address: address}
+func NewTCPServer(config ServerConfig) *TCPServer {
+	if config.Framer == nil {
+		config.Framer = LengthFieldFramer{}
+	}
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+	if config.Router == nil {
+		config.Router = NewRouter()
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = 5 * time.Minute
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 10 * time.Second
+	}
+	if config.MaxConn <= 0 {
+		config.MaxConn = 1024
+	}
+	if config.Logger == nil {
+		config.Logger = defaultLogger()
+	}
+
+	return &TCPServer{
+		config:  config,
+		manager: &ConnManager{},
+		pool:    config.Pool,
+	}
+}
+
+// Router 获取消息路由
+func (s *TCPServer) Router() *Router {
+	return s.config.Router
+}
+
+// Conns 获取连接管理器
+func (s *TCPServer) Conns() *ConnManager {
+	return s.manager
 }
 
-// Start 启动TCP服务器
-func (s *TCPServer) Start() {
-	// 监听指定的端口
-	listener, err := net.Listen("tcp", s.address)
+// Start 启动TCP服务器，阻塞直到 Stop 被调用或监听失败
+func (s *TCPServer) Start() error {
+	if s.pool == nil {
+		pool, err := ants.NewAnts(s.config.MaxConn)
+		if err != nil {
+			return err
+		}
+		s.pool = pool
+		s.ownPool = true
+	}
+
+	listener, err := net.Listen("tcp", s.config.Addr)
 	if err != nil {
-		fmt.Println("Error listening:", err.Error())
-		os.Exit(1)
+		return fmt.Errorf("tcp listen %s: %w", s.config.Addr, err)
 	}
-	defer listener.Close()
-	fmt.Println("Listening on", s.address)
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	s.config.Logger.Info("TCP server listening", zap.String("addr", s.config.Addr))
 
 	for {
-		// 接受来自客户端的连接
-		conn, err := listener.Accept()
+		raw, err := listener.Accept()
 		if err != nil {
-			fmt.Println("Error accepting:", err.Error())
+			if s.closing.Load() {
+				return nil
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+
+			return err
+		}
+
+		if s.manager.Len() >= s.config.MaxConn {
+			s.config.Logger.Warn("TCP connection limit reached", zap.String("remote", raw.RemoteAddr().String()))
+			_ = raw.Close()
 			continue
 		}
-		go s.handleRequest(conn)
+
+		conn := newConn(raw, s.config.Framer, s.config.Codec, s.config.WriteTimeout)
+		s.manager.add(conn)
+		s.wg.Add(1)
+
+		if err := s.pool.Submit(func() { s.serve(conn) }); err != nil {
+			s.config.Logger.Error("TCP submit connection failed", zap.Error(err))
+			s.release(conn)
+		}
 	}
 }
 
-// handleRequest 处理客户端请求
-func (s *TCPServer) handleRequest(conn net.Conn) {
-	defer conn.Close()
-	reader := bufio.NewReader(conn)
-	message, err := reader.ReadString('\n')
-	if err != nil {
-		fmt.Println("Error reading:", err.Error())
+// serve 处理单个连接的读循环，同一连接上的消息按顺序处理
+func (s *TCPServer) serve(conn *Conn) {
+	defer s.release(conn)
+
+	if s.config.OnConnect != nil {
+		s.config.OnConnect(conn)
+	}
+
+	for !s.closing.Load() {
+		_ = conn.raw.SetReadDeadline(time.Now().Add(s.config.IdleTimeout))
+
+		// Stop 先设置 closing 再设置截止时间，这里重新检查，避免覆盖 Stop 设置的截止时间
+		if s.closing.Load() {
+			return
+		}
+
+		packet, err := conn.readPacket()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && !s.closing.Load() {
+				s.config.Logger.Info("TCP connection idle timeout", zap.Uint64("conn", conn.ID()))
+			}
+			return
+		}
+
+		s.handle(conn, packet)
+	}
+}
+
+// handle 分发单条消息，处理函数出错或 panic 时回复错误包
+func (s *TCPServer) handle(conn *Conn, packet Packet) {
+	ctx := &Context{Conn: conn, Packet: packet, codec: s.config.Codec}
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return s.config.Router.dispatch(ctx)
+	}()
+
+	if err == nil {
 		return
 	}
-	fmt.Printf("Message received: %s", message)
 
-	// 回复客户端
-	_, err = conn.Write([]byte("Message received\n"))
-	if err != nil {
-		fmt.Println("Error writing:", err.Error())
+	s.config.Logger.Error("TCP handle message failed",
+		zap.Uint64("conn", conn.ID()),
+		zap.Uint32("msgID", packet.MsgID),
+		zap.Error(err),
+	)
+
+	if packet.Seq != 0 {
+		_ = conn.writePacket(Packet{MsgID: packet.MsgID, Seq: packet.Seq, Flag: flagError, Body: []byte(err.Error())})
+	}
+}
+
+// release 关闭并移除连接
+func (s *TCPServer) release(conn *Conn) {
+	_ = conn.Close()
+	s.manager.remove(conn)
+
+	if s.config.OnClose != nil {
+		s.config.OnClose(conn)
+	}
+
+	s.wg.Done()
+}
+
+// Stop 优雅停止：不再接受新连接，等待正在处理的消息完成，超时后强制关闭
+func (s *TCPServer) Stop(ctx context.Context) error {
+	if !s.closing.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.listener != nil {
+		_ = s.listener.Close()
 	}
+	s.mu.Unlock()
+
+	// 打断阻塞中的读取，正在执行的处理函数会在完成后退出
+	s.manager.Range(func(c *Conn) bool {
+		_ = c.raw.SetReadDeadline(time.Now())
+		return true
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.manager.Range(func(c *Conn) bool {
+			_ = c.Close()
+			return true
+		})
+		err = ctx.Err()
+	}
+
+	if s.ownPool {
+		s.pool.Release()
+	}
+
+	s.config.Logger.Info("TCP server stopped", zap.String("addr", s.config.Addr))
+	return err
+}
 
-	//如果message是stop
-	if message == "stop\n" {
-		//打印
-		fmt.Println("Stop server")
-		//(event_manage.CreateEventManageFactory()).FuzzyCall(variable.EventDestroyPrefix)
+// defaultLogger 默认日志
+func defaultLogger() *zap.Logger {
+	if variable.Logs != nil {
+		return variable.Logs
 	}
+	return zap.NewNop()
 }
@@ -1,26 +1,128 @@
 package udp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
-	"tool/global/variable"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
 )
 
-func Send(message string) {
+// ErrNoAck 重试后仍未收到确认
+var ErrNoAck = errors.New("udp: no ack received")
+
+// Client 任务触发客户端
+type Client struct {
+	config JobConfig
+}
+
+// NewClient 创建任务触发客户端
+func NewClient(config JobConfig) *Client {
+	return &Client{config: config}
+}
+
+// Send 按配置向任务服务投递任务，返回消息ID
+func Send(ctx context.Context, job string, payload any) (string, error) {
+	return NewClient(loadConfig()).Send(ctx, job, payload)
+}
 
-	ip := variable.ConfigYml.GetString("JobServer.Ip") + ":" + variable.ConfigYml.GetString("JobServer.Port")
+// Send 投递任务并等待确认，超时未确认时重试，服务端按消息ID去重，因此重试不会重复执行
+func (c *Client) Send(ctx context.Context, job string, payload any) (string, error) {
+	if c.config.Secret == "" {
+		return "", ErrSecretMissing
+	}
 
-	conn, err := net.Dial("udp", ip)
+	body, err := json.Marshal(payload)
 	if err != nil {
-		fmt.Println("Error connecting to server:", err)
-		return
+		return "", err
+	}
+
+	msg := Message{
+		Type:      TypeJob,
+		ID:        uuid.New().String(),
+		Job:       job,
+		Payload:   body,
+		Timestamp: time.Now().Unix(),
 	}
-	defer conn.Close()
 
-	_, err = conn.Write([]byte(message))
+	packet, err := encode(msg, c.config.Secret)
 	if err != nil {
-		fmt.Println("Error sending message:", err)
+		return "", err
 	}
 
-	fmt.Println("Message sent:", message)
+	if len(packet) > maxPacketSize {
+		return "", fmt.Errorf("udp: packet too large: %d", len(packet))
+	}
+
+	conn, err := net.Dial("udp", c.config.Addr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, maxPacketSize)
+
+	for attempt := 0; attempt <= c.config.Retries; attempt++ {
+		if _, err := conn.Write(packet); err != nil {
+			return "", err
+		}
+
+		// 每次重试逐步延长等待时间
+		deadline := time.Now().Add(c.config.AckTimeout * time.Duration(attempt+1))
+		ctxDeadline, ok := ctx.Deadline()
+		if ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		_ = conn.SetReadDeadline(deadline)
+
+		ack, err := c.waitAck(conn, buf, msg.ID)
+		if err == nil {
+			if ack.Status != StatusOK {
+				return msg.ID, fmt.Errorf("udp: job %s %s", job, ack.Status)
+			}
+			return msg.ID, nil
+		}
+
+		// 服务端未启动时会收到 ICMP 端口不可达，同样等待后重试
+		var netErr net.Error
+		switch {
+		case errors.As(err, &netErr) && netErr.Timeout():
+		case errors.Is(err, syscall.ECONNREFUSED):
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(deadline)):
+			}
+		default:
+			return msg.ID, err
+		}
+
+		// 读超时与 ctx 的截止时间相同时，ctx 可能还未标记为超时
+		if ctx.Err() != nil || (ok && !time.Now().Before(ctxDeadline)) {
+			<-ctx.Done()
+			return msg.ID, ctx.Err()
+		}
+	}
+
+	return msg.ID, ErrNoAck
+}
+
+// waitAck 读取直到收到指定消息的确认，忽略签名错误或不相关的数据报
+func (c *Client) waitAck(conn net.Conn, buf []byte, id string) (Message, error) {
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return Message{}, err
+		}
+
+		ack, err := decode(buf[:n], c.config.Secret)
+		if err != nil || ack.Type != TypeAck || ack.ID != id {
+			continue
+		}
+
+		return ack, nil
+	}
 }
@@ -0,0 +1,101 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeServer 接收数据报，第 ackAt 次（从 1 开始）起回复确认，0 表示从不回复
+func fakeServer(t *testing.T, ackAt int) (string, <-chan Message) {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	received := make(chan Message, 16)
+	go func() {
+		buf := make([]byte, maxPacketSize)
+		for count := 1; ; count++ {
+			n, remote, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			msg, err := decode(buf[:n], testSecret)
+			if err != nil {
+				continue
+			}
+			received <- msg
+
+			if ackAt > 0 && count >= ackAt {
+				// 先回复一个无关的确认，客户端应忽略
+				other, _ := encode(Message{Type: TypeAck, ID: "other", Status: StatusOK}, testSecret)
+				_, _ = conn.WriteToUDP(other, remote)
+
+				ack, _ := encode(Message{Type: TypeAck, ID: msg.ID, Status: StatusOK}, testSecret)
+				_, _ = conn.WriteToUDP(ack, remote)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), received
+}
+
+func clientConfig(addr string) JobConfig {
+	return JobConfig{Addr: addr, Secret: testSecret, AckTimeout: 50 * time.Millisecond, Retries: 2}
+}
+
+func TestClientRetry(t *testing.T) {
+	addr, received := fakeServer(t, 2)
+
+	id, err := NewClient(clientConfig(addr)).Send(context.Background(), "job", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重试使用相同的消息ID，服务端据此去重
+	for i := 0; i < 2; i++ {
+		msg := <-received
+		if msg.ID != id || msg.Job != "job" {
+			t.Fatalf("attempt %d = %+v", i+1, msg)
+		}
+	}
+}
+
+func TestClientNoAck(t *testing.T) {
+	addr, received := fakeServer(t, 0)
+
+	_, err := NewClient(clientConfig(addr)).Send(context.Background(), "job", nil)
+	if !errors.Is(err, ErrNoAck) {
+		t.Fatalf("err = %v, want ErrNoAck", err)
+	}
+
+	// 首次发送 + Retries 次重试
+	if n := len(received); n != 3 {
+		t.Fatalf("sent %d times, want 3", n)
+	}
+}
+
+func TestClientContextCanceled(t *testing.T) {
+	addr, _ := fakeServer(t, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	config := clientConfig(addr)
+	config.Retries = 100
+
+	start := time.Now()
+	if _, err := NewClient(config).Send(ctx, "job", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("send did not stop at context deadline")
+	}
+}
@@ -0,0 +1,57 @@
+package udp
+
+import (
+	"time"
+	"tool/global/variable"
+)
+
+// JobConfig 任务调度服务配置
+type JobConfig struct {
+	Addr         string        // 服务地址 ip:port
+	Secret       string        // HMAC 签名密钥，客户端与服务端需一致
+	AckTimeout   time.Duration // 等待确认的超时时间
+	Retries      int           // 未收到确认时的重试次数
+	MaxClockSkew time.Duration // 允许的时钟偏差，超出的消息视为重放
+	DedupeTTL    time.Duration // 消息ID去重的保留时间，需大于 MaxClockSkew
+}
+
+// loadConfig 加载配置
+//
+// JobServer:
+//
+//	Ip: "127.0.0.1"
+//	Port: 9081
+//	Secret: "xxx"         # HMAC 签名密钥，必填
+//	AckTimeout: 500       # 等待确认超时(毫秒)
+//	Retries: 3            # 重试次数
+//	MaxClockSkew: 60      # 允许的时钟偏差(秒)
+//	DedupeTTL: 600        # 去重保留时间(秒)
+//	WorkNum: 10           # 任务执行协程数，cmd/job 按此创建协程池
+func loadConfig() JobConfig {
+	config := JobConfig{
+		Addr:         variable.ConfigYml.GetString("JobServer.Ip") + ":" + variable.ConfigYml.GetString("JobServer.Port"),
+		Secret:       variable.ConfigYml.GetString("JobServer.Secret"),
+		AckTimeout:   time.Duration(variable.ConfigYml.GetInt("JobServer.AckTimeout")) * time.Millisecond,
+		Retries:      variable.ConfigYml.GetInt("JobServer.Retries"),
+		MaxClockSkew: time.Duration(variable.ConfigYml.GetInt("JobServer.MaxClockSkew")) * time.Second,
+		DedupeTTL:    time.Duration(variable.ConfigYml.GetInt("JobServer.DedupeTTL")) * time.Second,
+	}
+
+	if config.AckTimeout <= 0 {
+		config.AckTimeout = 500 * time.Millisecond
+	}
+
+	if config.Retries <= 0 {
+		config.Retries = 3
+	}
+
+	if config.MaxClockSkew <= 0 {
+		config.MaxClockSkew = time.Minute
+	}
+
+	if config.DedupeTTL <= config.MaxClockSkew {
+		config.DedupeTTL = 10 * config.MaxClockSkew
+	}
+
+	return config
+}
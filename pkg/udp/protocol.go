@@ -0,0 +1,78 @@
+package udp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+)
+
+// 消息类型
+const (
+	TypeJob = "job" // 触发任务
+	TypeAck = "ack" // 确认
+)
+
+// 确认状态
+const (
+	StatusOK         = "ok"          // 已接收并投递
+	StatusUnknownJob = "unknown_job" // 未注册的任务
+	StatusRejected   = "rejected"    // 消息过期或格式错误
+)
+
+// maxPacketSize 单个数据报的最大长度
+const maxPacketSize = 64 * 1024
+
+var (
+	ErrSecretMissing    = errors.New("udp: JobServer.Secret is required and must be the same for api and job")
+	ErrInvalidSignature = errors.New("udp: invalid signature")
+	ErrInvalidPacket    = errors.New("udp: invalid packet")
+)
+
+// Message 任务协议消息，每个数据报为 32 字节 HMAC-SHA256 签名 + JSON 消息体
+type Message struct {
+	Type      string          `json:"type"`              // 消息类型 job / ack
+	ID        string          `json:"id"`                // 消息ID，用于确认与去重
+	Job       string          `json:"job,omitempty"`     // 任务名称
+	Payload   json.RawMessage `json:"payload,omitempty"` // 任务参数
+	Status    string          `json:"status,omitempty"`  // 确认状态
+	Timestamp int64           `json:"ts"`                // 发送时间（Unix 秒）
+}
+
+// encode 编码并签名
+func encode(msg Message, secret string) ([]byte, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	packet := sign(body, secret)
+	return append(packet, body...), nil
+}
+
+// decode 校验签名并解码
+func decode(packet []byte, secret string) (Message, error) {
+	var msg Message
+
+	if len(packet) <= sha256.Size {
+		return msg, ErrInvalidPacket
+	}
+
+	signature, body := packet[:sha256.Size], packet[sha256.Size:]
+	if !hmac.Equal(signature, sign(body, secret)) {
+		return msg, ErrInvalidSignature
+	}
+
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return msg, ErrInvalidPacket
+	}
+
+	return msg, nil
+}
+
+// sign 计算签名
+func sign(body []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,58 @@
+package udp
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	msg := Message{Type: TypeJob, ID: "id", Job: "job", Payload: []byte(`{"a":1}`), Timestamp: 1700000000}
+
+	packet, err := encode(msg, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := decode(packet, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != msg.Type || got.ID != msg.ID || got.Job != msg.Job || string(got.Payload) != string(msg.Payload) || got.Timestamp != msg.Timestamp {
+		t.Fatalf("decode = %+v, want %+v", got, msg)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	packet, err := encode(Message{Type: TypeJob, ID: "id"}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte(nil), packet...)
+	tampered[len(tampered)-2] ^= 1
+
+	badSignature := append([]byte(nil), packet...)
+	badSignature[0] ^= 1
+
+	invalidJSON := append(sign([]byte("{"), "secret"), '{')
+
+	tests := []struct {
+		name   string
+		packet []byte
+		secret string
+		err    error
+	}{
+		{"wrong secret", packet, "other", ErrInvalidSignature},
+		{"tampered body", tampered, "secret", ErrInvalidSignature},
+		{"tampered signature", badSignature, "secret", ErrInvalidSignature},
+		{"signature only", packet[:sha256.Size], "secret", ErrInvalidPacket},
+		{"empty", nil, "secret", ErrInvalidPacket},
+		{"invalid json", invalidJSON, "secret", ErrInvalidPacket},
+	}
+	for _, tt := range tests {
+		if _, err := decode(tt.packet, tt.secret); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/ants"
+
+	"go.uber.org/zap"
+)
+
+// Handler 任务处理函数，payload 为客户端投递的 JSON 参数
+type Handler func(ctx context.Context, payload []byte) error
+
+var jobs sync.Map // 任务名 => Handler
+
+// RegisterJob 注册任务处理函数
+func RegisterJob(name string, handler Handler) {
+	if _, loaded := jobs.LoadOrStore(name, handler); loaded {
+		panic(fmt.Sprintf("udp: job %s already registered", name))
+	}
+}
+
+// Server 任务调度服务
+type Server struct {
+	config  JobConfig
+	conn    *net.UDPConn
+	pool    ants.AntsInterface
+	mu      sync.Mutex           // 保护 conn 和 seen
+	seen    map[string]time.Time // 已接收的消息ID => 过期时间
+	closing atomic.Bool
+	wg      sync.WaitGroup
+}
+
+// NewServer 按配置创建任务调度服务
+func NewServer(pool ants.AntsInterface) *Server {
+	return &Server{
+		config: loadConfig(),
+		pool:   pool,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// ListenAndServe 监听并处理任务，阻塞直到 Stop 被调用
+func (s *Server) ListenAndServe() error {
+	if s.config.Secret == "" {
+		return ErrSecretMissing
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	// 监听期间 Stop 可能已被调用
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	if s.closing.Load() {
+		_ = conn.Close()
+		return nil
+	}
+
+	variable.Logs.Info("Job server is listening", zap.String("addr", s.config.Addr))
+
+	go s.cleanup()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, remote, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if s.closing.Load() {
+				return nil
+			}
+			variable.Logs.Error("Job server read error", zap.Error(err))
+			continue
+		}
+
+		s.handle(append([]byte(nil), buf[:n]...), remote)
+	}
+}
+
+// handle 校验消息、去重、确认并投递到协程池执行
+func (s *Server) handle(packet []byte, remote *net.UDPAddr) {
+	msg, err := decode(packet, s.config.Secret)
+	if err != nil {
+		// 未通过签名校验的数据报不做任何回应
+		variable.Logs.Warn("Job server dropped packet", zap.String("remote", remote.String()), zap.Error(err))
+		return
+	}
+
+	if msg.Type != TypeJob || msg.ID == "" {
+		return
+	}
+
+	skew := time.Since(time.Unix(msg.Timestamp, 0))
+	if skew > s.config.MaxClockSkew || skew < -s.config.MaxClockSkew {
+		s.ack(msg.ID, StatusRejected, remote)
+		return
+	}
+
+	handler, ok := jobs.Load(msg.Job)
+	if !ok {
+		s.ack(msg.ID, StatusUnknownJob, remote)
+		return
+	}
+
+	// 重复的消息（客户端未收到确认而重发）只确认，不再执行
+	if !s.markSeen(msg.ID) {
+		s.ack(msg.ID, StatusOK, remote)
+		return
+	}
+
+	s.wg.Add(1)
+	err = s.pool.Submit(func() {
+		defer s.wg.Done()
+		s.run(msg, handler.(Handler))
+	})
+	if err != nil {
+		s.wg.Done()
+		s.forget(msg.ID)
+		variable.Logs.Error("Job submit failed", zap.String("job", msg.Job), zap.Error(err))
+		return
+	}
+
+	s.ack(msg.ID, StatusOK, remote)
+}
+
+// run 执行任务
+func (s *Server) run(msg Message, handler Handler) {
+	defer func() {
+		if r := recover(); r != nil {
+			variable.Logs.Error("Job panic", zap.String("job", msg.Job), zap.String("id", msg.ID), zap.Any("panic", r))
+		}
+	}()
+
+	start := time.Now()
+	if err := handler(context.Background(), msg.Payload); err != nil {
+		variable.Logs.Error("Job failed", zap.String("job", msg.Job), zap.String("id", msg.ID), zap.Error(err))
+		return
+	}
+
+	variable.Logs.Info("Job completed", zap.String("job", msg.Job), zap.String("id", msg.ID), zap.Duration("latency", time.Since(start)))
+}
+
+// ack 回复确认
+func (s *Server) ack(id, status string, remote *net.UDPAddr) {
+	packet, err := encode(Message{Type: TypeAck, ID: id, Status: status, Timestamp: time.Now().Unix()}, s.config.Secret)
+	if err != nil {
+		return
+	}
+
+	if _, err := s.conn.WriteToUDP(packet, remote); err != nil {
+		variable.Logs.Error("Job ack failed", zap.String("id", id), zap.Error(err))
+	}
+}
+
+// markSeen 记录消息ID，已存在时返回 false
+func (s *Server) markSeen(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[id]; ok {
+		return false
+	}
+
+	s.seen[id] = time.Now().Add(s.config.DedupeTTL)
+	return true
+}
+
+// forget 删除消息ID，投递失败时允许客户端重试
+func (s *Server) forget(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.seen, id)
+}
+
+// cleanup 定期清理过期的消息ID
+func (s *Server) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if s.closing.Load() {
+			return
+		}
+
+		now := time.Now()
+
+		s.mu.Lock()
+		for id, expire := range s.seen {
+			if now.After(expire) {
+				delete(s.seen, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Stop 停止接收新任务，并等待正在执行的任务完成
+func (s *Server) Stop(ctx context.Context) error {
+	if !s.closing.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn != nil {
+		_ = conn.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("udp: timeout waiting for running jobs")
+	}
+}
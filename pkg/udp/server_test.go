@@ -0,0 +1,230 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/ants"
+
+	"go.uber.org/zap"
+)
+
+const testSecret = "test-secret"
+
+func init() {
+	if variable.Logs == nil {
+		variable.Logs = zap.NewNop()
+	}
+}
+
+// testConfig 测试配置，地址为空闲端口
+func testConfig(t *testing.T) JobConfig {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	_ = conn.Close()
+
+	return JobConfig{
+		Addr:         addr,
+		Secret:       testSecret,
+		AckTimeout:   100 * time.Millisecond,
+		Retries:      3,
+		MaxClockSkew: time.Minute,
+		DedupeTTL:    10 * time.Minute,
+	}
+}
+
+// startServer 启动任务服务，测试结束时停止
+func startServer(t *testing.T, config JobConfig) *Server {
+	t.Helper()
+
+	pool, err := ants.NewAnts(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{config: config, pool: pool, seen: make(map[string]time.Time)}
+	go func() {
+		if err := s.ListenAndServe(); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = s.Stop(context.Background())
+	})
+	return s
+}
+
+// registerCounter 注册计数任务，返回执行次数，测试结束时注销
+func registerCounter(t *testing.T, name string) *atomic.Int32 {
+	var count atomic.Int32
+	RegisterJob(name, func(ctx context.Context, payload []byte) error {
+		count.Add(1)
+		return nil
+	})
+	t.Cleanup(func() { jobs.Delete(name) })
+	return &count
+}
+
+// exchange 发送原始数据报并等待确认，超时返回 nil
+func exchange(t *testing.T, addr string, packet []byte) *Message {
+	t.Helper()
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, maxPacketSize)
+
+	// 服务可能还未开始监听，重发几次
+	for i := 0; i < 10; i++ {
+		if _, err := conn.Write(packet); err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			// 端口不可达时立即返回，等待服务启动
+			time.Sleep(20 * time.Millisecond)
+			continue
+		}
+		ack, err := decode(buf[:n], testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &ack
+	}
+	return nil
+}
+
+func TestServerJob(t *testing.T) {
+	config := testConfig(t)
+	startServer(t, config)
+	count := registerCounter(t, "test_job")
+
+	id, err := NewClient(config).Send(context.Background(), "test_job", map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == "" {
+		t.Fatal("empty message id")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for count.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("job ran %d times, want 1", count.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerUnknownJob(t *testing.T) {
+	config := testConfig(t)
+	startServer(t, config)
+
+	_, err := NewClient(config).Send(context.Background(), "missing_job", nil)
+	if err == nil || err.Error() != "udp: job missing_job "+StatusUnknownJob {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestServerDedupe(t *testing.T) {
+	config := testConfig(t)
+	startServer(t, config)
+	count := registerCounter(t, "dedupe_job")
+
+	packet, err := encode(Message{Type: TypeJob, ID: "dup", Job: "dedupe_job", Timestamp: time.Now().Unix()}, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复的消息同样确认，但只执行一次
+	for i := 0; i < 3; i++ {
+		ack := exchange(t, config.Addr, packet)
+		if ack == nil || ack.ID != "dup" || ack.Status != StatusOK {
+			t.Fatalf("ack %d = %+v", i, ack)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := count.Load(); n != 1 {
+		t.Fatalf("job ran %d times, want 1", n)
+	}
+}
+
+func TestServerClockSkew(t *testing.T) {
+	config := testConfig(t)
+	startServer(t, config)
+	count := registerCounter(t, "skew_job")
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		status string
+	}{
+		{"past", -2 * config.MaxClockSkew, StatusRejected},
+		{"future", 2 * config.MaxClockSkew, StatusRejected},
+		{"within window", -config.MaxClockSkew / 2, StatusOK},
+	}
+	for _, tt := range tests {
+		msg := Message{Type: TypeJob, ID: tt.name, Job: "skew_job", Timestamp: time.Now().Add(tt.offset).Unix()}
+		packet, err := encode(msg, testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ack := exchange(t, config.Addr, packet)
+		if ack == nil || ack.Status != tt.status {
+			t.Fatalf("%s: ack = %+v, want %s", tt.name, ack, tt.status)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := count.Load(); n != 1 {
+		t.Fatalf("job ran %d times, want 1", n)
+	}
+}
+
+func TestServerInvalidSignature(t *testing.T) {
+	config := testConfig(t)
+	startServer(t, config)
+	count := registerCounter(t, "signed_job")
+
+	packet, err := encode(Message{Type: TypeJob, ID: "forged", Job: "signed_job", Timestamp: time.Now().Unix()}, "wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 签名错误的数据报不回复
+	if ack := exchange(t, config.Addr, packet); ack != nil {
+		t.Fatalf("ack = %+v, want none", ack)
+	}
+	if n := count.Load(); n != 0 {
+		t.Fatalf("job ran %d times", n)
+	}
+}
+
+func TestServerSecretMissing(t *testing.T) {
+	config := testConfig(t)
+	config.Secret = ""
+
+	s := &Server{config: config, seen: make(map[string]time.Time)}
+	if err := s.ListenAndServe(); !errors.Is(err, ErrSecretMissing) {
+		t.Fatalf("err = %v, want ErrSecretMissing", err)
+	}
+	if _, err := NewClient(config).Send(context.Background(), "job", nil); !errors.Is(err, ErrSecretMissing) {
+		t.Fatalf("err = %v, want ErrSecretMissing", err)
+	}
+}
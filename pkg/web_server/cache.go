@@ -0,0 +1,386 @@
+package web_server
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/cache"
+	"tool/pkg/event_bus"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ResponseCacheConfig 定义响应缓存的配置
+type ResponseCacheConfig struct {
+	Store   *cache.Store  // 缓存，为空时使用 cache.Default()（Redis / Memcached 由 Cache.Driver 决定）
+	Bus     event_bus.Bus // 事件总线，为空时使用 event_bus.Default()
+	TTL     time.Duration // 缓存时间，默认 1 分钟，响应的 Cache-Control: max-age 更短时以 max-age 为准
+	Query   []string      // 参与缓存 key 的查询参数，为空时使用全部查询参数
+	Headers []string      // 参与缓存 key 的请求头（如 Accept-Language），同时写入 Vary
+	Topics  []string      // 收到这些主题的事件时清除缓存，即底层数据变更时发布的主题
+}
+
+// cachedResponse 缓存的响应
+type cachedResponse struct {
+	Status       int         `json:"status" codec:"status"`
+	Header       http.Header `json:"header" codec:"header"`
+	Body         []byte      `json:"body" codec:"body"`
+	ETag         string      `json:"etag" codec:"etag"`
+	LastModified int64       `json:"last_modified" codec:"last_modified"`
+}
+
+// 不写入缓存的响应头
+var uncachedHeaders = []string{"Set-Cookie", "Date", "Content-Length", "X-Cache"}
+
+// Cache 为路由添加响应缓存
+//
+//	group.GET("/articles", article.List).Cache(web_server.ResponseCacheConfig{
+//		Query:  []string{"page", "size"},
+//		Topics: []string{enum.TopicArticleChanged},
+//	})
+func (r *Route) Cache(config ResponseCacheConfig) *Route {
+	return r.Use(ResponseCache(config))
+}
+
+// ResponseCache 创建响应缓存中间件
+//
+// 只缓存 GET 请求的 200 响应，key 由方法、路径、选定的查询参数和请求头组成；
+// 响应带有 ETag / Last-Modified，客户端携带 If-None-Match / If-Modified-Since 时返回 304；
+// 请求 Cache-Control: no-cache 时跳过读取缓存、no-store 时完全不使用缓存，
+// 响应 Cache-Control: no-store / private 时不写入缓存；
+// 首次请求时订阅 Topics，收到事件后按主题标签清除缓存
+func ResponseCache(config ResponseCacheConfig) gin.HandlerFunc {
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+
+	var (
+		store *cache.Store
+		once  sync.Once
+	)
+
+	tags := make([]string, len(config.Topics))
+	for i, topic := range config.Topics {
+		tags[i] = topicTag(topic)
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		// 缓存和事件总线在请求时才获取，路由注册时配置可能还未加载
+		once.Do(func() {
+			store = config.Store
+			if store == nil {
+				store = cache.Default()
+			}
+
+			if len(config.Topics) > 0 {
+				bus := config.Bus
+				if bus == nil {
+					bus = event_bus.Default()
+				}
+				go invalidateOnEvents(bus, store, config.Topics)
+			}
+		})
+
+		directives := cacheControl(c.GetHeader("Cache-Control"))
+		if _, ok := directives["no-store"]; ok {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key := responseCacheKey(c, config)
+
+		if _, ok := directives["no-cache"]; !ok {
+			var entry cachedResponse
+			err := store.Get(ctx, key, &entry)
+			if err == nil {
+				c.Header("X-Cache", "HIT")
+				writeCachedResponse(c, &entry, config.Headers)
+				c.Abort()
+				return
+			}
+			if !errors.Is(err, cache.ErrNotFound) {
+				variable.Logs.Warn("响应缓存读取失败", zap.String("key", key), zap.Error(err))
+			}
+		}
+
+		// 缓冲响应，处理完成后再补充 ETag 等响应头并写出
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		func() {
+			// 处理函数 panic 时也要恢复，recovery 中间件需要写出错误响应
+			defer func() { c.Writer = w.ResponseWriter }()
+			c.Next()
+		}()
+
+		entry := &cachedResponse{
+			Status: w.status,
+			Header: w.Header().Clone(),
+			Body:   w.body.Bytes(),
+		}
+
+		ttl, cacheable := responseTTL(entry, config.TTL)
+		if entry.Status == http.StatusOK {
+			entry.ETag = w.Header().Get("ETag")
+			if entry.ETag == "" {
+				sum := md5.Sum(entry.Body)
+				entry.ETag = `"` + hex.EncodeToString(sum[:]) + `"`
+			}
+
+			entry.LastModified = time.Now().Unix()
+			if t, err := http.ParseTime(w.Header().Get("Last-Modified")); err == nil {
+				entry.LastModified = t.Unix()
+			}
+		}
+
+		if cacheable {
+			for _, name := range uncachedHeaders {
+				entry.Header.Del(name)
+			}
+			if err := store.Set(ctx, key, entry, ttl, tags...); err != nil {
+				variable.Logs.Warn("响应缓存写入失败", zap.String("key", key), zap.Error(err))
+			}
+		}
+
+		c.Header("X-Cache", "MISS")
+		writeCachedResponse(c, entry, config.Headers)
+	}
+}
+
+// responseCacheKey 按方法、路径、查询参数和请求头生成缓存 key
+func responseCacheKey(c *gin.Context, config ResponseCacheConfig) string {
+	query := c.Request.URL.Query()
+	if len(config.Query) > 0 {
+		selected := url.Values{}
+		for _, name := range config.Query {
+			if values, ok := query[name]; ok {
+				selected[name] = values
+			}
+		}
+		query = selected
+	}
+
+	var b strings.Builder
+	b.WriteString(c.Request.Method)
+	b.WriteString(" ")
+	b.WriteString(c.Request.URL.Path)
+	b.WriteString("?")
+	b.WriteString(query.Encode()) // Encode 按参数名排序
+
+	for _, name := range config.Headers {
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteString(": ")
+		b.WriteString(c.GetHeader(name))
+	}
+
+	sum := md5.Sum([]byte(b.String()))
+	return "response:" + hex.EncodeToString(sum[:])
+}
+
+// responseTTL 根据响应判断是否可以缓存及缓存时间
+func responseTTL(entry *cachedResponse, ttl time.Duration) (time.Duration, bool) {
+	if entry.Status != http.StatusOK || len(entry.Header.Values("Set-Cookie")) > 0 {
+		return 0, false
+	}
+
+	directives := cacheControl(entry.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return 0, false
+	}
+	if _, ok := directives["private"]; ok {
+		return 0, false
+	}
+
+	if value, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+		if maxAge := time.Duration(seconds) * time.Second; maxAge < ttl {
+			ttl = maxAge
+		}
+	}
+
+	return ttl, true
+}
+
+// writeCachedResponse 写出响应，条件请求命中时返回 304
+func writeCachedResponse(c *gin.Context, entry *cachedResponse, vary []string) {
+	header := c.Writer.Header()
+	for name, values := range entry.Header {
+		header[name] = values
+	}
+
+	if len(vary) > 0 {
+		header.Set("Vary", strings.Join(vary, ", "))
+	}
+
+	if entry.ETag != "" {
+		header.Set("ETag", entry.ETag)
+		header.Set("Last-Modified", time.Unix(entry.LastModified, 0).UTC().Format(http.TimeFormat))
+
+		// 未指定时要求浏览器每次都用 ETag 验证，数据变更后缓存清除即可生效
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", "no-cache")
+		}
+
+		if notModified(c.Request, entry) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			c.Status(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+	}
+
+	c.Status(entry.Status)
+	_, _ = c.Writer.Write(entry.Body)
+}
+
+// notModified 判断条件请求是否命中，If-None-Match 优先于 If-Modified-Since
+func notModified(r *http.Request, entry *cachedResponse) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := strings.TrimPrefix(entry.ETag, "W/")
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return entry.LastModified <= t.Unix()
+		}
+	}
+
+	return false
+}
+
+// cacheControl 解析 Cache-Control 指令
+func cacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+	return directives
+}
+
+// topicTag 主题对应的缓存标签
+func topicTag(topic string) string {
+	return "topic:" + topic
+}
+
+// invalidateOnEvents 订阅主题，收到事件后清除对应标签的缓存
+// 订阅断开（如消费过慢被关闭）后自动重新订阅
+func invalidateOnEvents(bus event_bus.Bus, store *cache.Store, topics []string) {
+	offsets := make(map[string]string, len(topics))
+	for _, topic := range topics {
+		offsets[topic] = ""
+	}
+
+	for {
+		events, err := bus.Subscribe(context.Background(), offsets)
+		if err != nil {
+			variable.Logs.Warn("响应缓存订阅失败", zap.Strings("topics", topics), zap.Error(err))
+			time.Sleep(3 * time.Second)
+			continue
+		}
+
+		// 重新订阅期间可能漏掉事件，先全部清除
+		invalidateTopics(store, topics)
+
+		for evt := range events {
+			invalidateTopics(store, []string{evt.Topic})
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+// invalidateTopics 清除主题对应的缓存
+func invalidateTopics(store *cache.Store, topics []string) {
+	tags := make([]string, len(topics))
+	for i, topic := range topics {
+		tags[i] = topicTag(topic)
+	}
+	sort.Strings(tags)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := store.InvalidateTags(ctx, tags...); err != nil {
+		variable.Logs.Warn("响应缓存清除失败", zap.Strings("tags", tags), zap.Error(err))
+	}
+}
+
+// bufferedWriter 缓冲响应，不直接写出
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush 缓冲期间不向客户端输出
+func (w *bufferedWriter) Flush() {}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net/http"
 	"time"
 	"tool/global/variable"
 
@@ -16,21 +17,34 @@ import (
 // ResponseWriter 包装 gin.ResponseWriter 以捕获响应数据
 type ResponseWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body  *bytes.Buffer
+	size  int // 响应体总长度
+	limit int // 最多缓存的长度，避免 SSE 等长连接响应无限占用内存
 }
 
 func (w *ResponseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	w.size += len(b)
+	if w.body.Len() <= w.limit {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
+// Unwrap 返回底层的 http.ResponseWriter，供 http.ResponseController 使用
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // LoggerMiddleware 使用 zap 和 lumberjack 记录 HTTP 请求日志的中间件
 func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
+		// 设置日志中记录字符串的最大长度
+		maxLogLength := variable.ConfigYml.GetInt("Logs.ResponseLengthMax") // 最大日志长度
+
 		// 捕获响应数据
-		w := &ResponseWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
+		w := &ResponseWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer, limit: maxLogLength}
 		c.Writer = w
 
 		// 读取请求数据
@@ -53,9 +67,6 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		// 请求IP
 		clientIP := c.ClientIP()
 
-		// 设置日志中记录字符串的最大长度
-		maxLogLength := variable.ConfigYml.GetInt("Logs.ResponseLengthMax") // 最大日志长度
-
 		// 请求体日志处理
 		var logReqBody string
 		if len(reqBody) > maxLogLength {
@@ -66,8 +77,8 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		// 响应体日志处理
 		var logRespBody string
-		if w.body.Len() > maxLogLength {
-			logRespBody = fmt.Sprintf("Response body too large to log, length: %d", w.body.Len())
+		if w.size > maxLogLength {
+			logRespBody = fmt.Sprintf("Response body too large to log, length: %d", w.size)
 		} else {
 			logRespBody = w.body.String()
 		}
@@ -0,0 +1,154 @@
+package web_server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"tool/pkg/event_bus"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSEConfig 定义 Server-Sent Events 路由的配置
+type SSEConfig struct {
+	Bus       event_bus.Bus                 // 事件总线，为空时使用 event_bus.Default()
+	Topics    []string                      // 订阅的主题
+	TopicFunc func(c *gin.Context) []string // 按请求解析订阅的主题（如房间），设置后优先于 Topics
+	Heartbeat time.Duration                 // 心跳间隔，默认 15 秒
+	Retry     time.Duration                 // 建议客户端断线后的重连间隔，默认 3 秒
+}
+
+// SSE 是添加 Server-Sent Events 路由的快捷方法
+func (rg *RouterGroup) SSE(path string, config SSEConfig) *Route {
+	return rg.GET(path, SSEHandler(config))
+}
+
+// SSEHandler 创建 Server-Sent Events 处理函数
+// 连接会一直保持，定时发送心跳注释，并支持通过 Last-Event-ID 请求头（或 lastEventId 查询参数）续传
+func SSEHandler(config SSEConfig) gin.HandlerFunc {
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = 15 * time.Second
+	}
+
+	if config.Retry <= 0 {
+		config.Retry = 3 * time.Second
+	}
+
+	return func(c *gin.Context) {
+		topics := config.Topics
+		if config.TopicFunc != nil {
+			topics = config.TopicFunc(c)
+		}
+
+		if len(topics) == 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "no topics to subscribe"})
+			return
+		}
+
+		// 事件总线在请求时才获取，路由注册时配置可能还未加载
+		bus := config.Bus
+		if bus == nil {
+			bus = event_bus.Default()
+		}
+
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("lastEventId")
+		}
+		cursor := decodeEventID(lastEventID, topics)
+
+		ctx := c.Request.Context()
+
+		events, err := bus.Subscribe(ctx, cursor)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		header.Set("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+		c.Status(http.StatusOK)
+
+		// 服务器设置了 WriteTimeout，长连接需要在每次写入前延长写超时
+		rc := http.NewResponseController(c.Writer)
+		write := func(payload []byte) bool {
+			_ = rc.SetWriteDeadline(time.Now().Add(2 * config.Heartbeat))
+			if _, err := c.Writer.Write(payload); err != nil {
+				return false
+			}
+			return rc.Flush() == nil
+		}
+
+		if !write([]byte(fmt.Sprintf("retry: %d\n\n", config.Retry.Milliseconds()))) {
+			return
+		}
+
+		ticker := time.NewTicker(config.Heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-events:
+				if !ok {
+					return
+				}
+
+				cursor[evt.Topic] = evt.ID
+				if !write(formatEvent(encodeEventID(cursor), evt)) {
+					return
+				}
+			case <-ticker.C:
+				if !write([]byte(": ping\n\n")) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// formatEvent 按 SSE 协议格式化事件，多行内容拆分为多个 data 字段
+func formatEvent(id string, evt event_bus.Event) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("id: " + id + "\n")
+	buf.WriteString("event: " + evt.Topic + "\n")
+
+	for _, line := range bytes.Split(evt.Data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+// encodeEventID 将每个主题的消费位置编码为一个事件ID，浏览器重连时会原样带回
+func encodeEventID(cursor map[string]string) string {
+	values := url.Values{}
+	for topic, id := range cursor {
+		if id != "" {
+			values.Set(topic, id)
+		}
+	}
+	return values.Encode()
+}
+
+// decodeEventID 解析 Last-Event-ID，只保留本次订阅的主题
+func decodeEventID(lastEventID string, topics []string) map[string]string {
+	cursor := make(map[string]string, len(topics))
+
+	values, _ := url.ParseQuery(lastEventID)
+	for _, topic := range topics {
+		cursor[topic] = values.Get(topic)
+	}
+
+	return cursor
+}
@@ -0,0 +1,25 @@
+package yml_config
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration 解析时长配置，支持 200ms、1s 等格式，纯数字按秒处理（兼容旧配置），格式错误时为 0
+// key 只用于日志
+func ParseDuration(key, value string) time.Duration {
+	value = strings.TrimSpace(value)
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("配置 %s 格式错误: %s", key, value)
+		return 0
+	}
+	return duration
+}
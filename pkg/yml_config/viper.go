@@ -76,6 +76,14 @@ func (y *ymlConfig) GetFloat64(key string) float64 {
 	return y.viper.GetFloat64(key)
 }
 
+// 封装 viper.GetStringSlice 方法，如果键不存在，则返回 nil
+func (y *ymlConfig) GetStringSlice(key string) []string {
+	if !y.viper.IsSet(key) {
+		return nil
+	}
+	return y.viper.GetStringSlice(key)
+}
+
 // GetDuration 时间单位格式返回值
 func (y *ymlConfig) GetDuration(keyName string) time.Duration {
 
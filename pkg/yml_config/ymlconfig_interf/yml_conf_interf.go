@@ -8,6 +8,7 @@ type YmlConfigInterf interface {
 	GetString(key string) string
 	GetBool(key string) bool
 	GetFloat64(key string) float64
+	GetStringSlice(key string) []string
 	GetConfig(key string, defaultValue interface{}) interface{}
 	GetDuration(keyName string) time.Duration
 }
@@ -30,7 +30,16 @@ func LoginSubmit(c *gin.Context) {
 		"password": password,
 	}
 
-	result, _ := variable.Pool.SubmitTask(admin.Login, params)
+	ctx := c.Request.Context()
+
+	result, err := variable.Pool.SubmitTask(ctx, func(params map[string]any) (map[string]any, error) {
+		return admin.Login(ctx, params)
+	}, params)
+
+	if err != nil {
+		common.Fail(c, http.StatusInternalServerError, "登录失败", nil)
+		return
+	}
 
 	if result["code"] != 200 {
 		common.Fail(c, http.StatusBadRequest, result["msg"].(string), nil)
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"strconv"
+
+	"tool/global/utils/common"
+	"tool/pkg/mysql"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SqlStats SQL 指纹统计，按指标倒序返回前 N 个
+//
+//	GET /admin/sql/stats?top=20&sort=p99
+//
+// sort: total（总耗时，默认）/ count / avg / p95 / p99 / max / rows / slow
+func SqlStats(c *gin.Context) {
+
+	top, _ := strconv.Atoi(c.DefaultQuery("top", "20"))
+
+	common.Success(c, "获取成功", mysql.TopQueries(top, c.Query("sort")))
+}
+
+// SqlStatsReset 清空 SQL 统计
+//
+//	POST /admin/sql/stats/reset
+func SqlStatsReset(c *gin.Context) {
+
+	mysql.ResetQueryStats()
+
+	common.Success(c, "已清空", nil)
+}
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+
+	"tool/global/utils/common"
+	"tool/global/utils/query_spec"
+	"tool/server/http/model"
+	"tool/server/http/service/admin"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserList 管理员列表
+//
+//	GET /admin/user/list?filter[username][like]=a&sort=-create_time&page=2&size=20
+func UserList(c *gin.Context) {
+
+	spec, err := query_spec.Bind[model.Admin](c)
+	if err != nil {
+		common.Fail(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	result, err := admin.List(c.Request.Context(), spec)
+	if err != nil {
+		common.Fail(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	common.Success(c, "获取成功", result)
+}
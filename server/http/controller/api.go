@@ -1,15 +1,19 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
+	"tool/global/utils/common"
 	"tool/global/utils/db_client"
 	"tool/global/variable"
+	"tool/pkg/mongo"
 	"tool/pkg/session"
 	"tool/pkg/udp"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 // User 定义用户模型
@@ -67,32 +71,40 @@ func GetUsersHandler(c *gin.Context) {
 	c.JSON(200, gin.H{"users": users})
 }
 
+// GetUserMongo 按游标分页查询 ChatGPT 记录，支持 username / cursor / size 查询参数
 func GetUserMongo(c *gin.Context) {
+	var req mongo.CursorRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Fail(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
 
-	//params, _ := c.GetQuery("name")
-
-	db_client.MongoLocal().Collection("t_chatgpt_log").FindOne(c, nil)
-
-	// //Example query usage
-	// //filter := bson.D{{"updated_at", "2023-03-21 16:54:53"}}
-	// result := db_client.MongoLocal.Collection("t_chatgpt_log").FindOne(c, bson.D{})
-
-	// // Decode the result
-	// var doc map[string]interface{}
-	// err := result.Decode(&doc)
-	// if err != nil {
-	// 	variable.Logs.Error("Failed to decode document", zap.Error(err))
-	// } else {
-	// 	fmt.Printf("Found a document: %v\n", doc)
-	// 	variable.Logs.Info("Found a document", zap.Any("document", doc))
+	repo := mongo.NewRepo[bson.M]("t_chatgpt_log").Sort("-create_time")
+	if username := c.Query("username"); username != "" {
+		repo = repo.Where(bson.M{"username": username})
+	}
 
-	// 	c.JSON(200, gin.H{"users": doc, "params": params})
-	// }
+	result, err := repo.CursorPage(c.Request.Context(), req)
+	if errors.Is(err, mongo.ErrCursor) {
+		common.Fail(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		common.Fail(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
 
+	common.Success(c, "ok", result)
 }
 
 func TestUdp(c *gin.Context) {
-	udp.Send("test")
+	id, err := udp.Send(c.Request.Context(), "test", gin.H{"time": time.Now().Unix()})
+	if err != nil {
+		common.Fail(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	common.Success(c, "任务已投递", gin.H{"id": id})
 }
 
 func TestAnt(c *gin.Context) {
@@ -114,13 +126,13 @@ func TestAnt(c *gin.Context) {
 
 	//c.JSON(http.StatusOK, gin.H{"result": result})
 
-	task := func(params map[string]any) map[string]any {
+	task := func(params map[string]any) (map[string]any, error) {
 		// 模拟一个任务
 		time.Sleep(5 * time.Second)
 		variable.Logs.Info("Task completed")
-		return nil
+		return nil, nil
 	}
-	variable.Pool.SubmitTask(task, map[string]any{})
+	variable.Pool.SubmitTask(c.Request.Context(), task, map[string]any{})
 
 	c.JSON(http.StatusOK, gin.H{"message": "task submitted"})
 }
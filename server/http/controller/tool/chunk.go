@@ -0,0 +1,161 @@
+package tool
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"tool/global/utils/common"
+	"tool/global/utils/oss"
+	"tool/global/variable"
+	"tool/pkg/session"
+	"tool/pkg/storage"
+	request "tool/server/http/request/tool"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChunkInit 创建分片上传会话，返回会话 ID、分片大小和分片数量
+// POST /tool/upload/sessions
+func ChunkInit(c *gin.Context) {
+	uploader, ok := chunkUploader(c)
+	if !ok {
+		return
+	}
+
+	params, _ := c.Get("params")
+	p := params.(*request.ChunkInitParams)
+
+	session, err := uploader.Init(c.Request.Context(), p.FileName, p.Size, p.Checksum)
+	if err != nil {
+		chunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// ChunkUpload 上传分片，请求体为分片内容，X-Chunk-Checksum 为分片的十六进制摘要
+// PUT /tool/upload/sessions/:id/chunks/:number
+func ChunkUpload(c *gin.Context) {
+	uploader, ok := chunkUploader(c)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		chunkError(c, oss.ErrChunkInvalid)
+		return
+	}
+
+	checksum := c.GetHeader("X-Chunk-Checksum")
+	if checksum == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing X-Chunk-Checksum header"})
+		return
+	}
+
+	// 分片较大时延长读取的超时时间，不受服务的 ReadTimeout 限制
+	timeout := time.Duration(variable.ConfigYml.GetConfig("ChunkUpload.Timeout", 300).(int)) * time.Second
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(time.Now().Add(timeout))
+	_ = controller.SetWriteDeadline(time.Now().Add(timeout))
+
+	part, err := uploader.UploadChunk(c.Request.Context(), c.Param("id"), number, c.Request.Body, checksum)
+	if err != nil {
+		chunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"number": part.Number, "size": part.Size})
+}
+
+// ChunkStatus 会话状态和已上传的分片号，断点续传时只上传缺少的分片
+// GET /tool/upload/sessions/:id
+func ChunkStatus(c *gin.Context) {
+	uploader, ok := chunkUploader(c)
+	if !ok {
+		return
+	}
+
+	session, uploaded, err := uploader.Status(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		chunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session": session, "uploaded": uploaded})
+}
+
+// ChunkComplete 所有分片上传后合并为文件
+// POST /tool/upload/sessions/:id/complete
+func ChunkComplete(c *gin.Context) {
+	uploader, ok := chunkUploader(c)
+	if !ok {
+		return
+	}
+
+	result, err := uploader.Complete(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		chunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "File uploaded successfully",
+		"file_name":    result.Key,
+		"url":          result.URL,
+		"size":         result.Size,
+		"content_type": result.ContentType,
+	})
+}
+
+// ChunkAbort 取消上传
+// DELETE /tool/upload/sessions/:id
+func ChunkAbort(c *gin.Context) {
+	uploader, ok := chunkUploader(c)
+	if !ok {
+		return
+	}
+
+	if err := uploader.Abort(c.Request.Context(), c.Param("id")); err != nil {
+		chunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Upload aborted"})
+}
+
+// chunkUploader 按配置创建分片上传，会话只能由创建的用户访问
+func chunkUploader(c *gin.Context) (*oss.ChunkUploader, bool) {
+	username, _ := session.GetM(c, "user", "username").(string)
+	if username == "" {
+		common.Fail(c, http.StatusUnauthorized, "未登录", nil)
+		return nil, false
+	}
+
+	uploader, err := oss.NewChunkUploader(oss.LoadChunkConfig(), username)
+	if err != nil {
+		variable.Logs.Error("创建分片上传失败: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload is not available"})
+		return nil, false
+	}
+	return uploader, true
+}
+
+// chunkError 按错误类型返回状态码
+func chunkError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, oss.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, oss.ErrSessionBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, oss.ErrTooLarge) || errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": oss.ErrTooLarge.Error()})
+	case errors.Is(err, oss.ErrChunkInvalid), errors.Is(err, oss.ErrChunkSize), errors.Is(err, oss.ErrChecksumMismatch),
+		errors.Is(err, oss.ErrChunksMissing), errors.Is(err, oss.ErrTooManyChunks), errors.Is(err, oss.ErrChecksumInvalid),
+		errors.Is(err, oss.ErrExtNotAllowed), errors.Is(err, oss.ErrTypeNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload session not found"})
+	default:
+		variable.Logs.Error("分片上传失败: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving file"})
+	}
+}
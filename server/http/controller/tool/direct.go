@@ -0,0 +1,86 @@
+package tool
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"tool/global/utils/oss"
+	"tool/global/variable"
+	"tool/pkg/storage"
+	request "tool/server/http/request/tool"
+	"tool/server/http/service/file"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxNotificationSize 上传通知请求体的最大字节数
+const maxNotificationSize = 1 << 20
+
+// Presign 生成直传到存储的请求，文件不经过应用服务
+// POST /tool/oss/presign
+func Presign(c *gin.Context) {
+	params, _ := c.Get("params")
+	p := params.(*request.PresignParams)
+
+	upload, err := oss.PresignDirect(c.Request.Context(), oss.LoadDirectConfig(), p.FileName, p.ContentType, p.Size, p.Method)
+	switch {
+	case errors.Is(err, oss.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, oss.ErrExtNotAllowed), errors.Is(err, oss.ErrTypeNotAllowed), errors.Is(err, oss.ErrDirectNotSupported),
+		errors.Is(err, storage.ErrSizeRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		variable.Logs.Error("生成直传请求失败: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error signing upload"})
+	default:
+		c.JSON(http.StatusOK, upload)
+	}
+}
+
+// UploadCallback 存储服务的上传通知，校验签名后记录文件
+// 阿里云 OSS 上传回调的响应会返回给上传的客户端；MinIO 需要配置 webhook 通知和 NotifyToken
+// POST /tool/oss/callback/:disk
+func UploadCallback(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxNotificationSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Notification too large"})
+		return
+	}
+
+	disk := c.Param("disk")
+	objects, err := oss.VerifyDirect(c.Request.Context(), oss.LoadDirectConfig(), disk, c.Request, body)
+	switch {
+	case errors.Is(err, storage.ErrInvalidNotification):
+		variable.Logs.Warn("上传通知校验失败", zap.String("disk", disk), zap.String("ip", c.ClientIP()))
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid notification"})
+		return
+	case errors.Is(err, oss.ErrDirectNotSupported), errors.Is(err, storage.ErrNotSupported):
+		c.JSON(http.StatusNotFound, gin.H{"error": oss.ErrDirectNotSupported.Error()})
+		return
+	case err != nil:
+		variable.Logs.Error("处理上传通知失败: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing notification"})
+		return
+	}
+
+	files := make([]gin.H, 0, len(objects))
+	for _, object := range objects {
+		record, err := file.Record(c.Request.Context(), disk, object)
+		if err != nil {
+			variable.Logs.Error("记录直传文件失败", zap.String("key", object.Key), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving file"})
+			return
+		}
+
+		files = append(files, gin.H{
+			"id":           record.ID,
+			"file_name":    record.Key,
+			"url":          oss.DiskURL(disk, record.Key),
+			"size":         record.Size,
+			"content_type": record.ContentType,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File uploaded successfully", "files": files})
+}
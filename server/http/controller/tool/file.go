@@ -0,0 +1,45 @@
+package tool
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"tool/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// File 读取本地磁盘的文件，磁盘配置了 Secret 时只能通过 SignedURL 访问
+// GET /files/:disk/*key
+func File(c *gin.Context) {
+	disk, err := storage.Disk(c.Param("disk"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disk not found"})
+		return
+	}
+
+	local, ok := disk.(*storage.LocalDriver)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disk not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	if err := local.Verify(http.MethodGet, key, c.Request.URL.Query()); err != nil && !errors.Is(err, storage.ErrNotSupported) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, info, err := local.Get(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", info.ContentType)
+	c.Header("ETag", `"`+info.ETag+`"`)
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime, reader.(io.ReadSeeker))
+}
@@ -0,0 +1,64 @@
+package tool
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"tool/global/utils/oss"
+	"tool/global/variable"
+	"tool/pkg/imaging"
+	"tool/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Image 按变体读取图片，第一次访问时生成并缓存到存储
+// 本地磁盘配置了 Secret 时需要带上原图 SignedURL 的 expires 和 signature 参数
+// GET /img/:variant/*key
+func Image(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	reader, info, err := oss.OpenVariant(c.Request.Context(), c.Param("variant"), key, c.Request.URL.Query())
+	switch {
+	case errors.Is(err, oss.ErrVariantNotFound), errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrInvalidKey):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	case errors.Is(err, oss.ErrImageForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, oss.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, imaging.ErrDecode):
+		// 上传目录中的非图片文件（如视频）
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Not an image"})
+		return
+	case errors.Is(err, imaging.ErrTooManyPixels):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		variable.Logs.Error("生成图片变体失败: " + key + ", " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing image"})
+		return
+	}
+	defer reader.Close()
+
+	if info.ETag != "" {
+		etag := `"` + strings.Trim(info.ETag, `"`) + `"`
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Header("ETag", etag)
+	}
+	c.Header("Content-Type", info.ContentType)
+	if c.Query("signature") != "" {
+		// 私有文件不能被共享缓存保存
+		c.Header("Cache-Control", "private, max-age=3600")
+	} else {
+		c.Header("Cache-Control", "public, max-age=31536000")
+	}
+	c.Status(http.StatusOK)
+	_, _ = io.Copy(c.Writer, reader)
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"tool/pkg/mysql"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DbSticky 开启 Mysql 粘滞读，同一个请求内写入后的读取走主库
+// 业务代码需要使用 c.Request.Context() 进行查询
+func DbSticky() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(mysql.WithSticky(c.Request.Context()))
+		c.Next()
+	}
+}
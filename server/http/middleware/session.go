@@ -1,8 +1,9 @@
 package middleware
 
 import (
-	"tool/global/utils/db_client"
+	"sync"
 	"tool/global/variable"
+	pkgMemcached "tool/pkg/memcached"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -32,11 +33,34 @@ func SessionMiddleware() gin.HandlerFunc {
 		})
 	} else if saveMethod == "memcached" {
 
-		// 本地连接 Memcached
-		memcachedClient := db_client.MemLocal()
+		// 使用 memcached.yml 中配置的连接，多个节点时按一致性哈希分布
+		connection := variable.ConfigYml.GetString("Session.Connection")
+		if connection == "" {
+			connection = "Local"
+		}
 
-		store = memcached.NewStore(memcachedClient, "", []byte(secret))
+		store = memcached.NewStore(pkgMemcached.NewClient(connection), "", []byte(secret))
+
+		store.Options(sessions.Options{
+			Path:     "/",
+			MaxAge:   maxAge,
+			HttpOnly: true,
+		})
 	}
 
 	return sessions.Sessions(name, store)
 }
+
+// LazySessionMiddleware 第一次请求时才创建会话中间件，用于在路由的 init 中注册，此时配置还未加载
+func LazySessionMiddleware() gin.HandlerFunc {
+	var (
+		once    sync.Once
+		handler gin.HandlerFunc
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			handler = SessionMiddleware()
+		})
+		handler(c)
+	}
+}
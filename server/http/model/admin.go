@@ -1,13 +1,13 @@
 package model
 
 type Admin struct {
-	ID            int        `gorm:"primaryKey" json:"id"`                      // 主键
-	Username      string     `gorm:"type:varchar(20);not null" json:"username"` // 用户名
-	Password      string     `gorm:"type:varchar(40);not null" json:"password"` // 密码
-	LastLoginTime *LocalTime `gorm:"type:datetime" json:"last_login_time"`      // 上次登录时间
-	LoginStatus   int8       `gorm:"type:tinyint" json:"login_status"`          // 登录状态 0禁用 1启用
-	CreateTime    *LocalTime `gorm:"type:datetime" json:"create_time"`          // 创建时间
-	UpdateTime    *LocalTime `gorm:"type:datetime" json:"update_time"`          // 更新时间
+	ID            int        `gorm:"primaryKey" json:"id" query:"filter:eq,in;sort"`                                // 主键
+	Username      string     `gorm:"type:varchar(20);not null" json:"username" query:"filter:eq,like;sort;search"`  // 用户名
+	Password      string     `gorm:"type:varchar(40);not null" json:"-"`                                            // 密码
+	LastLoginTime *LocalTime `gorm:"type:datetime" json:"last_login_time" query:"filter:gte,lte,between,null;sort"` // 上次登录时间
+	LoginStatus   int8       `gorm:"type:tinyint" json:"login_status" query:"filter:eq"`                            // 登录状态 0禁用 1启用
+	CreateTime    *LocalTime `gorm:"type:datetime" json:"create_time" query:"filter:gte,lte,between;sort"`          // 创建时间
+	UpdateTime    *LocalTime `gorm:"type:datetime" json:"update_time" query:"sort"`                                 // 更新时间
 }
 
 // TableName 设置表名前缀
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"time"
 )
@@ -11,3 +12,33 @@ func (t *LocalTime) MarshalJSON() ([]byte, error) {
 	tTime := time.Time(*t)
 	return []byte(fmt.Sprintf("\"%v\"", tTime.Format("2006-01-02 15:04:05"))), nil
 }
+
+func (t *LocalTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	tTime, err := time.ParseInLocation(`"2006-01-02 15:04:05"`, string(data), time.Local)
+	if err != nil {
+		return err
+	}
+	*t = LocalTime(tTime)
+	return nil
+}
+
+// Value 写入数据库
+func (t LocalTime) Value() (driver.Value, error) {
+	return time.Time(t), nil
+}
+
+// Scan 从数据库读取
+func (t *LocalTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		*t = LocalTime(v)
+	case nil:
+		*t = LocalTime(time.Time{})
+	default:
+		return fmt.Errorf("无法将 %T 转换为 LocalTime", value)
+	}
+	return nil
+}
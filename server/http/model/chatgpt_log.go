@@ -0,0 +1,15 @@
+package model
+
+// ChatgptLog MongoDB 中的 ChatGPT 对话记录
+type ChatgptLog struct {
+	ID         string `bson:"_id" json:"_id"`                                              // 主键
+	Username   string `bson:"username" json:"username" index:"user_time"`                  // 用户名
+	Question   string `bson:"question" json:"question" index:"content,text"`               // 问题
+	Answer     string `bson:"answer" json:"answer" index:"content,text"`                   // 回答
+	CreateTime int64  `bson:"create_time" json:"create_time" index:"user_time,desc;,desc"` // 创建时间（Unix 秒）
+}
+
+// CollectionName 集合名称
+func (ChatgptLog) CollectionName() string {
+	return "t_chatgpt_log"
+}
@@ -0,0 +1,17 @@
+package model
+
+// File 直传到存储的文件，存储服务回调校验通过后记录
+type File struct {
+	ID          int        `gorm:"primaryKey" json:"id" query:"filter:eq,in;sort"`                       // 主键
+	Disk        string     `gorm:"type:varchar(50);not null" json:"disk" query:"filter:eq"`              // 磁盘名称，对应 Oss 下的配置
+	Key         string     `gorm:"type:varchar(255);not null" json:"key" query:"filter:eq,like;search"`  // 对象 key
+	Size        int64      `gorm:"not null" json:"size" query:"filter:gte,lte,between;sort"`             // 字节数
+	ContentType string     `gorm:"type:varchar(100);not null" json:"content_type" query:"filter:eq,in"`  // 类型
+	ETag        string     `gorm:"type:varchar(100);not null" json:"etag"`                               // 存储返回的 ETag
+	CreateTime  *LocalTime `gorm:"type:datetime" json:"create_time" query:"filter:gte,lte,between;sort"` // 创建时间
+}
+
+// TableName 设置表名前缀
+func (File) TableName() string {
+	return "t_file"
+}
@@ -0,0 +1,16 @@
+package tool
+
+// ChunkInitParams 创建分片上传会话
+type ChunkInitParams struct {
+	FileName string `json:"file_name" form:"file_name" binding:"required"`
+	Size     int64  `json:"size" form:"size" binding:"required,gt=0"`
+	Checksum string `json:"checksum" form:"checksum" binding:"omitempty,oneof=md5 sha256"` // 分片的校验算法，默认 sha256
+}
+
+// PresignParams 直传到存储
+type PresignParams struct {
+	FileName    string `json:"file_name" form:"file_name" binding:"required"`
+	ContentType string `json:"content_type" form:"content_type"`
+	Size        int64  `json:"size" form:"size" binding:"required_if=Method PUT,gte=0"` // PUT 时必须传入文件大小
+	Method      string `json:"method" form:"method" binding:"omitempty,oneof=POST PUT"` // 默认 POST
+}
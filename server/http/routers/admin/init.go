@@ -87,6 +87,9 @@ func initMiddleware() {
 	//初始化参数验证
 	Api.Use(middleware.ValidateParams())
 
+	//Mysql 写入后的读取走主库
+	Api.Use(middleware.DbSticky())
+
 	// 使用 panic 恢复中间件
 	Api.Use(middleware.PanicRecovery())
 }
@@ -1,8 +1,13 @@
 package admin
 
 import (
+	"expvar"
+	"tool/global/enum"
+	"tool/pkg/web_server"
 	"tool/server/http/controller/admin"
 	"tool/server/http/middleware"
+
+	"github.com/gin-gonic/gin"
 )
 
 // RegisterUserRouter 注册用户路由
@@ -14,6 +19,18 @@ func RegisterUserRouter() {
 	{
 		//后台首页
 		adminGroup.GET("/index", admin.Index)
+
+		//管理员列表，按全部查询参数（filter[...] / q / sort / page / size）缓存，管理员数据变更时清除
+		adminGroup.GET("/user/list", web_server.ResponseCache(web_server.ResponseCacheConfig{
+			Topics: []string{enum.TopicAdminChanged},
+		}), admin.UserList)
+
+		//运行指标，包含 MongoDB 命令、SQL 耗时等 expvar 统计
+		adminGroup.GET("/metrics", gin.WrapH(expvar.Handler()))
+
+		//SQL 指纹统计
+		adminGroup.GET("/sql/stats", admin.SqlStats)
+		adminGroup.POST("/sql/stats/reset", admin.SqlStatsReset)
 	}
 
 }
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"tool/global/enum"
+	"tool/pkg/web_server"
+	"tool/server/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 注册路由 - 事件推送（SSE），用于无法使用 WebSocket 的场景，需要后台登录
+func init() {
+
+	web_server.RegisterRoutes("/events",
+		web_server.Route{
+			Method: "GET",
+			Path:   "/stream",
+			Handlers: []gin.HandlerFunc{web_server.SSEHandler(web_server.SSEConfig{
+				Topics: []string{enum.TopicWsBroadcast},
+			})},
+			Middlewares: []gin.HandlerFunc{middleware.LazySessionMiddleware(), middleware.AuthMiddleware()},
+		},
+	)
+}
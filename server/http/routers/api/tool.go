@@ -1,8 +1,11 @@
 package api
 
 import (
+	"reflect"
 	"tool/pkg/web_server"
 	"tool/server/http/controller/tool"
+	"tool/server/http/middleware"
+	request "tool/server/http/request/tool"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,5 +19,68 @@ func init() {
 			Path:     "/upload",
 			Handlers: []gin.HandlerFunc{tool.Upload},
 		},
+		// 直传到存储，需要后台登录
+		web_server.Route{
+			Method:      "POST",
+			Path:        "/presign",
+			Handlers:    []gin.HandlerFunc{tool.Presign},
+			Middlewares: []gin.HandlerFunc{middleware.LazySessionMiddleware(), middleware.AuthMiddleware()},
+			Params:      reflect.TypeOf(request.PresignParams{}),
+		},
+		// 存储服务的上传通知，按签名校验
+		web_server.Route{
+			Method:   "POST",
+			Path:     "/callback/:disk",
+			Handlers: []gin.HandlerFunc{tool.UploadCallback},
+		},
+	)
+
+	// 本地磁盘的文件
+	web_server.RegisterRoutes("/files",
+		web_server.Route{
+			Method:   "GET",
+			Path:     "/:disk/*key",
+			Handlers: []gin.HandlerFunc{tool.File},
+		},
+	)
+
+	// 图片变体
+	web_server.RegisterRoutes("/img",
+		web_server.Route{
+			Method:   "GET",
+			Path:     "/:variant/*key",
+			Handlers: []gin.HandlerFunc{tool.Image},
+		},
+	)
+
+	// 分片上传，断点续传大文件，需要后台登录
+	web_server.RegisterMiddleware("/tool/upload", middleware.LazySessionMiddleware(), middleware.AuthMiddleware())
+	web_server.RegisterRoutes("/tool/upload",
+		web_server.Route{
+			Method:   "POST",
+			Path:     "/sessions",
+			Handlers: []gin.HandlerFunc{tool.ChunkInit},
+			Params:   reflect.TypeOf(request.ChunkInitParams{}),
+		},
+		web_server.Route{
+			Method:   "GET",
+			Path:     "/sessions/:id",
+			Handlers: []gin.HandlerFunc{tool.ChunkStatus},
+		},
+		web_server.Route{
+			Method:   "PUT",
+			Path:     "/sessions/:id/chunks/:number",
+			Handlers: []gin.HandlerFunc{tool.ChunkUpload},
+		},
+		web_server.Route{
+			Method:   "POST",
+			Path:     "/sessions/:id/complete",
+			Handlers: []gin.HandlerFunc{tool.ChunkComplete},
+		},
+		web_server.Route{
+			Method:   "DELETE",
+			Path:     "/sessions/:id",
+			Handlers: []gin.HandlerFunc{tool.ChunkAbort},
+		},
 	)
 }
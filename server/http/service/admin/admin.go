@@ -1,36 +1,76 @@
 package admin
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
+	"tool/global/enum"
 	"tool/global/utils/common"
-	"tool/global/utils/db_client"
+	"tool/global/utils/curd"
+	"tool/global/utils/query_spec"
+	"tool/global/utils/tx"
+	"tool/pkg/cache"
+	"tool/pkg/event_bus"
 	"tool/server/http/model"
+
+	"gorm.io/gorm"
 )
 
-var mysql = db_client.MysqlLocal()
+// cacheTag 管理员数据的缓存标签，数据变更后需要清除
+const cacheTag = "admin"
 
-// Login 登录函数
-func Login(data map[string]any) map[string]any {
-	username := data["username"].(string)
-	// password := data["password"].(string)
+// Login 登录函数，校验通过后在事务中更新上次登录时间
+func Login(ctx context.Context, data map[string]any) (map[string]any, error) {
+	username, _ := data["username"].(string)
+	password, _ := data["password"].(string)
 
-	var users model.Admin
-	if err := mysql.Where(&model.Admin{Username: username}).Find(&users).Error; err != nil {
-		return common.ServiceResponse(400, "用户或密码错误", nil)
+	// 结构体条件会忽略零值，用户名为空时直接返回
+	if username == "" {
+		return common.ServiceResponse(400, "用户或密码错误", nil), nil
 	}
 
-	fmt.Println(common.Md5(users.Password))
-	fmt.Println(data["password"])
+	var response map[string]any
 
-	//md5 判断
-	if users.Password != common.Md5(data["password"].(string)) {
-		return common.ServiceResponse(400, "用户或密码错误", nil)
-	}
+	err := tx.Run(ctx, func(ctx context.Context) error {
+		repo := curd.New[model.Admin]().Where(&model.Admin{Username: username})
 
-	returnData := map[string]any{
-		"id":       users.ID,
-		"username": users.Username,
-	}
+		users, err := repo.First(ctx)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && users.Password != common.Md5(password)) {
+			response = common.ServiceResponse(400, "用户或密码错误", nil)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := repo.Update(ctx, map[string]any{"last_login_time": time.Now()}); err != nil {
+			return err
+		}
+
+		// 提交后清除管理员相关缓存，并通知订阅了该主题的响应缓存
+		tx.AfterCommit(ctx, func(ctx context.Context) {
+			_ = cache.Default().InvalidateTags(ctx, cacheTag)
+			_, _ = event_bus.Publish(ctx, enum.TopicAdminChanged, []byte(strconv.Itoa(users.ID)))
+		})
+
+		response = common.ServiceResponse(200, "登录成功", map[string]any{
+			"id":       users.ID,
+			"username": users.Username,
+		})
+		return nil
+	})
+
+	return response, err
+}
+
+// List 管理员列表，支持 filter / q / sort / page / size 查询参数，结果缓存 1 分钟
+func List(ctx context.Context, spec query_spec.Spec) (curd.PageResult[model.Admin], error) {
+	key := "admin:list:" + common.Md5(fmt.Sprintf("%v", spec))
 
-	return common.ServiceResponse(200, "登录成功", returnData)
+	return cache.Remember(ctx, key, time.Minute, func(ctx context.Context) (curd.PageResult[model.Admin], error) {
+		repo := query_spec.Apply(curd.New[model.Admin](), spec)
+		return repo.Page(ctx, spec.PageRequest())
+	}, cacheTag)
 }
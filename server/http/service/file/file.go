@@ -0,0 +1,29 @@
+package file
+
+import (
+	"context"
+	"time"
+	"tool/global/utils/curd"
+	"tool/pkg/storage"
+	"tool/server/http/model"
+)
+
+// Record 记录上传到存储的文件，存储服务可能重复通知，按磁盘和 key 去重
+func Record(ctx context.Context, disk string, info storage.ObjectInfo) (model.File, error) {
+	now := model.LocalTime(time.Now())
+	file := model.File{
+		Disk:        disk,
+		Key:         info.Key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ETag:        info.ETag,
+		CreateTime:  &now,
+	}
+
+	if err := curd.New[model.File]().Upsert(ctx, &file, []string{"disk", "key"}, "size", "content_type", "etag"); err != nil {
+		return model.File{}, err
+	}
+
+	// 重复通知时主键不会回填，重新查询
+	return curd.New[model.File]().Where(&model.File{Disk: disk, Key: info.Key}).First(ctx)
+}
@@ -0,0 +1,26 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"tool/global/variable"
+	"tool/pkg/udp"
+
+	"go.uber.org/zap"
+)
+
+// 注册任务
+func init() {
+	udp.RegisterJob("test", Test)
+}
+
+// Test 测试任务
+func Test(ctx context.Context, payload []byte) error {
+	var params map[string]any
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return err
+	}
+
+	variable.Logs.Info("Test job received", zap.Any("params", params))
+	return nil
+}
@@ -0,0 +1,71 @@
+package job
+
+import (
+	"context"
+	"time"
+	"tool/global/utils/oss"
+	"tool/global/variable"
+	"tool/pkg/event_manage"
+	"tool/pkg/lock"
+	"tool/pkg/udp"
+
+	"go.uber.org/zap"
+)
+
+// 注册任务
+func init() {
+	udp.RegisterJob("upload_clean", CleanUploads)
+}
+
+// CleanUploads 清理过期的分片上传会话，取消驱动的分片上传或删除临时分片
+func CleanUploads(ctx context.Context, payload []byte) error {
+	uploader, err := oss.NewChunkUploader(oss.LoadChunkConfig(), "")
+	if err != nil {
+		return err
+	}
+
+	count, err := uploader.Clean(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		variable.Logs.Info("清理过期的分片上传会话", zap.Int("count", count))
+	}
+	return nil
+}
+
+// ScheduleUploadClean 定期清理过期的分片上传会话，多个任务服务实例时只由一个实例执行
+//
+// ChunkUpload:
+//
+//	CleanInterval: 600 # 清理间隔(秒)，0 时不定期清理，只能通过 upload_clean 任务触发
+func ScheduleUploadClean() {
+	interval := time.Duration(variable.ConfigYml.GetConfig("ChunkUpload.CleanInterval", 600).(int)) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	(event_manage.CreateEventManageFactory()).Set(variable.EventDestroyPrefix+"UploadClean", func(args ...interface{}) {
+		cancel()
+	})
+
+	run := func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := CleanUploads(ctx, nil); err != nil && ctx.Err() == nil {
+				variable.Logs.Error("清理分片上传会话失败", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+
+	go lock.NewLeaderElector("job:upload_clean", lock.LeaderCallbacks{OnStartedLeading: run}).Run(ctx)
+}
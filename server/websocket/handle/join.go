@@ -1,9 +1,11 @@
 package handle
 
 import (
-	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"tool/global/utils/common"
+	"tool/pkg/session"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -23,6 +25,14 @@ func Join(c *gin.Context) {
 
 	username := c.Query("username")
 
+	// 加入的房间，多个用逗号分隔，如 mongo:t_chatgpt_log 接收该集合的变更
+	// 只能加入 MongoWatch.Rooms 中的房间，并且需要登录
+	roomNames := allowedRooms(c.Query("rooms"))
+	if len(roomNames) > 0 && session.Get(c, "user") == nil {
+		common.Fail(c, 401, "未登录", nil)
+		return
+	}
+
 	// if username != "1" {
 	// 	c.JSON(http.StatusBadRequest, gin.H{
 	// 		"message": "username is not 1",
@@ -38,13 +48,18 @@ func Join(c *gin.Context) {
 	}
 	defer ws.Close()
 
+	writeLocks.Store(ws, &sync.Mutex{})
+	defer writeLocks.Delete(ws)
+
 	//加入房间
+	mu.Lock()
 	clients[username] = ws
+	mu.Unlock()
 
-	log.Printf("Client connected")
+	joinRooms(ws, roomNames)
+	defer leaveRooms(ws)
 
-	//打印clients
-	fmt.Println("clients:", clients)
+	log.Printf("Client connected: %s", username)
 
 	// WebSocket 处理逻辑
 	for {
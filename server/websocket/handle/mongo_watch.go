@@ -0,0 +1,160 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+	"tool/global/variable"
+	"tool/pkg/event_bus"
+	"tool/pkg/event_manage"
+	"tool/pkg/lock"
+	"tool/pkg/mongo"
+	"tool/pkg/redis"
+
+	"go.uber.org/zap"
+)
+
+// WatchMongo 监听 MongoDB 集合变更并推送到同名房间（mongo:集合名）
+//
+// MongoWatch:
+//
+//	Open: false                    # 需要副本集或分片集群
+//	Connection: "Local"            # mongo.yml 中的连接名
+//	Collections: "t_chatgpt_log"   # 监听的集合，多个用逗号分隔
+//	FullDocument: false            # update 时返回完整文档
+//	PushDocument: false            # 推送给房间时保留文档内容（full_document / updated_fields）
+//	Rooms: "mongo:t_chatgpt_log"   # 登录用户可以加入的房间，多个用逗号分隔
+//	TokenStore: "redis"            # resume token 保存位置 redis / mongo，为空时不保存
+//	TokenConnection: "Local"       # redis.yml / mongo.yml 中的连接名
+//	Election: true                 # 多个 ws 实例时只由一个实例监听，依赖分布式锁配置
+func WatchMongo() {
+	config := variable.ConfigYml
+	if !config.GetBool("MongoWatch.Open") {
+		return
+	}
+
+	conn := config.GetString("MongoWatch.Connection")
+	tokens := tokenStore(config.GetString("MongoWatch.TokenStore"), config.GetString("MongoWatch.TokenConnection"))
+
+	var (
+		watchers []*mongo.Watcher
+		topics   = make(map[string]string)
+	)
+	for _, collection := range strings.Split(config.GetString("MongoWatch.Collections"), ",") {
+		if collection = strings.TrimSpace(collection); collection == "" {
+			continue
+		}
+
+		watcher := mongo.NewWatcher(mongo.WatchOptions{
+			Name:         "ws:" + collection,
+			Conn:         conn,
+			Collection:   collection,
+			FullDocument: config.GetBool("MongoWatch.FullDocument"),
+			Tokens:       tokens,
+		})
+		watchers = append(watchers, watcher)
+		topics[watcher.Topic()] = ""
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	(event_manage.CreateEventManageFactory()).Set(variable.EventDestroyPrefix+"MongoWatch", func(args ...interface{}) {
+		cancel()
+	})
+
+	// 每个实例都订阅事件总线，推送给本实例的客户端
+	go pushEvents(ctx, topics, config.GetBool("MongoWatch.PushDocument"))
+
+	run := func(ctx context.Context) {
+		done := make(chan struct{}, len(watchers))
+		for _, watcher := range watchers {
+			go func(watcher *mongo.Watcher) {
+				watcher.Run(ctx)
+				done <- struct{}{}
+			}(watcher)
+		}
+		for range watchers {
+			<-done
+		}
+	}
+
+	if !config.GetBool("MongoWatch.Election") {
+		go run(ctx)
+		return
+	}
+
+	go lock.NewLeaderElector("ws:mongo_watch", lock.LeaderCallbacks{OnStartedLeading: run}).Run(ctx)
+}
+
+// tokenStore 按配置创建 resume token 存储
+func tokenStore(driver, conn string) mongo.TokenStore {
+	if conn == "" {
+		conn = "Local"
+	}
+
+	switch driver {
+	case "redis":
+		return mongo.NewRedisTokenStore(redis.NewClient(conn), "")
+	case "mongo":
+		return mongo.NewMongoTokenStore(conn, "")
+	default:
+		return nil
+	}
+}
+
+// pushEvents 订阅变更事件并推送到同名房间，订阅失败或被关闭时按退避间隔重新订阅
+// withDocument 为 false 时去掉文档内容，只推送操作类型和文档 _id
+func pushEvents(ctx context.Context, topics map[string]string, withDocument bool) {
+	const (
+		minDelay = time.Second
+		maxDelay = time.Minute
+	)
+	delay := minDelay
+
+	for ctx.Err() == nil {
+		events, err := event_bus.Default().Subscribe(ctx, topics)
+		if err == nil {
+			for evt := range events {
+				// 收到事件说明订阅正常，重置重试间隔
+				delay = minDelay
+
+				data := evt.Data
+				if !withDocument {
+					if data, err = stripDocument(data); err != nil {
+						variable.Logs.Warn("解析 MongoDB 变更事件失败", zap.String("topic", evt.Topic), zap.Error(err))
+						continue
+					}
+				}
+				PushRoom(evt.Topic, data)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		variable.Logs.Warn("MongoDB 变更事件订阅中断，稍后重试", zap.Duration("delay", delay), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// stripDocument 去掉变更事件中的文档内容
+func stripDocument(data []byte) ([]byte, error) {
+	var evt mongo.ChangeEvent
+	if err := json.Unmarshal(data, &evt); err != nil {
+		return nil, err
+	}
+
+	evt.FullDocument = nil
+	evt.UpdatedFields = nil
+	evt.RemovedFields = nil
+	return json.Marshal(evt)
+}
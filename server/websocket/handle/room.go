@@ -0,0 +1,111 @@
+package handle
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"tool/global/utils/common"
+	"tool/global/variable"
+
+	"github.com/gorilla/websocket"
+)
+
+// mu 保护 clients、rooms，不能在持有时写入连接，否则一个慢连接会阻塞全部房间和新连接
+var mu sync.Mutex
+
+var rooms = make(map[string]map[*websocket.Conn]struct{}) // 房间 => 客户端
+
+// writeLocks 每个连接的写锁，gorilla/websocket 不支持并发写，连接断开时删除
+var writeLocks sync.Map // *websocket.Conn => *sync.Mutex
+
+// writeWait 单条消息的写超时
+const writeWait = 10 * time.Second
+
+// writeMessage 发送文本消息，同一连接的写入串行执行，连接已断开时返回 websocket.ErrCloseSent
+func writeMessage(ws *websocket.Conn, message []byte) error {
+	lock, ok := writeLocks.Load(ws)
+	if !ok {
+		return websocket.ErrCloseSent
+	}
+
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	_ = ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return ws.WriteMessage(websocket.TextMessage, message)
+}
+
+// allowedRooms 过滤出可以加入的房间，只能加入 MongoWatch.Rooms 中配置的房间
+func allowedRooms(names string) []string {
+	allowed := strings.Split(variable.ConfigYml.GetString("MongoWatch.Rooms"), ",")
+	for i := range allowed {
+		allowed[i] = strings.TrimSpace(allowed[i])
+	}
+
+	var result []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !common.InArray(name, allowed) {
+			log.Printf("Room not allowed: %s", name)
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// joinRooms 加入房间
+func joinRooms(ws *websocket.Conn, names []string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, name := range names {
+		if rooms[name] == nil {
+			rooms[name] = make(map[*websocket.Conn]struct{})
+		}
+		rooms[name][ws] = struct{}{}
+	}
+}
+
+// leaveRooms 离开全部房间
+func leaveRooms(ws *websocket.Conn) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for name, members := range rooms {
+		delete(members, ws)
+		if len(members) == 0 {
+			delete(rooms, name)
+		}
+	}
+}
+
+// PushRoom 给房间内的客户端发送消息，发送失败的客户端会被移出房间
+// 在锁内复制成员列表，在锁外写入
+func PushRoom(room string, message []byte) {
+	mu.Lock()
+	members := make([]*websocket.Conn, 0, len(rooms[room]))
+	for client := range rooms[room] {
+		members = append(members, client)
+	}
+	mu.Unlock()
+
+	for _, client := range members {
+		if err := writeMessage(client, message); err != nil {
+			log.Printf("Error writing message: %v", err)
+			client.Close()
+
+			mu.Lock()
+			if members, ok := rooms[room]; ok {
+				delete(members, client)
+				if len(members) == 0 {
+					delete(rooms, room)
+				}
+			}
+			mu.Unlock()
+		}
+	}
+}
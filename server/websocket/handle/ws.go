@@ -1,8 +1,11 @@
 package handle
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"tool/global/enum"
+	"tool/pkg/event_bus"
 
 	"github.com/gorilla/websocket"
 )
@@ -14,19 +17,34 @@ func HandleMsg() {
 
 		fmt.Println("message:", string(message))
 
-		// 给所有客户端发送消息
+		// 发布到事件总线，供 api 服务的 SSE 订阅
+		if _, err := event_bus.Publish(context.Background(), enum.TopicWsBroadcast, message); err != nil {
+			log.Printf("Error publishing message: %v", err)
+		}
+
+		// 给所有客户端发送消息，在锁外写入
+		mu.Lock()
+		targets := make(map[string]*websocket.Conn, len(clients))
 		for username, client := range clients {
-			err := client.WriteMessage(websocket.TextMessage, message)
+			targets[username] = client
+		}
+		mu.Unlock()
+
+		for username, client := range targets {
+			err := writeMessage(client, message)
 			if err != nil {
 				log.Printf("Error writing message: %v", err)
-				client.Close()
-				delete(clients, username)
 			}
 
-			// 判断是否是关闭消息
-			if string(message) == "close" {
+			// 发送失败或是关闭消息时断开
+			if err != nil || string(message) == "close" {
 				client.Close()
-				delete(clients, username)
+
+				mu.Lock()
+				if clients[username] == client {
+					delete(clients, username)
+				}
+				mu.Unlock()
 			}
 		}
 	}
@@ -2,6 +2,7 @@ package routers
 
 import (
 	"tool/pkg/web_server"
+	"tool/server/http/middleware"
 	"tool/server/websocket/handle"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +16,8 @@ func init() {
 			Method:   "GET",
 			Path:     "/join",
 			Handlers: []gin.HandlerFunc{handle.Join},
+			// 加入房间时需要读取登录会话
+			Middlewares: []gin.HandlerFunc{middleware.LazySessionMiddleware()},
 		},
 	)
 }